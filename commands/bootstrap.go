@@ -0,0 +1,67 @@
+package commands
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+// BootstrapAndMigrate applies the bootstrap, all up migrations and the repeatable migrations to
+// a (started) database
+func BootstrapAndMigrate(db database.Database, observer database.Observer) error {
+	if _, err := db.EnsureMigrationsChangelog(); err != nil {
+		return err
+	}
+
+	if err := db.Bootstrap(observer); err != nil {
+		return err
+	}
+
+	if err := db.ApplyAllUpMigrations(observer); err != nil {
+		return err
+	}
+	log.Debug("Applied all migrations")
+
+	return ApplyRepeatableMigrations(db, observer)
+}
+
+// MigratePending applies the pending up migrations and the repeatable migrations to a database,
+// which was set up before (e.g. in a reused container). A database with an empty changelog is
+// bootstrapped and migrated instead (see BootstrapAndMigrate)
+func MigratePending(db database.Database, observer database.Observer) error {
+	if _, err := db.EnsureMigrationsChangelog(); err != nil {
+		return err
+	}
+	appliedMigrations, err := db.GetAppliedMigrations()
+	if err != nil {
+		return err
+	}
+	if len(appliedMigrations) == 0 {
+		return BootstrapAndMigrate(db, observer)
+	}
+
+	fileMigrations, err := db.GetFileMigrations()
+	if err != nil {
+		return err
+	}
+	appliedLookup := map[string]bool{}
+	for _, mig := range appliedMigrations {
+		appliedLookup[mig.ID] = true
+	}
+	pending := 0
+	for _, mig := range fileMigrations {
+		if !appliedLookup[mig.ID] {
+			pending++
+		}
+	}
+
+	if pending > 0 {
+		if err := db.ApplyMigrationsWithCount(0, true, direction.Up, observer); err != nil {
+			return err
+		}
+		log.Debugf("Applied %d pending migrations", pending)
+	}
+
+	return ApplyRepeatableMigrations(db, observer)
+}
@@ -1,9 +1,10 @@
 package bootstrap
 
 import (
+	"io"
+	"os"
 	"time"
 
-	"github.com/jedib0t/go-pretty/v6/progress"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
@@ -12,10 +13,11 @@ import (
 )
 
 var (
-	mockableLoadDB = driver.LoadDB
+	mockableLoadDB           = driver.LoadDB
+	mockableStdin  io.Reader = os.Stdin
 )
 
-var flags = []cli.Flag{
+var flags = append([]cli.Flag{
 	&cli.StringFlag{
 		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
 		Usage: "(relative) path to the folder containing the database migrations",
@@ -24,7 +26,7 @@ var flags = []cli.Flag{
 		Name: "environment", Aliases: []string{"e"}, Value: "development",
 		Usage: "Name of the environment and the corresponding configuration",
 	},
-}
+}, commands.ProtectedFlags...)
 
 // BootstrapCommand bootstraps an already running (empty) database
 var BootstrapCommand = &cli.Command{
@@ -33,43 +35,29 @@ var BootstrapCommand = &cli.Command{
 	Flags:  flags,
 	Before: commands.NoArguments,
 	Action: func(c *cli.Context) error {
-		var err error
-
-		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		observer, err := commands.NewObserver(c)
 		if err != nil {
 			return err
 		}
 
-		if err := db.WaitForStart(1*time.Second, 10); err != nil {
-			return err
-		}
-		log.Debug("Connected to database")
-
-		if _, err := db.EnsureMigrationsChangelog(); err != nil {
+		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
 			return err
 		}
+		defer db.Close()
 
-		if err := db.Bootstrap(); err != nil {
+		err = commands.ConfirmProtected(c, mockableStdin, db.IsProtected(), []string{
+			"apply the bootstrap migration", "apply all up and repeatable migrations",
+		})
+		if err != nil {
 			return err
 		}
-		log.Info("Applied bootstrap migration")
-
-		pw := progress.NewWriter()
-		pw.SetAutoStop(true)
-		pw.SetTrackerPosition(progress.PositionRight)
-		pw.SetUpdateFrequency(time.Millisecond * 250)
-
-		go pw.Render()
 
-		if err := db.ApplyAllUpMigrations(pw); err != nil {
+		if err := db.WaitForStart(1*time.Second, 10); err != nil {
 			return err
 		}
-		log.Debug("Applied all migrations")
-
-		if pw.IsRenderInProgress() {
-			time.Sleep(time.Millisecond * 251)
-		}
+		log.Debug("Connected to database")
 
-		return nil
+		return commands.BootstrapAndMigrate(db, observer)
 	},
 }
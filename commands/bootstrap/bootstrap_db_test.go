@@ -48,7 +48,8 @@ func TestBootstrapDbDefaults(t *testing.T) {
 	fakeDb.AssertEnsureMigrationsChangelogCalled(t, true)
 	fakeDb.AssertBootstrapCalled(t, true)
 	fakeDb.AssertApplyAllUpMigrationsCalled(t, true)
-
+	fakeDb.AssertApplyRepeatableMigrationsCalled(t, true)
+	fakeDb.AssertCloseCalled(t, true)
 }
 
 func TestBootstrapDbWithFlags(t *testing.T) {
@@ -68,4 +69,5 @@ func TestBootstrapDbWithFlags(t *testing.T) {
 	fakeDb.AssertEnsureMigrationsChangelogCalled(t, true)
 	fakeDb.AssertBootstrapCalled(t, true)
 	fakeDb.AssertApplyAllUpMigrationsCalled(t, true)
+	fakeDb.AssertApplyRepeatableMigrationsCalled(t, true)
 }
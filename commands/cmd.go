@@ -2,6 +2,7 @@ package commands
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/urfave/cli/v2"
 )
@@ -13,3 +14,11 @@ func NoArguments(c *cli.Context) error {
 	}
 	return nil
 }
+
+// OneArgument exits the program unless exactly one argument was passed
+func OneArgument(c *cli.Context) error {
+	if c.NArg() != 1 {
+		return fmt.Errorf("This command requires exactly one argument: %s", c.Command.ArgsUsage)
+	}
+	return nil
+}
@@ -1,12 +1,17 @@
 package createseed
 
 import (
+	"compress/gzip"
 	"fmt"
+	"io"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 
 	"github.com/urfave/cli/v2"
 
 	"go-migrations/commands"
+	"go-migrations/database"
 	"go-migrations/database/driver"
 	"go-migrations/utils"
 )
@@ -15,7 +20,18 @@ var (
 	mockableLoadDB = driver.LoadDB
 )
 
+// stdoutTarget writes the seed to stdout instead of a file
+const stdoutTarget = "-"
+
 var flags = []cli.Flag{
+	&cli.StringSliceFlag{
+		Name:  "app",
+		Usage: "restrict the migrations to this application folder (can be repeated)",
+	},
+	&cli.StringFlag{
+		Name:  "to",
+		Usage: "end the seed with the migration of this id (e.g. to match an older state)",
+	},
 	&cli.StringFlag{
 		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
 		Usage: "(relative) path to the folder containing the database migrations",
@@ -26,40 +42,108 @@ var flags = []cli.Flag{
 	},
 	&cli.StringFlag{
 		Name: "target", Aliases: []string{"t"}, Value: "seed.sql",
-		Usage: "Name and path of the file containing the seed sql",
+		Usage: "Name and path of the file containing the seed sql ('-' for stdout)",
+	},
+	&cli.BoolFlag{
+		Name: "force", Aliases: []string{"f"},
+		Usage: "overwrite an existing target file",
+	},
+	&cli.BoolFlag{
+		Name: "gzip", Aliases: []string{"z"},
+		Usage: "compress the seed with gzip",
+	},
+	&cli.BoolFlag{
+		Name:  "transaction",
+		Usage: "wrap the seed in a single transaction (BEGIN/COMMIT)",
+	},
+	&cli.BoolFlag{
+		Name:  "idempotent",
+		Usage: "create the changelog only if necessary and skip migrations found in the changelog",
 	},
 }
 
-// CreateSeedCommand creates an SQL file that can be used to seed the database directly
+// CreateSeedCommand creates an SQL file that can be used to seed the database directly.
+// The seed can be written to stdout (e.g. to pipe it into psql) and compressed
 var CreateSeedCommand = &cli.Command{
 	Name:   "create-seed",
 	Usage:  "creates an SQL file that can be used to seed the database directly",
 	Flags:  flags,
 	Before: commands.NoArguments,
-	Action: func(c *cli.Context) (err error) {
-		exists, err := utils.FileExists(c.String("target"))
-		if err != nil {
-			return fmt.Errorf("Could not check existence of %s: %v", c.String("target"), err)
-		} else if exists {
-			return fmt.Errorf("The file %s already exists", c.String("target"))
+	Action: func(c *cli.Context) error {
+		toStdout := c.String("target") == stdoutTarget
+		if !toStdout && !c.Bool("force") {
+			exists, err := utils.FileExists(c.String("target"))
+			if err != nil {
+				return fmt.Errorf("Could not check existence of %s: %v", c.String("target"), err)
+			} else if exists {
+				return fmt.Errorf("The file %s already exists", c.String("target"))
+			}
 		}
 
 		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
 		if err != nil {
 			return err
 		}
+		defer db.Close()
+		db.SetApplications(c.StringSlice("app"))
 
-		target, err := os.Create(c.String("target"))
-		defer target.Close()
-		if err != nil {
-			return fmt.Errorf("Could not create the target file: %v", err)
+		options := database.SeedOptions{
+			Transaction: c.Bool("transaction"),
+			Idempotent:  c.Bool("idempotent"),
+			ToID:        c.String("to"),
+		}
+		if toStdout {
+			return writeSeed(c, db, c.App.Writer, options)
 		}
+		return writeSeedFile(c, db, c.String("target"), options)
+	},
+}
 
-		err = db.GenerateSeedSQL(target)
+// writeSeedFile writes the seed into a temporary file next to the target, which replaces the
+// target only once the seed is complete
+func writeSeedFile(
+	c *cli.Context, db database.Database, target string, options database.SeedOptions,
+) (err error) {
+	tmpFile, err := ioutil.TempFile(filepath.Dir(target), filepath.Base(target)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("Could not create the target file: %v", err)
+	}
+	defer func() {
 		if err != nil {
-			return err
+			tmpFile.Close()
+			os.Remove(tmpFile.Name())
 		}
+	}()
 
-		return nil
-	},
+	if err = writeSeed(c, db, tmpFile, options); err != nil {
+		return err
+	}
+	if err = tmpFile.Chmod(0644); err != nil {
+		return fmt.Errorf("Could not write the target file: %v", err)
+	}
+	if err = tmpFile.Close(); err != nil {
+		return fmt.Errorf("Could not write the target file: %v", err)
+	}
+	if err = os.Rename(tmpFile.Name(), target); err != nil {
+		return fmt.Errorf("Could not replace the target file: %v", err)
+	}
+	return nil
+}
+
+// writeSeed writes the seed (compressed with --gzip) into the target
+func writeSeed(
+	c *cli.Context, db database.Database, target io.Writer, options database.SeedOptions,
+) error {
+	if !c.Bool("gzip") {
+		return db.GenerateSeedSQL(target, options)
+	}
+
+	gzipWriter := gzip.NewWriter(target)
+	if err := db.GenerateSeedSQL(gzipWriter, options); err != nil {
+		return err
+	}
+	if err := gzipWriter.Close(); err != nil {
+		return fmt.Errorf("Could not compress the seed: %v", err)
+	}
+	return nil
 }
@@ -1,10 +1,14 @@
 package createseed
 
 import (
+	"bytes"
+	"compress/gzip"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"log"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -52,6 +56,7 @@ func TestCreateSeedFlags(t *testing.T) {
 	}
 
 	fakeDb.AssertGenerateSeedSQLCalled(t, true)
+	fakeDb.AssertCloseCalled(t, true)
 }
 
 func TestErrorForExistingTargets(t *testing.T) {
@@ -71,3 +76,84 @@ func TestErrorForExistingTargets(t *testing.T) {
 
 	fakeDb.AssertGenerateSeedSQLCalled(t, false)
 }
+
+func TestCreateSeedOverwriteWithForce(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpy
+
+	target, _ := ioutil.TempFile(os.TempDir(), "g-mig-test-")
+	defer os.Remove(target.Name())
+	target.WriteString("old seed, which is longer than the new one")
+
+	args := []string{"sth.exe", "create-seed", "-t", target.Name(), "--force", "--transaction"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeDb.AssertGenerateSeedSQLCalledWith(t, database.SeedOptions{Transaction: true})
+	if content, _ := ioutil.ReadFile(target.Name()); string(content) != internal.FakeSeedSQL {
+		t.Errorf("Expected the file to be overwritten, but got: %s", content)
+	}
+}
+
+func TestCreateSeedKeepsTargetOnError(t *testing.T) {
+	mockableLoadDB = func(migrationsPath, environment string) (database.Database, error) {
+		fakeDb = internal.FakeDbWithSpy{GenerateSeedErr: errors.New("broken migration")}
+		return &fakeDb, nil
+	}
+
+	targetDir, _ := ioutil.TempDir(os.TempDir(), "g-mig-test-")
+	defer os.RemoveAll(targetDir)
+	target := filepath.Join(targetDir, "seed.sql")
+	ioutil.WriteFile(target, []byte("old seed"), 0644)
+
+	args := []string{"sth.exe", "create-seed", "-t", target, "--force"}
+	if err := app.Run(args); err == nil || err.Error() != "broken migration" {
+		t.Errorf("Expected the error of the seed, but got: %v", err)
+	}
+
+	if content, _ := ioutil.ReadFile(target); string(content) != "old seed" {
+		t.Errorf("Expected the target to be kept, but got: %s", content)
+	}
+	if files, _ := ioutil.ReadDir(targetDir); len(files) != 1 {
+		t.Errorf("Expected the temporary file to be removed, but found %d files", len(files))
+	}
+}
+
+func TestCreateSeedToStdoutWithGzip(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpy
+	var stdout bytes.Buffer
+	app.Writer = &stdout
+	defer func() { app.Writer = os.Stdout }()
+
+	args := []string{"sth.exe", "create-seed", "-t", "-", "--gzip", "--idempotent"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeDb.AssertGenerateSeedSQLCalledWith(t, database.SeedOptions{Idempotent: true})
+	gzipReader, err := gzip.NewReader(&stdout)
+	if err != nil {
+		t.Fatalf("Expected gzip output, but got: %v", err)
+	}
+	if content, _ := ioutil.ReadAll(gzipReader); string(content) != internal.FakeSeedSQL {
+		t.Errorf("Expected the seed on stdout, but got: %s", content)
+	}
+}
+
+func TestCreateSeedToIDForApplications(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpy
+
+	target := fmt.Sprintf("%s/go-mig-test-%d", os.TempDir(), time.Now().UnixNano())
+	defer os.Remove(target)
+
+	args := []string{
+		"sth.exe", "create-seed", "-t", target, "--to", "20200101000001",
+		"--app", "common", "--app", "sub_app",
+	}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeDb.AssertGenerateSeedSQLCalledWith(t, database.SeedOptions{ToID: "20200101000001"})
+	fakeDb.AssertSetApplicationsCalledWith(t, []string{"common", "sub_app"})
+}
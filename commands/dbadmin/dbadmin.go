@@ -0,0 +1,196 @@
+package dbadmin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+	"go-migrations/database"
+	"go-migrations/database/config"
+	"go-migrations/database/driver"
+)
+
+// variables to allow mocking for tests
+var (
+	mockableLoadConfig           = driver.LoadConfig
+	mockableInitAdmin            = driver.InitAdmin
+	mockableInitDB               = driver.InitDB
+	mockableStdin      io.Reader = os.Stdin
+)
+
+var flags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
+		Usage: "(relative) path to the folder containing the database migrations",
+	},
+	&cli.StringFlag{
+		Name: "environment", Aliases: []string{"e"}, Value: "development",
+		Usage: "Name of the environment and the corresponding configuration",
+	},
+}, commands.ProtectedFlags...)
+
+var createFlags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name:  "owner",
+		Usage: "owner of the created database (overrides the owner of the configuration)",
+	},
+	&cli.StringFlag{
+		Name:  "template",
+		Usage: "template of the created database (overrides the template of the configuration)",
+	},
+}, flags...)
+
+var destructiveFlags = append([]cli.Flag{commands.AllowDestructiveFlag}, flags...)
+
+// DbCommands create and drop the database of an environment
+var DbCommands = &cli.Command{
+	Name:  "db",
+	Usage: "create, drop or reset the database of an environment",
+	Subcommands: []*cli.Command{
+		dbCreateCommand,
+		dbDropCommand,
+		dbResetCommand,
+	},
+}
+
+var dbCreateCommand = &cli.Command{
+	Name:   "create",
+	Usage:  "creates the (empty) database of the environment",
+	Flags:  createFlags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		plan := []string{"create the database"}
+		return withAdmin(c, false, plan, func(cfg config.Config, admin database.Admin) error {
+			exists, err := admin.DatabaseExists()
+			if err != nil {
+				return err
+			}
+			if exists {
+				return fmt.Errorf("The database %s already exists", cfg.Db.Name)
+			}
+
+			if err := admin.CreateDatabase(); err != nil {
+				return err
+			}
+			log.Infof("Created database %s", cfg.Db.Name)
+			return nil
+		})
+	},
+}
+
+var dbDropCommand = &cli.Command{
+	Name:   "drop",
+	Usage:  "terminates all sessions of the database of the environment and drops it",
+	Flags:  destructiveFlags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		plan := []string{"terminate all sessions of the database", "drop the database"}
+		return withAdmin(c, true, plan, func(cfg config.Config, admin database.Admin) error {
+			if err := admin.DropDatabase(); err != nil {
+				return err
+			}
+			log.Infof("Dropped database %s", cfg.Db.Name)
+			return nil
+		})
+	},
+}
+
+var dbResetCommand = &cli.Command{
+	Name:  "reset",
+	Usage: "drops and recreates the database of the environment and applies all migrations",
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name:  "with-seeds",
+			Usage: "apply the data seeds of the environment after the migrations",
+		},
+	}, append([]cli.Flag{commands.AllowDestructiveFlag}, createFlags...)...),
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		observer, err := commands.NewObserver(c)
+		if err != nil {
+			return err
+		}
+		plan := []string{
+			"terminate all sessions of the database", "drop and recreate the database",
+			"apply the bootstrap migration", "apply all up and repeatable migrations",
+		}
+		var cfg config.Config
+		err = withAdmin(c, true, plan, func(adminCfg config.Config, admin database.Admin) error {
+			cfg = adminCfg
+			if err := admin.DropDatabase(); err != nil {
+				return err
+			}
+			if err := admin.CreateDatabase(); err != nil {
+				return err
+			}
+			log.Infof("Recreated database %s", cfg.Db.Name)
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		db, err := mockableInitDB(cfg)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := db.WaitForStart(1*time.Second, 10); err != nil {
+			return err
+		}
+		if err := commands.BootstrapAndMigrate(db, observer); err != nil {
+			return err
+		}
+
+		if c.Bool("with-seeds") {
+			return commands.ApplyDataSeeds(db, observer)
+		}
+		return nil
+	},
+}
+
+// withAdmin loads the configuration (with the owner and template flags), confirms the plan on
+// protected environments and runs the action with a connection to the maintenance database.
+// Destructive commands (drop and reset) are blocked on protected environments unless
+// --allow-destructive is passed
+func withAdmin(
+	c *cli.Context, destructive bool, plan []string, run func(config.Config, database.Admin) error,
+) error {
+	cfg, err := mockableLoadConfig(c.String("migrations-path"), c.String("environment"))
+	if err != nil {
+		return err
+	}
+	if destructive {
+		if err := commands.RefuseDestructive(c, cfg.Protected, "db "+c.Command.Name); err != nil {
+			return err
+		}
+	}
+	if err := commands.ConfirmProtected(c, mockableStdin, cfg.Protected, plan); err != nil {
+		return err
+	}
+	if c.String("owner") != "" {
+		cfg.Owner = c.String("owner")
+	}
+	if c.String("template") != "" {
+		cfg.Template = c.String("template")
+	}
+
+	admin, err := mockableInitAdmin(cfg)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	if err := admin.WaitForStart(1*time.Second, 10); err != nil {
+		return err
+	}
+	log.Debug("Connected to maintenance database")
+
+	return run(cfg, admin)
+}
@@ -0,0 +1,145 @@
+package dbadmin
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/database"
+	"go-migrations/database/config"
+	"go-migrations/internal"
+)
+
+var fakeAdmin internal.FakeAdminWithSpy
+var fakeDb internal.FakeDbWithSpy
+var adminConfig config.Config
+var protected bool
+
+func fakeLoadConfig(migrationsPath, environment string) (config.Config, error) {
+	cfg := config.Config{MigrationsPath: migrationsPath, Environment: environment}
+	cfg.Db.Name = "zlab"
+	cfg.Owner = "zlab_owner"
+	cfg.Protected = protected
+	return cfg, nil
+}
+
+func fakeInitAdmin(cfg config.Config) (database.Admin, error) {
+	adminConfig = cfg
+	return &fakeAdmin, nil
+}
+
+func fakeInitDB(cfg config.Config) (database.Database, error) {
+	fakeDb = internal.FakeDbWithSpy{}
+	return &fakeDb, nil
+}
+
+var app = cli.NewApp()
+
+func TestMain(m *testing.M) {
+	app.Commands = []*cli.Command{
+		DbCommands,
+	}
+	mockableLoadConfig = fakeLoadConfig
+	mockableInitAdmin = fakeInitAdmin
+	mockableInitDB = fakeInitDB
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+func TestDbCreate(t *testing.T) {
+	fakeAdmin = internal.FakeAdminWithSpy{}
+
+	args := []string{"sth.exe", "db", "create", "--template", "template0"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	if adminConfig.Owner != "zlab_owner" || adminConfig.Template != "template0" {
+		t.Errorf("Expected the owner of the config and the template flag, but got %+v", adminConfig)
+	}
+	fakeAdmin.AssertCreateDatabaseCalled(t, true)
+	fakeAdmin.AssertDropDatabaseCalled(t, false)
+	fakeAdmin.AssertCloseCalled(t, true)
+}
+
+func TestDbCreateExisting(t *testing.T) {
+	fakeAdmin = internal.FakeAdminWithSpy{Exists: true}
+
+	if err := app.Run([]string{"sth.exe", "db", "create"}); err == nil {
+		t.Errorf("Expected an error for an existing database")
+	}
+	fakeAdmin.AssertCreateDatabaseCalled(t, false)
+}
+
+func TestDbDrop(t *testing.T) {
+	fakeAdmin = internal.FakeAdminWithSpy{Exists: true}
+
+	if err := app.Run([]string{"sth.exe", "db", "drop", "-e", "test"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeAdmin.AssertDropDatabaseCalled(t, true)
+	fakeAdmin.AssertCreateDatabaseCalled(t, false)
+}
+
+func TestDbReset(t *testing.T) {
+	fakeAdmin = internal.FakeAdminWithSpy{Exists: true}
+
+	if err := app.Run([]string{"sth.exe", "db", "reset", "--with-seeds"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeAdmin.AssertDropDatabaseCalled(t, true)
+	fakeAdmin.AssertCreateDatabaseCalled(t, true)
+	fakeDb.AssertWaitForStartCalled(t, true)
+	fakeDb.AssertBootstrapCalled(t, true)
+	fakeDb.AssertApplyAllUpMigrationsCalled(t, true)
+	fakeDb.AssertApplyDataSeedsCalled(t, true)
+	fakeDb.AssertCloseCalled(t, true)
+}
+
+func TestDbProtectedEnvironment(t *testing.T) {
+	protected = true
+	defer func() { protected = false }()
+	confirm := []string{"--yes", "--confirm-environment", "production", "-e", "production"}
+
+	for _, command := range []string{"drop", "reset"} {
+		t.Run(command, func(t *testing.T) {
+			fakeAdmin = internal.FakeAdminWithSpy{Exists: true}
+
+			args := append([]string{"sth.exe", "db", command}, confirm...)
+			if err := app.Run(args); err == nil {
+				t.Errorf("Expected an error without --allow-destructive")
+			}
+			fakeAdmin.AssertDropDatabaseCalled(t, false)
+
+			if err := app.Run(append(args, "--allow-destructive")); err != nil {
+				t.Errorf("Error running command - %s", err)
+			}
+			fakeAdmin.AssertDropDatabaseCalled(t, true)
+		})
+	}
+}
+
+func TestDbCreateProtectedEnvironment(t *testing.T) {
+	protected = true
+	defer func() { protected = false }()
+	fakeAdmin = internal.FakeAdminWithSpy{}
+
+	mockableStdin = strings.NewReader("staging\n")
+	if err := app.Run([]string{"sth.exe", "db", "create", "-e", "production"}); err == nil {
+		t.Errorf("Expected an error for a wrong environment")
+	}
+	fakeAdmin.AssertCreateDatabaseCalled(t, false)
+
+	mockableStdin = strings.NewReader("production\n")
+	if err := app.Run([]string{"sth.exe", "db", "create", "-e", "production"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeAdmin.AssertCreateDatabaseCalled(t, true)
+	mockableStdin = os.Stdin
+}
@@ -2,6 +2,8 @@ package migrate
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"strconv"
 
 	"github.com/urfave/cli/v2"
@@ -11,7 +13,8 @@ import (
 
 // variables to allow mocking for tests
 var (
-	mockableLoadDB = driver.LoadDB
+	mockableLoadDB           = driver.LoadDB
+	mockableStdin  io.Reader = os.Stdin
 )
 
 func checkFlags(c *cli.Context) error {
@@ -52,5 +55,10 @@ var MigrateCommands = &cli.Command{
 		migrateUpCommand,
 		migrateDownCommand,
 		migrateStatusCommand,
+		migrateBaselineCommand,
+		migrateMarkCommand,
+		migrateSquashCommand,
+		migrateSquashChangelogCommand,
+		migrateLintCommand,
 	},
 }
@@ -0,0 +1,72 @@
+package migrate
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+)
+
+var baselineFlags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name: "to", Aliases: []string{"t"}, Required: true,
+		Usage: "ID of the last migration to mark as applied",
+	},
+	&cli.StringSliceFlag{
+		Name:  "app",
+		Usage: "restrict the migrations to this application folder (can be repeated)",
+	},
+	&cli.StringFlag{
+		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
+		Usage: "(relative) path to the folder containing the database migrations",
+	},
+	&cli.StringFlag{
+		Name: "environment", Aliases: []string{"e"}, Value: "development",
+		Usage: "Name of the environment and the corresponding configuration",
+	},
+}, commands.ProtectedFlags...)
+
+// migrateBaselineCommand marks migrations as applied without executing them
+var migrateBaselineCommand = &cli.Command{
+	Name:   "baseline",
+	Usage:  "marks all migrations up to an ID as applied without executing them",
+	Flags:  baselineFlags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		db.SetApplications(c.StringSlice("app"))
+
+		if err := db.WaitForStart(100*time.Millisecond, 1); err != nil {
+			return err
+		}
+		log.Info("Connected to database")
+
+		err = confirmChanges(c, db, []string{
+			fmt.Sprintf("mark all migrations up to %s as applied", c.String("to")),
+		})
+		if err != nil {
+			return err
+		}
+
+		created, err := db.EnsureMigrationsChangelog()
+		if created {
+			log.Info("Created changelog table")
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := db.Baseline(c.String("to")); err != nil {
+			return err
+		}
+		log.Infof("Marked all migrations up to %s as applied", c.String("to"))
+		return nil
+	},
+}
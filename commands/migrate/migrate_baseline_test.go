@@ -0,0 +1,49 @@
+package migrate
+
+import (
+	"testing"
+
+	"go-migrations/database"
+	"go-migrations/internal"
+)
+
+var dbLoadArgsBaseline []string
+var fakeDbBaseline internal.FakeDbWithSpy
+
+func fakeLoadWithSpyBaseline(migrationsPath, environment string) (database.Database, error) {
+	dbLoadArgsBaseline = []string{migrationsPath, environment}
+	fakeDbBaseline = internal.FakeDbWithSpy{}
+	return &fakeDbBaseline, nil
+}
+
+func TestMigrateBaseline(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpyBaseline
+
+	args := []string{
+		"sth.exe", "migrate", "baseline", "--to", "20200101000000", "-p", "/my/path", "-e", "prod",
+	}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	expected := []string{"/my/path", "prod"}
+	if !internal.StrSliceEqual(dbLoadArgsBaseline, expected) {
+		t.Errorf("Expected to load db with '%v', but got %s", expected, dbLoadArgsBaseline)
+	}
+
+	fakeDbBaseline.AssertWaitForStartCalled(t, true)
+	fakeDbBaseline.AssertEnsureMigrationsChangelogCalled(t, true)
+	fakeDbBaseline.AssertBaselineCalledWith(t, "20200101000000")
+	fakeDbBaseline.AssertApplyMigrationsWithCountCalled(t, false)
+	fakeDbBaseline.AssertCloseCalled(t, true)
+}
+
+func TestMigrateBaselineRequiresTo(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpyBaseline
+	fakeDbBaseline = internal.FakeDbWithSpy{}
+
+	args := []string{"sth.exe", "migrate", "baseline"}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error without the --to flag")
+	}
+}
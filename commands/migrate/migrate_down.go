@@ -10,7 +10,15 @@ import (
 	"go-migrations/internal/direction"
 )
 
-var downFlags = []cli.Flag{
+var downFlags = append([]cli.Flag{
+	&cli.BoolFlag{
+		Name:  "allow-out-of-order",
+		Usage: "allow skipped (older) migrations to be applied after newer ones",
+	},
+	&cli.StringSliceFlag{
+		Name:  "app",
+		Usage: "restrict the migrations to this application folder (can be repeated)",
+	},
 	&cli.StringFlag{
 		Name: "count", Aliases: []string{"c"},
 		Usage: "number of migrations to apply (default action is to apply one)",
@@ -23,6 +31,7 @@ var downFlags = []cli.Flag{
 		Name: "only", Aliases: []string{"o"},
 		Usage: "apply only one migration containing this string",
 	},
+	commands.AllowDestructiveFlag,
 	&cli.StringFlag{
 		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
 		Usage: "(relative) path to the folder containing the database migrations",
@@ -31,7 +40,7 @@ var downFlags = []cli.Flag{
 		Name: "environment", Aliases: []string{"e"}, Value: "development",
 		Usage: "Name of the environment and the corresponding configuration",
 	},
-}
+}, commands.ProtectedFlags...)
 
 // migrateDownCommand executes down migrations
 var migrateDownCommand = &cli.Command{
@@ -44,17 +53,42 @@ var migrateDownCommand = &cli.Command{
 		if err := checkFlags(c); err != nil {
 			return err
 		}
+		observer, err := commands.NewObserver(c)
+		if err != nil {
+			return err
+		}
 
 		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
 		if err != nil {
 			return err
 		}
+		defer db.Close()
+		if err := commands.RefuseDestructive(c, db.IsProtected(), "migrate down"); err != nil {
+			return err
+		}
+		db.SetApplications(c.StringSlice("app"))
+		if c.Bool("allow-out-of-order") {
+			db.SetAllowOutOfOrder(true)
+		}
 
 		if err := db.WaitForStart(100*time.Millisecond, 1); err != nil {
 			return err
 		}
 		log.Info("Connected to database")
 
+		count := c.Uint("count")
+		if count == 0 && !c.Bool("all") {
+			count = 1
+		}
+		if c.String("only") == "" {
+			if err := db.EnsureConsistentMigrations(); err != nil {
+				return err
+			}
+		}
+		if err := confirmMigrations(c, db, count, direction.Down); err != nil {
+			return err
+		}
+
 		created, err := db.EnsureMigrationsChangelog()
 		if created {
 			log.Warning("Created changelog table")
@@ -64,19 +98,12 @@ var migrateDownCommand = &cli.Command{
 		}
 
 		if c.String("only") != "" {
-			if err := db.ApplySpecificMigration(c.String("only"), direction.Down); err != nil {
+			err = db.ApplySpecificMigration(c.String("only"), direction.Down, observer)
+			if err != nil {
 				return err
 			}
 		} else {
-			if err := db.EnsureConsistentMigrations(); err != nil {
-				return err
-			}
-
-			upCount := c.Uint("count")
-			if upCount == 0 && !c.Bool("all") {
-				upCount = 1
-			}
-			err = db.ApplyMigrationsWithCount(upCount, c.Bool("all"), direction.Down)
+			err = db.ApplyMigrationsWithCount(count, c.Bool("all"), direction.Down, observer)
 			if err != nil {
 				return err
 			}
@@ -35,6 +35,7 @@ func TestMigrateDownDefaults(t *testing.T) {
 	fakeDbDown.AssertEnsureConsistentMigrationsCalled(t, true)
 	fakeDbDown.AssertApplyMigrationsWithCountCalledWith(t, 1, false, direction.Down)
 	fakeDbDown.AssertApplySpecificMigrationCalled(t, false)
+	fakeDbDown.AssertCloseCalled(t, true)
 }
 
 func TestMigrateDownWithCount(t *testing.T) {
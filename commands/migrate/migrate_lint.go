@@ -0,0 +1,74 @@
+package migrate
+
+import (
+	"fmt"
+	"path/filepath"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+	"go-migrations/database/lint"
+)
+
+var lintFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name: "format", Aliases: []string{"f"}, Value: "text",
+		Usage: "output format of the findings: text or json",
+	},
+	&cli.StringSliceFlag{
+		Name:  "disable",
+		Usage: "disable this rule (can be repeated)",
+	},
+	&cli.StringFlag{
+		Name: "config", Aliases: []string{"c"},
+		Usage: "path to the lint configuration (default: <migrations-path>/_lint.yaml)",
+	},
+	&cli.StringFlag{
+		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
+		Usage: "(relative) path to the folder containing the database migrations",
+	},
+}
+
+// migrateLintCommand checks the migration files without connecting to a database
+var migrateLintCommand = &cli.Command{
+	Name:   "lint",
+	Usage:  "checks the migration files for problems without connecting to a database",
+	Flags:  lintFlags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		write := lint.WriteText
+		switch c.String("format") {
+		case "text":
+		case "json":
+			write = lint.WriteJSON
+		default:
+			return fmt.Errorf("Unknown format %s, expected text or json", c.String("format"))
+		}
+
+		// only the default configuration file is optional
+		configPath := c.String("config")
+		if configPath == "" {
+			configPath = filepath.Join(c.String("migrations-path"), "_lint.yaml")
+		}
+		config, err := lint.LoadConfig(configPath, c.String("config") != "")
+		if err != nil {
+			return err
+		}
+		config.Disabled = append(config.Disabled, c.StringSlice("disable")...)
+
+		findings, err := lint.Lint(c.String("migrations-path"), config)
+		if err != nil {
+			return err
+		}
+		if err := write(c.App.Writer, findings); err != nil {
+			return err
+		}
+
+		if len(findings) > 0 {
+			return fmt.Errorf("Found %d problems in the migration files", len(findings))
+		}
+		log.Info("Found no problems in the migration files")
+		return nil
+	},
+}
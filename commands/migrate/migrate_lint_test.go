@@ -0,0 +1,63 @@
+package migrate
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMigrateLint(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+	os.MkdirAll(filepath.Join(basePath, "common", "verify"), 0777)
+	ioutil.WriteFile(
+		filepath.Join(basePath, "common", "20171101000001_foo.sql"),
+		[]byte("TRUNCATE a;\n-- //@UNDO\nSELECT 1;\n"), 0777,
+	)
+	ioutil.WriteFile(
+		filepath.Join(basePath, "common", "verify", "20171101000001_foo.sql"),
+		[]byte("SELECT 1"), 0777,
+	)
+
+	var output bytes.Buffer
+	app.Writer = &output
+	defer func() { app.Writer = os.Stdout }()
+
+	args := []string{"sth.exe", "migrate", "lint", "-p", basePath, "--format", "json"}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error for the findings, but got nothing")
+	}
+	var findings []map[string]interface{}
+	if err := json.Unmarshal(output.Bytes(), &findings); err != nil {
+		t.Fatalf("Expected JSON output, but got '%s': %v", output.String(), err)
+	}
+	if len(findings) != 1 || findings[0]["rule"] != "truncate" {
+		t.Errorf("Expected one truncate finding, but got %v", findings)
+	}
+
+	output.Reset()
+	args = []string{"sth.exe", "migrate", "lint", "-p", basePath, "--disable", "truncate"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Expected no error with the disabled rule, but got: %v", err)
+	}
+	if output.Len() > 0 {
+		t.Errorf("Expected no output, but got '%s'", output.String())
+	}
+
+	args = []string{"sth.exe", "migrate", "lint", "-p", basePath, "--format", "xml"}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+
+	missingConfig := filepath.Join(basePath, "missing.yaml")
+	args = []string{"sth.exe", "migrate", "lint", "-p", basePath, "--config", missingConfig}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error for a missing config file")
+	}
+}
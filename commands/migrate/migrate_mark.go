@@ -0,0 +1,114 @@
+package migrate
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+var markFlags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name: "only", Aliases: []string{"o"}, Required: true,
+		Usage: "mark only one migration containing this string",
+	},
+	&cli.StringSliceFlag{
+		Name:  "app",
+		Usage: "restrict the migrations to this application folder (can be repeated)",
+	},
+	&cli.StringFlag{
+		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
+		Usage: "(relative) path to the folder containing the database migrations",
+	},
+	&cli.StringFlag{
+		Name: "environment", Aliases: []string{"e"}, Value: "development",
+		Usage: "Name of the environment and the corresponding configuration",
+	},
+}, commands.ProtectedFlags...)
+
+// migrateMarkCommand adds or removes migrations to / from the changelog without executing them
+var migrateMarkCommand = &cli.Command{
+	Name:  "mark",
+	Usage: "marks a migration as applied or pending without executing it",
+	Subcommands: []*cli.Command{
+		{
+			Name:   "applied",
+			Usage:  "adds a pending migration to the changelog without executing it",
+			Flags:  markFlags,
+			Before: commands.NoArguments,
+			Action: markAction(direction.Up),
+		},
+		{
+			Name:   "pending",
+			Usage:  "removes an applied migration from the changelog without executing it",
+			Flags:  markFlags,
+			Before: commands.NoArguments,
+			Action: markAction(direction.Down),
+		},
+	},
+}
+
+func markAction(dir direction.MigrateDirection) cli.ActionFunc {
+	return func(c *cli.Context) error {
+		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		db.SetApplications(c.StringSlice("app"))
+
+		if err := db.WaitForStart(100*time.Millisecond, 1); err != nil {
+			return err
+		}
+		log.Info("Connected to database")
+
+		migration, err := db.FindMigration(c.String("only"), dir)
+		if err != nil {
+			return err
+		}
+
+		if db.IsProtected() {
+			err := confirmChanges(c, db, []string{fmt.Sprintf(
+				"%s %s/%s", database.MarkAction(dir), migration.Application, migration.Filename,
+			)})
+			if err != nil {
+				return err
+			}
+		} else if !c.Bool("yes") {
+			fmt.Fprintf(
+				c.App.Writer, "Do you want to %s %s/%s? [y/N] ",
+				database.MarkAction(dir), migration.Application, migration.Filename,
+			)
+			answer, _ := bufio.NewReader(mockableStdin).ReadString('\n')
+			answer = strings.ToLower(strings.TrimSpace(answer))
+			if answer != "y" && answer != "yes" {
+				log.Info("Aborted, nothing was marked")
+				return nil
+			}
+		}
+
+		created, err := db.EnsureMigrationsChangelog()
+		if created {
+			log.Info("Created changelog table")
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := db.MarkMigration(migration, dir); err != nil {
+			return err
+		}
+		log.Infof(
+			"Completed %s of %s/%s", database.MarkAction(dir),
+			migration.Application, migration.Filename,
+		)
+		return nil
+	}
+}
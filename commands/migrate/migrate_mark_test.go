@@ -0,0 +1,73 @@
+package migrate
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"go-migrations/database"
+	"go-migrations/internal"
+	"go-migrations/internal/direction"
+)
+
+var fakeDbMark internal.FakeDbWithSpy
+
+func fakeLoadWithSpyMark(migrationsPath, environment string) (database.Database, error) {
+	fakeDbMark = internal.FakeDbWithSpy{}
+	return &fakeDbMark, nil
+}
+
+func TestMigrateMark(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpyMark
+
+	args := []string{"sth.exe", "migrate", "mark", "applied", "--only", "hotfix", "--yes"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeDbMark.AssertWaitForStartCalled(t, true)
+	fakeDbMark.AssertEnsureMigrationsChangelogCalled(t, true)
+	fakeDbMark.AssertMarkMigrationCalledWith(t, "hotfix", direction.Up)
+	fakeDbMark.AssertApplySpecificMigrationCalled(t, false)
+	fakeDbMark.AssertCloseCalled(t, true)
+
+	args = []string{"sth.exe", "migrate", "mark", "pending", "-o", "broken", "-y"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeDbMark.AssertMarkMigrationCalledWith(t, "broken", direction.Down)
+}
+
+func TestMigrateMarkConfirmation(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpyMark
+	app.Writer = ioutil.Discard
+	defer func() {
+		mockableStdin = os.Stdin
+		app.Writer = os.Stdout
+	}()
+
+	args := []string{"sth.exe", "migrate", "mark", "applied", "--only", "hotfix"}
+
+	mockableStdin = strings.NewReader("n\n")
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeDbMark.AssertMarkMigrationCalled(t, false)
+
+	mockableStdin = strings.NewReader("y\n")
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeDbMark.AssertMarkMigrationCalledWith(t, "hotfix", direction.Up)
+}
+
+func TestMigrateMarkRequiresOnly(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpyMark
+	fakeDbMark = internal.FakeDbWithSpy{}
+
+	args := []string{"sth.exe", "migrate", "mark", "applied", "--yes"}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error without the --only flag")
+	}
+	fakeDbMark.AssertMarkMigrationCalled(t, false)
+}
@@ -0,0 +1,127 @@
+package migrate
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+)
+
+var squashChangelogFlags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
+		Usage: "(relative) path to the folder containing the database migrations",
+	},
+	&cli.StringFlag{
+		Name: "environment", Aliases: []string{"e"}, Value: "development",
+		Usage: "Name of the environment and the corresponding configuration",
+	},
+}, commands.ProtectedFlags...)
+
+var squashFlags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name: "before", Aliases: []string{"b"}, Required: true,
+		Usage: "ID of the last migration to squash",
+	},
+	&cli.StringFlag{
+		Name: "app", Required: true,
+		Usage: "application folder of the migrations to squash",
+	},
+}, squashChangelogFlags...)
+
+// migrateSquashCommand merges old migrations into a single migration
+var migrateSquashCommand = &cli.Command{
+	Name:   "squash",
+	Usage:  "merges all migrations of an application up to an ID into a single migration",
+	Flags:  squashFlags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := db.WaitForStart(100*time.Millisecond, 1); err != nil {
+			return err
+		}
+		log.Info("Connected to database")
+
+		err = confirmChanges(c, db, []string{
+			fmt.Sprintf(
+				"squash the migrations of %s up to %s", c.String("app"), c.String("before"),
+			),
+			"replace their changelog entries by the squash migration, if all were applied",
+		})
+		if err != nil {
+			return err
+		}
+
+		created, err := db.EnsureMigrationsChangelog()
+		if created {
+			log.Info("Created changelog table")
+		}
+		if err != nil {
+			return err
+		}
+
+		squash, err := db.Squash(c.String("before"), c.String("app"))
+		if err != nil {
+			return err
+		}
+		log.Infof(
+			"Squashed %d migrations into %s/%s",
+			len(squash.Squashes), squash.Application, squash.Filename,
+		)
+		return db.SquashChangelog()
+	},
+}
+
+// migrateSquashChangelogCommand updates the changelog of other databases after a squash
+var migrateSquashChangelogCommand = &cli.Command{
+	Name:   "squash-changelog",
+	Usage:  "replaces the changelog entries of squashed migrations by their squash migration",
+	Flags:  squashChangelogFlags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		if err := db.WaitForStart(100*time.Millisecond, 1); err != nil {
+			return err
+		}
+		log.Info("Connected to database")
+
+		squashes, err := db.FindChangelogSquashes()
+		if err != nil {
+			return err
+		}
+		if len(squashes) == 0 {
+			log.Info("The changelog contains no squashed migrations")
+			return nil
+		}
+
+		plan := []string{}
+		for _, squash := range squashes {
+			plan = append(plan, fmt.Sprintf(
+				"replace the changelog entries of %d migrations by %s/%s",
+				len(squash.Squashes), squash.Application, squash.Filename,
+			))
+		}
+		if err := commands.ConfirmProtected(c, mockableStdin, db.IsProtected(), plan); err != nil {
+			return err
+		}
+
+		if err := db.SquashChangelog(); err != nil {
+			return err
+		}
+		log.Infof("Replaced the changelog entries of %d squash migrations", len(squashes))
+		return nil
+	},
+}
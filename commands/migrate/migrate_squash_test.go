@@ -0,0 +1,67 @@
+package migrate
+
+import (
+	"testing"
+
+	"go-migrations/database"
+	"go-migrations/internal"
+)
+
+var fakeDbSquash internal.FakeDbWithSpy
+
+func fakeLoadWithSpySquash(migrationsPath, environment string) (database.Database, error) {
+	fakeDbSquash = internal.FakeDbWithSpy{}
+	return &fakeDbSquash, nil
+}
+
+func TestMigrateSquash(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpySquash
+
+	args := []string{
+		"sth.exe", "migrate", "squash", "--before", "20200101000000", "--app", "common",
+	}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeDbSquash.AssertWaitForStartCalled(t, true)
+	fakeDbSquash.AssertEnsureMigrationsChangelogCalled(t, true)
+	fakeDbSquash.AssertSquashCalledWith(t, "20200101000000", "common")
+	fakeDbSquash.AssertSquashChangelogCalled(t, true)
+	fakeDbSquash.AssertCloseCalled(t, true)
+}
+
+func TestMigrateSquashChangelog(t *testing.T) {
+	mockableLoadDB = func(migrationsPath, environment string) (database.Database, error) {
+		fakeDbSquash = internal.FakeDbWithSpy{
+			ChangelogSquashes: []database.FileMigration{
+				{ID: "2", Application: "common", Squashes: []string{"1", "2"}},
+			},
+		}
+		return &fakeDbSquash, nil
+	}
+
+	if err := app.Run([]string{"sth.exe", "migrate", "squash-changelog"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeDbSquash.AssertSquashChangelogCalled(t, true)
+
+	mockableLoadDB = fakeLoadWithSpySquash
+	if err := app.Run([]string{"sth.exe", "migrate", "squash-changelog"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeDbSquash.AssertSquashChangelogCalled(t, false)
+}
+
+func TestMigrateSquashRequiresFlags(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpySquash
+
+	for _, args := range [][]string{
+		{"sth.exe", "migrate", "squash", "--before", "20200101000000"},
+		{"sth.exe", "migrate", "squash", "--app", "common"},
+	} {
+		if err := app.Run(args); err == nil {
+			t.Errorf("Expected an error for missing flags in %v", args)
+		}
+	}
+}
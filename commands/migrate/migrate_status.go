@@ -16,6 +16,14 @@ var (
 )
 
 var statusFlags = []cli.Flag{
+	&cli.BoolFlag{
+		Name:  "allow-out-of-order",
+		Usage: "allow skipped (older) migrations to be applied after newer ones",
+	},
+	&cli.StringSliceFlag{
+		Name:  "app",
+		Usage: "restrict the migrations to this application folder (can be repeated)",
+	},
 	&cli.StringFlag{
 		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
 		Usage: "(relative) path to the folder containing the database migrations",
@@ -38,6 +46,11 @@ var migrateStatusCommand = &cli.Command{
 		if err != nil {
 			return err
 		}
+		defer db.Close()
+		db.SetApplications(c.StringSlice("app"))
+		if c.Bool("allow-out-of-order") {
+			db.SetAllowOutOfOrder(true)
+		}
 
 		if err := db.WaitForStart(100*time.Millisecond, 1); err != nil {
 			return err
@@ -57,7 +70,9 @@ var migrateStatusCommand = &cli.Command{
 			return err
 		}
 
-		rows, statusNote, err := mockableGetMigrationStatus(fileMigrations, appliedMigrations)
+		rows, statusNote, err := mockableGetMigrationStatus(
+			fileMigrations, appliedMigrations, db.AllowsOutOfOrder(),
+		)
 		if err != nil {
 			return err
 		}
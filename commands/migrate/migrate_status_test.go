@@ -27,6 +27,7 @@ func TestMigrateStatus(t *testing.T) {
 
 	mockableGetMigrationStatus = func(
 		fileMigrations []database.FileMigration, appliedMigrations []database.AppliedMigration,
+		allowOutOfOrder bool,
 	) (rows []database.MigrateStatusRow, statusNote string, err error) {
 		return expectedRows, expectedStatus, nil
 	}
@@ -10,7 +10,15 @@ import (
 	"go-migrations/internal/direction"
 )
 
-var upFlags = []cli.Flag{
+var upFlags = append([]cli.Flag{
+	&cli.BoolFlag{
+		Name:  "allow-out-of-order",
+		Usage: "allow skipped (older) migrations to be applied after newer ones",
+	},
+	&cli.StringSliceFlag{
+		Name:  "app",
+		Usage: "restrict the migrations to this application folder (can be repeated)",
+	},
 	&cli.StringFlag{
 		Name: "count", Aliases: []string{"c"},
 		Usage: "number of migrations to apply (default action is to apply one)",
@@ -31,7 +39,7 @@ var upFlags = []cli.Flag{
 		Name: "environment", Aliases: []string{"e"}, Value: "development",
 		Usage: "Name of the environment and the corresponding configuration",
 	},
-}
+}, commands.ProtectedFlags...)
 
 // migrateUpCommand executes up migrations
 var migrateUpCommand = &cli.Command{
@@ -44,17 +52,39 @@ var migrateUpCommand = &cli.Command{
 		if err := checkFlags(c); err != nil {
 			return err
 		}
+		observer, err := commands.NewObserver(c)
+		if err != nil {
+			return err
+		}
 
 		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
 		if err != nil {
 			return err
 		}
+		defer db.Close()
+		db.SetApplications(c.StringSlice("app"))
+		if c.Bool("allow-out-of-order") {
+			db.SetAllowOutOfOrder(true)
+		}
 
 		if err := db.WaitForStart(100*time.Millisecond, 1); err != nil {
 			return err
 		}
 		log.Info("Connected to database")
 
+		count := c.Uint("count")
+		if count == 0 && !c.Bool("all") {
+			count = 1
+		}
+		if c.String("only") == "" {
+			if err := db.EnsureConsistentMigrations(); err != nil {
+				return err
+			}
+		}
+		if err := confirmMigrations(c, db, count, direction.Up); err != nil {
+			return err
+		}
+
 		created, err := db.EnsureMigrationsChangelog()
 		if created {
 			log.Info("Created changelog table")
@@ -64,24 +94,18 @@ var migrateUpCommand = &cli.Command{
 		}
 
 		if c.String("only") != "" {
-			if err := db.ApplySpecificMigration(c.String("only"), direction.Up); err != nil {
+			err = db.ApplySpecificMigration(c.String("only"), direction.Up, observer)
+			if err != nil {
 				return err
 			}
 		} else {
-			if err := db.EnsureConsistentMigrations(); err != nil {
-				return err
-			}
-
-			upCount := c.Uint("count")
-			if upCount == 0 && !c.Bool("all") {
-				upCount = 1
-			}
-			err = db.ApplyMigrationsWithCount(upCount, c.Bool("all"), direction.Up)
+			err = db.ApplyMigrationsWithCount(count, c.Bool("all"), direction.Up, observer)
 			if err != nil {
 				return err
 			}
 		}
 		log.Info("Up migration completed")
-		return nil
+
+		return commands.ApplyRepeatableMigrations(db, observer)
 	},
 }
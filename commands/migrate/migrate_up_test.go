@@ -34,7 +34,9 @@ func TestMigrateUpDefaults(t *testing.T) {
 	fakeDbUp.AssertEnsureMigrationsChangelogCalled(t, true)
 	fakeDbUp.AssertEnsureConsistentMigrationsCalled(t, true)
 	fakeDbUp.AssertApplyMigrationsWithCountCalledWith(t, 1, false, direction.Up)
+	fakeDbUp.AssertApplyRepeatableMigrationsCalled(t, true)
 	fakeDbUp.AssertApplySpecificMigrationCalled(t, false)
+	fakeDbUp.AssertCloseCalled(t, true)
 }
 
 func TestMigrateUpWithCount(t *testing.T) {
@@ -95,6 +97,7 @@ func TestMigrateUpWithOnly(t *testing.T) {
 	fakeDbUp.AssertWaitForStartCalled(t, true)
 	fakeDbUp.AssertEnsureMigrationsChangelogCalled(t, true)
 	fakeDbUp.AssertApplySpecificMigrationCalledWith(t, "sth", direction.Up)
+	fakeDbUp.AssertApplyRepeatableMigrationsCalled(t, true)
 	fakeDbUp.AssertApplyMigrationsWithCountCalled(t, false)
 	fakeDbUp.AssertEnsureConsistentMigrationsCalled(t, false)
 }
@@ -124,3 +127,16 @@ func TestMigrateUpErrorWithMultipleParams(t *testing.T) {
 	}
 
 }
+
+func TestMigrateUpWithApplications(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpyUp
+
+	args := []string{"sth.exe", "migrate", "up", "--app", "common", "--app", "sub_app"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeDbUp.AssertSetApplicationsCalledWith(t, []string{"common", "sub_app"})
+	fakeDbUp.AssertEnsureConsistentMigrationsCalled(t, true)
+	fakeDbUp.AssertApplyMigrationsWithCountCalledWith(t, 1, false, direction.Up)
+}
@@ -0,0 +1,69 @@
+package migrate
+
+import (
+	"fmt"
+
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+// confirmChanges asks for confirmation of the plan on protected environments. The changes
+// EnsureMigrationsChangelog would make are shown first, as commands only create or upgrade the
+// changelog after the confirmation
+func confirmChanges(c *cli.Context, db database.Database, plan []string) error {
+	if !db.IsProtected() {
+		return nil
+	}
+	changelogPlan, err := db.PlanMigrationsChangelog()
+	if err != nil {
+		return err
+	}
+	return commands.ConfirmProtected(c, mockableStdin, true, append(changelogPlan, plan...))
+}
+
+// confirmMigrations shows the migrations, which will be applied, and asks for confirmation on
+// protected environments. Up migrations are followed by the changed repeatable migrations
+func confirmMigrations(
+	c *cli.Context, db database.Database, count uint, dir direction.MigrateDirection,
+) error {
+	if !db.IsProtected() {
+		return nil
+	}
+
+	var migrations []database.FileMigration
+	if c.String("only") != "" {
+		migration, err := db.FindMigration(c.String("only"), dir)
+		if err != nil {
+			return err
+		}
+		migrations = append(migrations, migration)
+	} else {
+		var err error
+		if migrations, err = db.FindMigrationsWithCount(count, c.Bool("all"), dir); err != nil {
+			return err
+		}
+	}
+
+	plan := []string{}
+	for _, migration := range migrations {
+		plan = append(
+			plan, fmt.Sprintf("%s %s/%s", dir.Name(), migration.Application, migration.Filename),
+		)
+	}
+
+	if dir == direction.Up {
+		repeatables, err := db.FindRepeatableMigrations()
+		if err != nil {
+			return err
+		}
+		for _, repeatable := range repeatables {
+			plan = append(plan, fmt.Sprintf(
+				"repeatable %s, if no migration is pending afterwards", repeatable.Name(),
+			))
+		}
+	}
+	return confirmChanges(c, db, plan)
+}
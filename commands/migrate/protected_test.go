@@ -0,0 +1,148 @@
+package migrate
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"go-migrations/database"
+	"go-migrations/internal"
+	"go-migrations/internal/direction"
+)
+
+var fakeDbProtected internal.FakeDbWithSpy
+
+func fakeLoadProtected(migrationsPath, environment string) (database.Database, error) {
+	fakeDbProtected = internal.FakeDbWithSpy{Protected: true}
+	return &fakeDbProtected, nil
+}
+
+func TestMigrateUpProtectedTypedEnvironment(t *testing.T) {
+	mockableLoadDB = fakeLoadProtected
+	mockableStdin = strings.NewReader("production\n")
+	defer func() { mockableStdin = os.Stdin }()
+	var output bytes.Buffer
+	app.Writer = &output
+	defer func() { app.Writer = os.Stdout }()
+
+	args := []string{"sth.exe", "migrate", "up", "--count", "2", "-e", "production"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeDbProtected.AssertApplyMigrationsWithCountCalledWith(t, 2, false, direction.Up)
+	expectedPlan := "  - up /1_migration.sql\n  - up /2_migration.sql\n"
+	if !strings.Contains(output.String(), expectedPlan) {
+		t.Errorf("Expected the plan %s, but got %s", expectedPlan, output.String())
+	}
+}
+
+func TestMigrateUpProtectedPlan(t *testing.T) {
+	mockableLoadDB = func(migrationsPath, environment string) (database.Database, error) {
+		fakeDbProtected = internal.FakeDbWithSpy{
+			Protected:     true,
+			ChangelogPlan: []string{"create the changelog table public.migrations_changelog"},
+			Repeatables: []database.RepeatableMigration{
+				{Application: "common", Filename: "R_views.sql"},
+			},
+		}
+		return &fakeDbProtected, nil
+	}
+	mockableStdin = strings.NewReader("production\n")
+	defer func() { mockableStdin = os.Stdin }()
+	var output bytes.Buffer
+	app.Writer = &output
+	defer func() { app.Writer = os.Stdout }()
+
+	args := []string{"sth.exe", "migrate", "up", "-e", "production"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	expectedPlan := "  - create the changelog table public.migrations_changelog\n" +
+		"  - up /1_migration.sql\n" +
+		"  - repeatable common/R_views.sql, if no migration is pending afterwards\n"
+	if !strings.Contains(output.String(), expectedPlan) {
+		t.Errorf("Expected the plan %s, but got %s", expectedPlan, output.String())
+	}
+	fakeDbProtected.AssertEnsureMigrationsChangelogCalled(t, true)
+}
+
+func TestMigrateUpProtectedWrongEnvironment(t *testing.T) {
+	mockableLoadDB = fakeLoadProtected
+	mockableStdin = strings.NewReader("staging\n")
+	defer func() { mockableStdin = os.Stdin }()
+
+	args := []string{"sth.exe", "migrate", "up", "--all", "-e", "production"}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error for a wrong environment")
+	}
+
+	fakeDbProtected.AssertEnsureMigrationsChangelogCalled(t, false)
+	fakeDbProtected.AssertApplyMigrationsWithCountCalled(t, false)
+}
+
+func TestMigrateUpProtectedYes(t *testing.T) {
+	mockableLoadDB = fakeLoadProtected
+
+	args := []string{"sth.exe", "migrate", "up", "--yes", "-e", "production"}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error without --confirm-environment")
+	}
+	fakeDbProtected.AssertApplyMigrationsWithCountCalled(t, false)
+
+	args = []string{
+		"sth.exe", "migrate", "up", "--yes", "--confirm-environment", "production",
+		"-e", "production",
+	}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeDbProtected.AssertApplyMigrationsWithCountCalledWith(t, 1, false, direction.Up)
+}
+
+func TestMigrateDownProtected(t *testing.T) {
+	mockableLoadDB = fakeLoadProtected
+
+	args := []string{
+		"sth.exe", "migrate", "down", "--yes", "--confirm-environment", "production",
+		"-e", "production",
+	}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error without --allow-destructive")
+	}
+	fakeDbProtected.AssertWaitForStartCalled(t, false)
+	fakeDbProtected.AssertApplyMigrationsWithCountCalled(t, false)
+
+	args = append(args, "--allow-destructive")
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeDbProtected.AssertApplyMigrationsWithCountCalledWith(t, 1, false, direction.Down)
+}
+
+func TestMigrateMarkProtected(t *testing.T) {
+	mockableLoadDB = fakeLoadProtected
+	mockableStdin = strings.NewReader("y\n")
+	defer func() { mockableStdin = os.Stdin }()
+
+	args := []string{"sth.exe", "migrate", "mark", "applied", "--only", "hotfix", "-e", "production"}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error for a wrong environment")
+	}
+	fakeDbProtected.AssertEnsureMigrationsChangelogCalled(t, false)
+	fakeDbProtected.AssertMarkMigrationCalled(t, false)
+}
+
+func TestMigrateBaselineProtected(t *testing.T) {
+	mockableLoadDB = fakeLoadProtected
+	mockableStdin = strings.NewReader("staging\n")
+	defer func() { mockableStdin = os.Stdin }()
+
+	args := []string{"sth.exe", "migrate", "baseline", "--to", "20200101000001", "-e", "production"}
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error for a wrong environment")
+	}
+	fakeDbProtected.AssertEnsureMigrationsChangelogCalled(t, false)
+}
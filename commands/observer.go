@@ -0,0 +1,50 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/database"
+	"go-migrations/logging"
+	"go-migrations/observer"
+)
+
+// ProgressModes are the possible values of the global --progress flag
+var ProgressModes = []string{"auto", "bar", "log", "json"}
+
+// NewObserver returns the observer for the --progress flag. auto shows a progress bar in
+// terminals and logs the migrations otherwise (e.g. in CI or with --log-format json).
+// The run of the command ends with a summary of the migrations
+func NewObserver(c *cli.Context) (database.Observer, error) {
+	mode := c.String("progress")
+	if mode == "" || mode == "auto" {
+		mode = "bar"
+		if c.String("log-format") == "json" || !isTerminal(os.Stdout) {
+			mode = "log"
+		}
+	}
+
+	var display database.Observer
+	switch mode {
+	case "bar":
+		display = observer.NewProgress(os.Stdout)
+	case "log":
+		display = observer.Log
+	case "json":
+		display = observer.NewJSON(os.Stdout)
+	default:
+		return nil, fmt.Errorf(
+			"Unknown progress %q, use one of %s", mode, strings.Join(ProgressModes, ", "),
+		)
+	}
+	logging.StartSummary()
+	return database.MultiObserver{display, logging.SummaryObserver}, nil
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
@@ -0,0 +1,88 @@
+package commands
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/database/config"
+)
+
+// ErrNotConfirmed is returned if a command on a protected environment was not confirmed
+var ErrNotConfirmed = errors.New("The environment was not confirmed, nothing was changed")
+
+// YesFlag skips the confirmation (on protected environments together with ConfirmEnvironmentFlag)
+var YesFlag = &cli.BoolFlag{
+	Name: "yes", Aliases: []string{"y"},
+	Usage: "do not ask for confirmation (protected environments also need --confirm-environment)",
+}
+
+// ConfirmEnvironmentFlag confirms the protected environment without a prompt (e.g. in CI)
+var ConfirmEnvironmentFlag = &cli.StringFlag{
+	Name:  "confirm-environment",
+	Usage: "name of the protected environment to confirm it together with --yes",
+}
+
+// AllowDestructiveFlag allows destructive commands on protected environments
+var AllowDestructiveFlag = &cli.BoolFlag{
+	Name:  "allow-destructive",
+	Usage: "allow destructive commands (down migrations, db drop and reset) on protected environments",
+}
+
+// ProtectedFlags are the flags of mutating commands to confirm protected environments
+var ProtectedFlags = []cli.Flag{YesFlag, ConfirmEnvironmentFlag}
+
+// RefuseProtected returns an error for protected environments
+func RefuseProtected(cfg config.Config, action string) error {
+	if cfg.Protected {
+		return fmt.Errorf("The environment %s is protected, %s is not allowed", cfg.Environment, action)
+	}
+	return nil
+}
+
+// RefuseDestructive returns an error for protected environments unless --allow-destructive
+// was passed
+func RefuseDestructive(c *cli.Context, protected bool, action string) error {
+	if protected && !c.Bool("allow-destructive") {
+		return fmt.Errorf(
+			"%s is blocked on the protected environment %s, pass --allow-destructive to run it",
+			action, c.String("environment"),
+		)
+	}
+	return nil
+}
+
+// ConfirmProtected shows the plan of a mutating command on a protected environment and asks to
+// type the name of the environment. With --yes the environment has to be passed as
+// --confirm-environment instead. Other environments are not confirmed
+func ConfirmProtected(c *cli.Context, stdin io.Reader, protected bool, plan []string) error {
+	if !protected {
+		return nil
+	}
+	environment := c.String("environment")
+
+	fmt.Fprintf(c.App.Writer, "The protected environment %s will be changed:\n", environment)
+	for _, step := range plan {
+		fmt.Fprintf(c.App.Writer, "  - %s\n", step)
+	}
+
+	if c.Bool("yes") {
+		if c.String("confirm-environment") != environment {
+			return fmt.Errorf(
+				"%w, --yes requires --confirm-environment=%s", ErrNotConfirmed, environment,
+			)
+		}
+		return nil
+	}
+
+	fmt.Fprintf(c.App.Writer, "Type the name of the environment to continue: ")
+	answer, _ := bufio.NewReader(stdin).ReadString('\n')
+	if strings.TrimSpace(answer) != environment {
+		return ErrNotConfirmed
+	}
+	return nil
+}
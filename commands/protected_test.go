@@ -0,0 +1,92 @@
+package commands
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/urfave/cli/v2"
+)
+
+// runConfirm runs ConfirmProtected within a command with the arguments and the input
+func runConfirm(protected bool, input string, args ...string) (output string, err error) {
+	var writer bytes.Buffer
+	app := cli.NewApp()
+	app.Writer = &writer
+	app.Commands = []*cli.Command{{
+		Name: "cmd",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{Name: "environment", Aliases: []string{"e"}},
+		}, ProtectedFlags...),
+		Action: func(c *cli.Context) error {
+			return ConfirmProtected(c, strings.NewReader(input), protected, []string{"do sth"})
+		},
+	}}
+	err = app.Run(append([]string{"sth.exe", "cmd", "-e", "production"}, args...))
+	return writer.String(), err
+}
+
+func TestConfirmProtected(t *testing.T) {
+	testCases := []struct {
+		name      string
+		protected bool
+		input     string
+		args      []string
+		confirmed bool
+	}{
+		{"unprotected", false, "", nil, true},
+		{"typed environment", true, "production\n", nil, true},
+		{"typed other environment", true, "staging\n", nil, false},
+		{"no input", true, "", nil, false},
+		{"yes without environment", true, "", []string{"--yes"}, false},
+		{"yes with other environment", true, "", []string{"-y", "--confirm-environment=ci"}, false},
+		{
+			"yes with environment", true, "",
+			[]string{"--yes", "--confirm-environment=production"}, true,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			output, err := runConfirm(testCase.protected, testCase.input, testCase.args...)
+			if testCase.confirmed && err != nil {
+				t.Errorf("Expected a confirmation, but got: %v", err)
+			}
+			if !testCase.confirmed && !errors.Is(err, ErrNotConfirmed) {
+				t.Errorf("Expected ErrNotConfirmed, but got: %v", err)
+			}
+			if testCase.protected != strings.Contains(output, "  - do sth\n") {
+				t.Errorf("Expected the plan only for protected environments, but got: %s", output)
+			}
+		})
+	}
+}
+
+func TestRefuseDestructive(t *testing.T) {
+	testCases := []struct {
+		name      string
+		protected bool
+		args      []string
+		refused   bool
+	}{
+		{"unprotected", false, nil, false},
+		{"protected", true, nil, true},
+		{"protected and allowed", true, []string{"--allow-destructive"}, false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			app := cli.NewApp()
+			app.Commands = []*cli.Command{{
+				Name:  "cmd",
+				Flags: []cli.Flag{AllowDestructiveFlag, &cli.StringFlag{Name: "environment"}},
+				Action: func(c *cli.Context) error {
+					return RefuseDestructive(c, testCase.protected, "cmd")
+				},
+			}}
+			err := app.Run(append([]string{"sth.exe", "cmd"}, testCase.args...))
+			if (err != nil) != testCase.refused {
+				t.Errorf("Expected refused %t, but got: %v", testCase.refused, err)
+			}
+		})
+	}
+}
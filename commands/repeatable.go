@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"errors"
+
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database"
+)
+
+// ApplyRepeatableMigrations applies the changed repeatable migrations after the versioned ones.
+// They are skipped (without an error) while versioned migrations are pending
+func ApplyRepeatableMigrations(db database.Database, observer database.Observer) error {
+	applied, err := db.ApplyRepeatableMigrations(observer)
+	if errors.Is(err, database.ErrPendingMigrations) {
+		log.Infof("Skipped repeatable migrations, %v", err)
+		return nil
+	} else if err != nil {
+		return err
+	}
+
+	if applied > 0 {
+		log.Infof("Applied %d repeatable migrations", applied)
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package seed
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+	"go-migrations/database/driver"
+)
+
+var (
+	mockableLoadDB           = driver.LoadDB
+	mockableStdin  io.Reader = os.Stdin
+)
+
+var flags = append([]cli.Flag{
+	&cli.StringFlag{
+		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
+		Usage: "(relative) path to the folder containing the database migrations",
+	},
+	&cli.StringFlag{
+		Name: "environment", Aliases: []string{"e"}, Value: "development",
+		Usage: "Name of the environment and the corresponding configuration",
+	},
+}, commands.ProtectedFlags...)
+
+// SeedCommand applies the data seeds of the environment (_seeds/<environment>/*.sql)
+var SeedCommand = &cli.Command{
+	Name:   "seed",
+	Usage:  "applies the data seeds of the environment, which were not applied yet",
+	Flags:  flags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+
+		err = commands.ConfirmProtected(c, mockableStdin, db.IsProtected(), []string{
+			fmt.Sprintf("apply the data seeds of %s", c.String("environment")),
+		})
+		if err != nil {
+			return err
+		}
+
+		if err := db.WaitForStart(1*time.Second, 10); err != nil {
+			return err
+		}
+		log.Debug("Connected to database")
+
+		observer, err := commands.NewObserver(c)
+		if err != nil {
+			return err
+		}
+		return commands.ApplyDataSeeds(db, observer)
+	},
+}
@@ -0,0 +1,51 @@
+package seed
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/database"
+	"go-migrations/internal"
+)
+
+var dbLoadArgs []string
+var fakeDb internal.FakeDbWithSpy
+
+func fakeLoadWithSpy(migrationsPath, environment string) (database.Database, error) {
+	dbLoadArgs = []string{migrationsPath, environment}
+	fakeDb = internal.FakeDbWithSpy{}
+	return &fakeDb, nil
+}
+
+var app = cli.NewApp()
+
+func TestMain(m *testing.M) {
+	app.Commands = []*cli.Command{
+		SeedCommand,
+	}
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+func TestSeed(t *testing.T) {
+	mockableLoadDB = fakeLoadWithSpy
+
+	args := []string{"sth.exe", "seed", "-p", "/my/path", "-e", "staging"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	expected := []string{"/my/path", "staging"}
+	if !internal.StrSliceEqual(dbLoadArgs, expected) {
+		t.Errorf("Expected to load db with '%v', but got %s", expected, dbLoadArgs)
+	}
+
+	fakeDb.AssertWaitForStartCalled(t, true)
+	fakeDb.AssertApplyDataSeedsCalled(t, true)
+	fakeDb.AssertApplyAllUpMigrationsCalled(t, false)
+	fakeDb.AssertCloseCalled(t, true)
+}
@@ -0,0 +1,18 @@
+package commands
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database"
+)
+
+// ApplyDataSeeds applies the data seeds of the environment, which were not applied yet
+func ApplyDataSeeds(db database.Database, observer database.Observer) error {
+	applied, err := db.ApplyDataSeeds(observer)
+	if err != nil {
+		return err
+	}
+
+	log.Infof("Applied %d data seeds", applied)
+	return nil
+}
@@ -0,0 +1,219 @@
+package snapshot
+
+import (
+	"fmt"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+	"go-migrations/database"
+	"go-migrations/database/config"
+	"go-migrations/database/driver"
+)
+
+// variables to allow mocking for tests
+var (
+	mockableLoadConfig     = driver.LoadConfig
+	mockableInitAdmin      = driver.InitAdmin
+	mockableInitDB         = driver.InitDB
+	mockablePrintSnapshots = database.PrintSnapshotTable
+)
+
+var flags = []cli.Flag{
+	&cli.StringFlag{
+		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
+		Usage: "(relative) path to the folder containing the database migrations",
+	},
+	&cli.StringFlag{
+		Name: "environment", Aliases: []string{"e"}, Value: "development",
+		Usage: "Name of the environment and the corresponding configuration",
+	},
+}
+
+// SnapshotCommands save and restore snapshots of the database of a (local) environment
+var SnapshotCommands = &cli.Command{
+	Name:  "snapshot",
+	Usage: "save, restore, list or delete snapshots of the database of a local environment",
+	Subcommands: []*cli.Command{
+		snapshotSaveCommand,
+		snapshotRestoreCommand,
+		snapshotListCommand,
+		snapshotDeleteCommand,
+	},
+}
+
+var snapshotSaveCommand = &cli.Command{
+	Name:      "save",
+	Usage:     "copies the database into a snapshot",
+	ArgsUsage: "<name>",
+	Flags: append([]cli.Flag{
+		&cli.BoolFlag{
+			Name: "force", Aliases: []string{"f"},
+			Usage: "replace an existing snapshot of the name",
+		},
+	}, flags...),
+	Before: commands.OneArgument,
+	Action: func(c *cli.Context) error {
+		name := c.Args().First()
+		cfg, err := loadConfig(c, "snapshot save")
+		if err != nil {
+			return err
+		}
+
+		migrationID, err := lastAppliedMigrationID(cfg)
+		if err != nil {
+			return err
+		}
+
+		return withAdmin(cfg, func(admin database.Admin) error {
+			snapshots, err := admin.ListSnapshots()
+			if err != nil {
+				return err
+			}
+			for _, snapshot := range snapshots {
+				if snapshot.Name != name {
+					continue
+				}
+				if !c.Bool("force") {
+					return fmt.Errorf("Snapshot %s already exists, use --force to replace it", name)
+				}
+			}
+
+			if err := admin.SaveSnapshot(name, migrationID); err != nil {
+				return err
+			}
+			log.Infof("Saved snapshot %s at migration %s", name, migrationID)
+			return nil
+		})
+	},
+}
+
+var snapshotRestoreCommand = &cli.Command{
+	Name:      "restore",
+	Usage:     "replaces the database by a copy of the snapshot",
+	ArgsUsage: "<name>",
+	Flags:     flags,
+	Before:    commands.OneArgument,
+	Action: func(c *cli.Context) error {
+		cfg, err := loadConfig(c, "snapshot restore")
+		if err != nil {
+			return err
+		}
+
+		var snapshot database.Snapshot
+		err = withAdmin(cfg, func(admin database.Admin) (err error) {
+			snapshot, err = admin.RestoreSnapshot(c.Args().First())
+			return err
+		})
+		if err != nil {
+			return err
+		}
+		log.Infof("Restored snapshot %s at migration %s", snapshot.Name, snapshot.MigrationID)
+
+		// the changelog is restored with the database, check it matches the recorded migration
+		migrationID, err := lastAppliedMigrationID(cfg)
+		if err != nil {
+			return err
+		}
+		if migrationID != snapshot.MigrationID {
+			log.Warnf(
+				"The changelog of the restored database ends with migration %s instead of %s",
+				migrationID, snapshot.MigrationID,
+			)
+		}
+		return nil
+	},
+}
+
+var snapshotListCommand = &cli.Command{
+	Name:   "list",
+	Usage:  "lists the snapshots of the database",
+	Flags:  flags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		cfg, err := mockableLoadConfig(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
+			return err
+		}
+
+		return withAdmin(cfg, func(admin database.Admin) error {
+			snapshots, err := admin.ListSnapshots()
+			if err != nil {
+				return err
+			}
+			mockablePrintSnapshots(c.App.Writer, snapshots)
+			return nil
+		})
+	},
+}
+
+var snapshotDeleteCommand = &cli.Command{
+	Name:      "delete",
+	Usage:     "drops the snapshot",
+	ArgsUsage: "<name>",
+	Flags:     flags,
+	Before:    commands.OneArgument,
+	Action: func(c *cli.Context) error {
+		cfg, err := loadConfig(c, "snapshot delete")
+		if err != nil {
+			return err
+		}
+
+		return withAdmin(cfg, func(admin database.Admin) error {
+			if err := admin.DeleteSnapshot(c.Args().First()); err != nil {
+				return err
+			}
+			log.Infof("Deleted snapshot %s", c.Args().First())
+			return nil
+		})
+	},
+}
+
+// loadConfig loads the configuration and refuses protected environments
+func loadConfig(c *cli.Context, action string) (config.Config, error) {
+	cfg, err := mockableLoadConfig(c.String("migrations-path"), c.String("environment"))
+	if err != nil {
+		return cfg, err
+	}
+	return cfg, commands.RefuseProtected(cfg, action)
+}
+
+// lastAppliedMigrationID returns the ID of the last migration of the changelog
+func lastAppliedMigrationID(cfg config.Config) (string, error) {
+	db, err := mockableInitDB(cfg)
+	if err != nil {
+		return "", err
+	}
+	defer db.Close()
+
+	if err := db.WaitForStart(1*time.Second, 10); err != nil {
+		return "", err
+	}
+	appliedMigrations, err := db.GetAppliedMigrations()
+	if err != nil {
+		return "", err
+	}
+
+	if len(appliedMigrations) == 0 {
+		return "", nil
+	}
+	return appliedMigrations[len(appliedMigrations)-1].ID, nil
+}
+
+// withAdmin runs the action with a connection to the maintenance database
+func withAdmin(cfg config.Config, run func(database.Admin) error) error {
+	admin, err := mockableInitAdmin(cfg)
+	if err != nil {
+		return err
+	}
+	defer admin.Close()
+
+	if err := admin.WaitForStart(1*time.Second, 10); err != nil {
+		return err
+	}
+	log.Debug("Connected to maintenance database")
+
+	return run(admin)
+}
@@ -0,0 +1,154 @@
+package snapshot
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/database"
+	"go-migrations/database/config"
+	"go-migrations/internal"
+)
+
+var fakeAdmin internal.FakeAdminWithSpy
+var fakeDb internal.FakeDbWithSpy
+var protected bool
+
+func fakeLoadConfig(migrationsPath, environment string) (config.Config, error) {
+	cfg := config.Config{MigrationsPath: migrationsPath, Environment: environment}
+	cfg.Db.Name = "zlab"
+	cfg.Protected = protected
+	return cfg, nil
+}
+
+func fakeInitAdmin(cfg config.Config) (database.Admin, error) {
+	return &fakeAdmin, nil
+}
+
+func fakeInitDB(cfg config.Config) (database.Database, error) {
+	return &fakeDb, nil
+}
+
+var app = cli.NewApp()
+
+func TestMain(m *testing.M) {
+	app.Commands = []*cli.Command{
+		SnapshotCommands,
+	}
+	mockableLoadConfig = fakeLoadConfig
+	mockableInitAdmin = fakeInitAdmin
+	mockableInitDB = fakeInitDB
+	log.SetOutput(ioutil.Discard)
+	os.Exit(m.Run())
+}
+
+func TestSnapshotSave(t *testing.T) {
+	fakeAdmin = internal.FakeAdminWithSpy{}
+	fakeDb = internal.FakeDbWithSpy{AppliedMigrations: []database.AppliedMigration{
+		{ID: "20200101000001"}, {ID: "20200101000002"},
+	}}
+
+	if err := app.Run([]string{"sth.exe", "snapshot", "save", "before_import"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeAdmin.AssertSaveSnapshotCalledWith(t, [][]string{{"before_import", "20200101000002"}})
+	fakeAdmin.AssertDeleteSnapshotCalledWith(t, nil)
+	fakeDb.AssertCloseCalled(t, true)
+}
+
+func TestSnapshotSaveExisting(t *testing.T) {
+	fakeDb = internal.FakeDbWithSpy{}
+	fakeAdmin = internal.FakeAdminWithSpy{Snapshots: []database.Snapshot{{Name: "base"}}}
+
+	if err := app.Run([]string{"sth.exe", "snapshot", "save", "base"}); err == nil {
+		t.Errorf("Expected an error for an existing snapshot")
+	}
+	fakeAdmin.AssertSaveSnapshotCalledWith(t, nil)
+
+	if err := app.Run([]string{"sth.exe", "snapshot", "save", "--force", "base"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	// the snapshot is replaced by SaveSnapshot after the copy succeeded
+	fakeAdmin.AssertDeleteSnapshotCalledWith(t, nil)
+	fakeAdmin.AssertSaveSnapshotCalledWith(t, [][]string{{"base", ""}})
+}
+
+func TestSnapshotRestore(t *testing.T) {
+	fakeDb = internal.FakeDbWithSpy{}
+	fakeAdmin = internal.FakeAdminWithSpy{Snapshots: []database.Snapshot{
+		{Name: "base", MigrationID: "20200101000001"},
+	}}
+
+	if err := app.Run([]string{"sth.exe", "snapshot", "restore", "base"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeAdmin.AssertRestoreSnapshotCalledWith(t, []string{"base"})
+	fakeDb.AssertGetAppliedMigrationsCalled(t, true)
+
+	if err := app.Run([]string{"sth.exe", "snapshot", "restore", "missing"}); err == nil {
+		t.Errorf("Expected an error for a missing snapshot")
+	}
+}
+
+func TestSnapshotList(t *testing.T) {
+	snapshots := []database.Snapshot{{Name: "a"}, {Name: "b"}}
+	fakeAdmin = internal.FakeAdminWithSpy{Snapshots: snapshots}
+	var printed []database.Snapshot
+	mockablePrintSnapshots = func(w io.Writer, s []database.Snapshot) {
+		printed = s
+	}
+	defer func() { mockablePrintSnapshots = database.PrintSnapshotTable }()
+
+	if err := app.Run([]string{"sth.exe", "snapshot", "list"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	if diff := pretty.Compare(printed, snapshots); diff != "" {
+		t.Errorf("Printed unexpected snapshots: (-got +want)\n%s", diff)
+	}
+}
+
+func TestSnapshotDelete(t *testing.T) {
+	fakeAdmin = internal.FakeAdminWithSpy{}
+
+	if err := app.Run([]string{"sth.exe", "snapshot", "delete", "base"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+	fakeAdmin.AssertDeleteSnapshotCalledWith(t, []string{"base"})
+}
+
+func TestSnapshotArguments(t *testing.T) {
+	fakeAdmin = internal.FakeAdminWithSpy{}
+
+	for _, args := range [][]string{
+		{"sth.exe", "snapshot", "save"},
+		{"sth.exe", "snapshot", "delete", "a", "b"},
+		{"sth.exe", "snapshot", "list", "a"},
+	} {
+		if err := app.Run(args); err == nil {
+			t.Errorf("Expected an error for the arguments %v", args)
+		}
+	}
+	fakeAdmin.AssertSaveSnapshotCalledWith(t, nil)
+	fakeAdmin.AssertDeleteSnapshotCalledWith(t, nil)
+}
+
+func TestSnapshotProtectedEnvironment(t *testing.T) {
+	protected = true
+	defer func() { protected = false }()
+	fakeAdmin = internal.FakeAdminWithSpy{Snapshots: []database.Snapshot{{Name: "base"}}}
+
+	for _, command := range []string{"save", "restore", "delete"} {
+		if err := app.Run([]string{"sth.exe", "snapshot", command, "base"}); err == nil {
+			t.Errorf("Expected an error for snapshot %s of a protected environment", command)
+		}
+	}
+	fakeAdmin.AssertSaveSnapshotCalledWith(t, nil)
+	fakeAdmin.AssertRestoreSnapshotCalledWith(t, nil)
+	fakeAdmin.AssertDeleteSnapshotCalledWith(t, nil)
+}
@@ -0,0 +1,97 @@
+package start
+
+import (
+	"fmt"
+	"io/ioutil"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/container"
+	"go-migrations/database/config"
+)
+
+// defaultEphemeralImage is used for ephemeral databases without an image
+const defaultEphemeralImage = "postgres"
+
+// startEphemeral runs a throwaway container on a free port, migrates it and prints its DSN.
+// The container is removed after the timeout, by the stop command or if the setup fails
+func startEphemeral(c *cli.Context, cfg config.Config, image string) (err error) {
+	runtime, err := mockableGetEngine(c.String("runtime"))
+	if err != nil {
+		return err
+	}
+	port, err := mockableFreePort()
+	if err != nil {
+		return err
+	}
+
+	if image == "" {
+		image = defaultEphemeralImage
+	}
+	cfg.Image = image
+	cfg.Db.Host = "localhost"
+	cfg.Db.Port = port
+	name := ephemeralContainerName(cfg, port)
+
+	err = runCommand(runtime.RunContainer(container.ContainerSpec{
+		Name: name, Image: image, HostPort: port, ContainerPort: postgresPort,
+		Env:     postgresEnv(cfg),
+		Labels:  map[string]string{container.EphemeralLabel: cfg.Environment},
+		Timeout: c.Duration("timeout"),
+		Remove:  true,
+	}))
+	if err != nil {
+		return fmt.Errorf("Could not start the ephemeral container %s - Err: %v", name, err)
+	}
+	log.Infof("Started ephemeral container %s on port %d", name, port)
+	defer func() {
+		if err == nil {
+			return
+		}
+		// a database without the migrations is of no use
+		if removeErr := runCommand(runtime.RemoveContainer(name)); removeErr != nil {
+			log.Errorf("Could not remove the ephemeral container %s: %v", name, removeErr)
+		}
+	}()
+
+	db, err := mockableInitDB(cfg)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	if err = setupDb(c, db, false); err != nil {
+		return err
+	}
+
+	return writeEphemeralConfig(c, cfg)
+}
+
+// ephemeralContainerName returns the name of the ephemeral container on the host port, so
+// parallel runs (e.g. CI jobs on one host) can remove their own container only
+func ephemeralContainerName(cfg config.Config, port uint16) string {
+	return fmt.Sprintf(
+		"%s_ephemeral_%d", container.ContainerName(cfg.Db.Name, cfg.Environment), port,
+	)
+}
+
+// writeEphemeralConfig prints the DSN and writes the environment configuration (if requested)
+func writeEphemeralConfig(c *cli.Context, cfg config.Config) error {
+	if _, err := fmt.Fprintln(c.App.Writer, cfg.DSN()); err != nil {
+		return fmt.Errorf("Could not print the DSN: %v", err)
+	}
+	if c.String("config-out") == "" {
+		return nil
+	}
+
+	content, err := config.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(c.String("config-out"), content, 0644); err != nil {
+		return fmt.Errorf("Could not write the configuration: %v", err)
+	}
+	log.Infof("Wrote the configuration to %s", c.String("config-out"))
+	return nil
+}
@@ -2,24 +2,38 @@ package start
 
 import (
 	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"strings"
 	"time"
 
-	"github.com/jedib0t/go-pretty/v6/progress"
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
 	"go-migrations/commands"
+	"go-migrations/container"
+	"go-migrations/database"
+	"go-migrations/database/config"
 	"go-migrations/database/driver"
 	"go-migrations/utils"
 )
 
 var (
-	mockableRunWithOutput = utils.RunWithOutput
-	mockableLoadDB        = driver.LoadDB
+	mockableRunWithOutput           = utils.RunWithOutput
+	mockableLoadDB                  = driver.LoadDB
+	mockableGetRuntime              = container.GetRuntime
+	mockableGetEngine               = container.GetEngine
+	mockableLoadConfig              = driver.LoadConfig
+	mockableInitDB                  = driver.InitDB
+	mockableFreePort                = container.FreePort
+	mockableStdin         io.Reader = os.Stdin
 )
 
-var flags = []cli.Flag{
+// postgresPort is the port of the database within the container of an image
+const postgresPort = 5432
+
+var flags = append([]cli.Flag{
 	&cli.StringFlag{
 		Name: "dc-file", Aliases: []string{"d"}, Value: "docker-compose.yaml",
 		Usage: "Path to docker compose file",
@@ -32,6 +46,32 @@ var flags = []cli.Flag{
 		Name: "restart", Aliases: []string{"r"},
 		Usage: "stop the docker-compose database service before starting",
 	},
+	&cli.StringFlag{
+		Name: "image", Aliases: []string{"i"},
+		Usage: "start a container of this image (e.g. postgres:15) instead of a compose service. " +
+			"Defaults to the image of the environment configuration",
+	},
+	&cli.StringFlag{
+		Name: "runtime",
+		Usage: "container runtime (docker, docker-compose, podman or podman-compose), " +
+			"detected if not set",
+	},
+	&cli.BoolFlag{
+		Name:  "ephemeral",
+		Usage: "start a throwaway container of the image on a free port and print its DSN",
+	},
+	&cli.DurationFlag{
+		Name: "timeout", Value: time.Hour,
+		Usage: "remove the ephemeral container after this duration (0 keeps it until stop)",
+	},
+	&cli.StringFlag{
+		Name:  "config-out",
+		Usage: "write the environment configuration of the ephemeral database to this file",
+	},
+	&cli.BoolFlag{
+		Name:  "with-seeds",
+		Usage: "apply the data seeds of the environment after the migrations",
+	},
 	&cli.StringFlag{
 		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
 		Usage: "(relative) path to the folder containing the database migrations",
@@ -40,74 +80,155 @@ var flags = []cli.Flag{
 		Name: "environment", Aliases: []string{"e"}, Value: "development",
 		Usage: "Name of the environment and the corresponding configuration",
 	},
-}
+}, commands.ProtectedFlags...)
 
-// StartCommand starts a local development database based on a docker-compose file
+// StartCommand starts a local development database based on a docker-compose file or directly
+// from an image. The runtime (docker or podman) is detected or chosen with --runtime
 var StartCommand = &cli.Command{
 	Name:   "start",
-	Usage:  "starts a local development database based on a docker-compose file",
+	Usage:  "starts a local development database based on a docker-compose file or an image",
 	Flags:  flags,
 	Before: commands.NoArguments,
 	Action: func(c *cli.Context) error {
-		var err error
-
-		if c.Bool("restart") {
-			err = stopDb(c.String("dc-file"), c.String("service"))
-			if err != nil {
-				return fmt.Errorf("Could not stop database - Err: %v", err)
-			}
+		cfg, err := mockableLoadConfig(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
+			return err
+		}
+		image := c.String("image")
+		if image == "" {
+			image = cfg.Image
 		}
 
-		err = startDb(c.String("dc-file"), c.String("service"))
-		if err != nil {
-			return fmt.Errorf("Could not start database - Err: %v", err)
+		if c.Bool("ephemeral") {
+			return startEphemeral(c, cfg, image)
 		}
 
-		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		err = commands.ConfirmProtected(c, mockableStdin, cfg.Protected, []string{
+			"start the database", "apply the bootstrap migration",
+			"apply all up and repeatable migrations",
+		})
 		if err != nil {
 			return err
 		}
 
-		if err := db.WaitForStart(1*time.Second, 10); err != nil {
-			return err
+		reused := false
+		if image != "" {
+			reused, err = startContainer(c, cfg, image)
+		} else {
+			err = startComposeService(c)
 		}
-		log.Debug("Connected to database")
-
-		if _, err := db.EnsureMigrationsChangelog(); err != nil {
+		if err != nil {
 			return err
 		}
 
-		if err := db.Bootstrap(); err != nil {
+		db, err := mockableLoadDB(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
 			return err
 		}
-		log.Info("Applied bootstrap migration")
+		defer db.Close()
 
-		pw := progress.NewWriter()
-		pw.SetAutoStop(true)
-		pw.SetTrackerPosition(progress.PositionRight)
-		pw.SetUpdateFrequency(time.Millisecond * 250)
+		return setupDb(c, db, reused)
+	},
+}
 
-		go pw.Render()
+// setupDb bootstraps and migrates the started database. A reused database only gets the
+// pending migrations
+func setupDb(c *cli.Context, db database.Database, reused bool) error {
+	if err := db.WaitForStart(1*time.Second, 10); err != nil {
+		return err
+	}
+	log.Debug("Connected to database")
 
-		if err := db.ApplyAllUpMigrations(pw); err != nil {
-			return err
-		}
-		log.Debug("Applied all migrations")
+	observer, err := commands.NewObserver(c)
+	if err != nil {
+		return err
+	}
+	if reused {
+		err = commands.MigratePending(db, observer)
+	} else {
+		err = commands.BootstrapAndMigrate(db, observer)
+	}
+	if err != nil {
+		return err
+	}
 
-		if pw.IsRenderInProgress() {
-			time.Sleep(time.Millisecond * 251)
+	if c.Bool("with-seeds") {
+		return commands.ApplyDataSeeds(db, observer)
+	}
+	return nil
+}
+
+func startComposeService(c *cli.Context) error {
+	runtime, err := mockableGetRuntime(c.String("runtime"))
+	if err != nil {
+		return err
+	}
+
+	if c.Bool("restart") {
+		err = stopDb(runtime, c.String("dc-file"), c.String("service"))
+		if err != nil {
+			return fmt.Errorf("Could not stop database - Err: %v", err)
 		}
+	}
 
-		return nil
-	},
+	err = startDb(runtime, c.String("dc-file"), c.String("service"))
+	if err != nil {
+		return fmt.Errorf("Could not start database - Err: %v", err)
+	}
+	return nil
 }
 
-func startDb(dcFile, service string) error {
-	cmd := exec.Command("docker-compose", "--file", dcFile, "up", "--detach", service)
+// startContainer runs a container of the image with the user, password and database of the
+// configuration. An existing container of the environment is reused (and removed on restart),
+// which is returned as reused
+func startContainer(c *cli.Context, cfg config.Config, image string) (reused bool, err error) {
+	runtime, err := mockableGetEngine(c.String("runtime"))
+	if err != nil {
+		return false, err
+	}
+	name := container.ContainerName(cfg.Db.Name, cfg.Environment)
 
-	_, stderr, err := mockableRunWithOutput(cmd)
+	if c.Bool("restart") {
+		if err := runCommand(runtime.RemoveContainer(name)); err != nil {
+			return false, fmt.Errorf("Could not remove the container %s - Err: %v", name, err)
+		}
+		log.Debugf("Removed container %s", name)
+	}
+
+	// inspect fails if the container does not exist
+	running, _, inspectErr := mockableRunWithOutput(runtime.InspectRunning(name))
+	switch {
+	case inspectErr == nil && strings.TrimSpace(running) == "true":
+		log.Infof("Reusing running container %s", name)
+		return true, nil
+	case inspectErr == nil:
+		reused = true
+		err = runCommand(runtime.StartContainer(name))
+	default:
+		err = runCommand(runtime.RunContainer(container.ContainerSpec{
+			Name: name, Image: image, HostPort: cfg.Db.Port, ContainerPort: postgresPort,
+			Env: postgresEnv(cfg),
+		}))
+	}
 	if err != nil {
-		log.Error(stderr)
+		return false, fmt.Errorf("Could not start the container %s - Err: %v", name, err)
+	}
+
+	log.Infof("Started container %s of %s", name, image)
+	return reused, nil
+}
+
+// postgresEnv configures the user, password and database of the postgres image
+func postgresEnv(cfg config.Config) map[string]string {
+	return map[string]string{
+		"POSTGRES_USER":     cfg.Db.User,
+		"POSTGRES_PASSWORD": cfg.Db.Password,
+		"POSTGRES_DB":       cfg.Db.Name,
+	}
+}
+
+func startDb(runtime container.Runtime, dcFile, service string) error {
+	if err := runCommand(runtime.ComposeUp(dcFile, service)); err != nil {
 		return err
 	}
 
@@ -116,12 +237,8 @@ func startDb(dcFile, service string) error {
 	return nil
 }
 
-func stopDb(dcFile, service string) error {
-	cmd := exec.Command("docker-compose", "--file", dcFile, "rm", "--force", "--stop", service)
-
-	_, stderr, err := mockableRunWithOutput(cmd)
-	if err != nil {
-		log.Error(stderr)
+func stopDb(runtime container.Runtime, dcFile, service string) error {
+	if err := runCommand(runtime.ComposeRemove(dcFile, service)); err != nil {
 		return err
 	}
 
@@ -129,3 +246,13 @@ func stopDb(dcFile, service string) error {
 
 	return nil
 }
+
+// runCommand runs the command and passes its stderr through if it fails
+func runCommand(cmd *exec.Cmd) error {
+	_, stderr, err := mockableRunWithOutput(cmd)
+	if err != nil {
+		log.Error(stderr)
+		return fmt.Errorf("%s failed: %v", strings.Join(cmd.Args, " "), err)
+	}
+	return nil
+}
@@ -38,8 +38,10 @@ func TestStartDbDefaults(t *testing.T) {
 	fakeDb.AssertWaitForStartCalled(t, true)
 	fakeDb.AssertBootstrapCalled(t, true)
 	fakeDb.AssertApplyAllUpMigrationsCalled(t, true)
+	fakeDb.AssertApplyRepeatableMigrationsCalled(t, true)
 	fakeDb.AssertEnsureMigrationsChangelogCalled(t, true)
-
+	fakeDb.AssertApplyDataSeedsCalled(t, false)
+	fakeDb.AssertCloseCalled(t, true)
 }
 
 func TestStartDbWithFlags(t *testing.T) {
@@ -59,5 +61,19 @@ func TestStartDbWithFlags(t *testing.T) {
 	fakeDb.AssertWaitForStartCalled(t, true)
 	fakeDb.AssertBootstrapCalled(t, true)
 	fakeDb.AssertApplyAllUpMigrationsCalled(t, true)
+	fakeDb.AssertApplyRepeatableMigrationsCalled(t, true)
 	fakeDb.AssertEnsureMigrationsChangelogCalled(t, true)
 }
+
+func TestStartDbWithSeeds(t *testing.T) {
+	mockableRunWithOutput = fakeRunWithOutput
+	mockableLoadDB = fakeLoadWithSpy
+
+	args := []string{"sth.exe", "start", "--with-seeds"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	fakeDb.AssertApplyAllUpMigrationsCalled(t, true)
+	fakeDb.AssertApplyDataSeedsCalled(t, true)
+}
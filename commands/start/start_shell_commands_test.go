@@ -7,7 +7,9 @@ import (
 
 	"github.com/sirupsen/logrus/hooks/test"
 
+	"go-migrations/database"
 	"go-migrations/internal"
+	"go-migrations/internal/direction"
 )
 
 var (
@@ -47,7 +49,7 @@ func TestStartWithDefaults(t *testing.T) {
 	}
 
 	expected := []string{
-		"docker-compose", "--file", "docker-compose.yaml",
+		"docker", "compose", "--file", "docker-compose.yaml",
 		"up", "--detach", "database",
 	}
 	if !internal.StrSliceEqual(fakeRun.LastCmd.Args, expected) {
@@ -81,7 +83,7 @@ func TestStartAlternateComposeFile(t *testing.T) {
 	}
 
 	expected := []string{
-		"docker-compose", "--file", "./docker-compose/non_standard.yaml",
+		"docker", "compose", "--file", "./docker-compose/non_standard.yaml",
 		"up", "--detach", "db",
 	}
 	if !internal.StrSliceEqual(fakeRun.LastCmd.Args, expected) {
@@ -103,14 +105,14 @@ func TestStartWithRestart(t *testing.T) {
 	}
 
 	expectedStop := []string{
-		"docker-compose", "--file", "docker-compose.yaml",
+		"docker", "compose", "--file", "docker-compose.yaml",
 		"rm", "--force", "--stop", "db",
 	}
 	if !internal.StrSliceEqual(fakeRun.Cmds[0].Args, expectedStop) {
 		t.Errorf("Expected to run command '%v', but got %s", expectedStop, fakeRun.Cmds[0].Args)
 	}
 	expectedStart := []string{
-		"docker-compose", "--file", "docker-compose.yaml",
+		"docker", "compose", "--file", "docker-compose.yaml",
 		"up", "--detach", "db",
 	}
 	if !internal.StrSliceEqual(fakeRun.Cmds[1].Args, expectedStart) {
@@ -143,3 +145,160 @@ func TestStartFailure(t *testing.T) {
 		t.Error("Expected start to log the stderr of the program for a failure case")
 	}
 }
+
+func TestStartWithRuntime(t *testing.T) {
+	args := []string{"sth.exe", "start", "--restart", "--runtime", "podman-compose"}
+	var fakeRun fakeRunWithSpy
+	mockableRunWithOutput = fakeRun.runWithOutputSuccess
+
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	expectedStop := []string{
+		"podman-compose", "--file", "docker-compose.yaml",
+		"rm", "--force", "--stop", "database",
+	}
+	if !internal.StrSliceEqual(fakeRun.Cmds[0].Args, expectedStop) {
+		t.Errorf("Expected to run command '%v', but got %s", expectedStop, fakeRun.Cmds[0].Args)
+	}
+	expectedStart := []string{
+		"podman-compose", "--file", "docker-compose.yaml",
+		"up", "--detach", "database",
+	}
+	if !internal.StrSliceEqual(fakeRun.Cmds[1].Args, expectedStart) {
+		t.Errorf("Expected to run command '%v', but got %s", expectedStart, fakeRun.Cmds[1].Args)
+	}
+}
+
+func TestStartWithUnknownRuntime(t *testing.T) {
+	args := []string{"sth.exe", "start", "--runtime", "lxc"}
+	var fakeRun fakeRunWithSpy
+	mockableRunWithOutput = fakeRun.runWithOutputSuccess
+
+	if err := app.Run(args); err == nil {
+		t.Errorf("Expected an error for an unknown runtime")
+	}
+	if len(fakeRun.Cmds) > 0 {
+		t.Errorf("Expected no command to run, but got %d", len(fakeRun.Cmds))
+	}
+}
+
+// fakeContainerRun answers the inspect command with the state of the container
+// ("" if it does not exist) and succeeds for all other commands
+func (f *fakeRunWithSpy) fakeContainerRun(state string) func(*exec.Cmd) (string, string, error) {
+	return func(cmd *exec.Cmd) (stdout, stderr string, err error) {
+		f.Cmds = append(f.Cmds, cmd)
+		f.LastCmd = cmd
+		if cmd.Args[1] == "container" && cmd.Args[2] == "inspect" {
+			if state == "" {
+				return "", "Error: No such container", errors.New("exit status 1")
+			}
+			return state + "\n", "", nil
+		}
+		return successStdout, successStderr, nil
+	}
+}
+
+func TestStartImage(t *testing.T) {
+	var fakeRun fakeRunWithSpy
+	mockableRunWithOutput = fakeRun.fakeContainerRun("")
+	mockableLoadDB = fakeLoadWithSpy
+
+	args := []string{"sth.exe", "start", "--image", "postgres:15", "-e", "dev"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	if len(fakeRun.Cmds) != 2 {
+		t.Fatalf("Expected 2 commands to run but got %d", len(fakeRun.Cmds))
+	}
+	expected := []string{
+		"docker", "run", "--detach", "--name", "go-migrations_zlab_dev",
+		"--publish", "35432:5432",
+		"--env", "POSTGRES_DB=zlab", "--env", "POSTGRES_PASSWORD=pass",
+		"--env", "POSTGRES_USER=db_admin",
+		"postgres:15",
+	}
+	if !internal.StrSliceEqual(fakeRun.LastCmd.Args, expected) {
+		t.Errorf("Expected to run command '%v', but got %s", expected, fakeRun.LastCmd.Args)
+	}
+	fakeDb.AssertBootstrapCalled(t, true)
+	fakeDb.AssertApplyAllUpMigrationsCalled(t, true)
+}
+
+func TestStartImageReuseEmptyChangelog(t *testing.T) {
+	fakeConfigImage = "postgres:14"
+	defer func() { fakeConfigImage = "" }()
+	var fakeRun fakeRunWithSpy
+	mockableRunWithOutput = fakeRun.fakeContainerRun("true")
+	mockableLoadDB = fakeLoadWithSpy
+
+	if err := app.Run([]string{"sth.exe", "start"}); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	// a container, which was never migrated, is bootstrapped
+	fakeDb.AssertBootstrapCalled(t, true)
+	fakeDb.AssertApplyAllUpMigrationsCalled(t, true)
+	fakeDb.AssertApplyMigrationsWithCountCalled(t, false)
+}
+
+func TestStartImageReuseContainer(t *testing.T) {
+	testCases := []struct {
+		state    string
+		expected []string
+	}{
+		{"true", []string{"docker", "container", "inspect"}},
+		{"false", []string{"docker", "start", "go-migrations_zlab_development"}},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.state, func(t *testing.T) {
+			fakeConfigImage = "postgres:14"
+			defer func() { fakeConfigImage = "" }()
+			var fakeRun fakeRunWithSpy
+			mockableRunWithOutput = fakeRun.fakeContainerRun(testCase.state)
+			mockableLoadDB = func(migrationsPath, environment string) (database.Database, error) {
+				fakeDb = internal.FakeDbWithSpy{
+					FileMigrations: []database.FileMigration{
+						{ID: "20171101000001"}, {ID: "20171101000002"},
+					},
+					AppliedMigrations: []database.AppliedMigration{{ID: "20171101000001"}},
+				}
+				return &fakeDb, nil
+			}
+
+			if err := app.Run([]string{"sth.exe", "start"}); err != nil {
+				t.Errorf("Error running command - %s", err)
+			}
+
+			lastArgs := fakeRun.LastCmd.Args[:len(testCase.expected)]
+			if !internal.StrSliceEqual(lastArgs, testCase.expected) {
+				t.Errorf("Expected to run command '%v', but got %s", testCase.expected, lastArgs)
+			}
+			// the migrated database of the container only gets the pending migration
+			fakeDb.AssertBootstrapCalled(t, false)
+			fakeDb.AssertApplyAllUpMigrationsCalled(t, false)
+			fakeDb.AssertApplyMigrationsWithCountCalledWith(t, 0, true, direction.Up)
+			fakeDb.AssertApplyRepeatableMigrationsCalled(t, true)
+		})
+	}
+}
+
+func TestStartImageRestart(t *testing.T) {
+	var fakeRun fakeRunWithSpy
+	mockableRunWithOutput = fakeRun.fakeContainerRun("")
+
+	args := []string{"sth.exe", "start", "--image", "postgres:15", "--restart"}
+	if err := app.Run(args); err != nil {
+		t.Errorf("Error running command - %s", err)
+	}
+
+	expectedRemove := []string{"docker", "rm", "--force", "go-migrations_zlab_development"}
+	if !internal.StrSliceEqual(fakeRun.Cmds[0].Args, expectedRemove) {
+		t.Errorf("Expected to run command '%v', but got %s", expectedRemove, fakeRun.Cmds[0].Args)
+	}
+	if fakeRun.LastCmd.Args[1] != "run" {
+		t.Errorf("Expected to run a new container, but got %s", fakeRun.LastCmd.Args)
+	}
+}
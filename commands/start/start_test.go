@@ -7,6 +7,9 @@ import (
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
+
+	"go-migrations/container"
+	"go-migrations/database/config"
 )
 
 var app = cli.NewApp()
@@ -14,7 +17,33 @@ var app = cli.NewApp()
 func TestMain(m *testing.M) {
 	app.Commands = []*cli.Command{
 		StartCommand,
+		StopCommand,
 	}
 	log.SetOutput(ioutil.Discard)
+	mockableGetRuntime = fakeGetRuntime
+	mockableGetEngine = fakeGetRuntime
+	mockableLoadConfig = fakeLoadConfig
+	mockableFreePort = func() (uint16, error) { return 40001, nil }
 	os.Exit(m.Run())
 }
+
+// fakeGetRuntime uses docker compose instead of detecting the installed runtime
+func fakeGetRuntime(name string) (container.Runtime, error) {
+	if name == "" {
+		name = "docker"
+	}
+	return container.GetRuntime(name)
+}
+
+// fakeConfigImage is the image of the environment returned by fakeLoadConfig
+var fakeConfigImage string
+
+func fakeLoadConfig(migrationsPath, environment string) (config.Config, error) {
+	cfg := config.Config{MigrationsPath: migrationsPath, Environment: environment}
+	cfg.Image = fakeConfigImage
+	cfg.Db.Port = 35432
+	cfg.Db.Name = "zlab"
+	cfg.Db.User = "db_admin"
+	cfg.Db.Password = "pass"
+	return cfg, nil
+}
@@ -0,0 +1,132 @@
+package start
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/urfave/cli/v2"
+
+	"go-migrations/commands"
+	"go-migrations/container"
+	"go-migrations/database/config"
+)
+
+var stopFlags = []cli.Flag{
+	&cli.StringFlag{
+		Name: "dc-file", Aliases: []string{"d"}, Value: "docker-compose.yaml",
+		Usage: "Path to docker compose file",
+	},
+	&cli.StringFlag{
+		Name: "service", Aliases: []string{"s"}, Value: "database",
+		Usage: "service name (in the docker-compose file) of the database",
+	},
+	&cli.StringFlag{
+		Name: "image", Aliases: []string{"i"},
+		Usage: "stop the container of the image instead of a compose service. " +
+			"Defaults to the image of the environment configuration",
+	},
+	&cli.BoolFlag{
+		Name:  "ephemeral",
+		Usage: "remove an ephemeral container of the environment (requires --port or --all)",
+	},
+	&cli.UintFlag{
+		Name:  "port",
+		Usage: "remove the ephemeral container on this port (part of the DSN printed by start)",
+	},
+	&cli.BoolFlag{
+		Name:  "all",
+		Usage: "remove all ephemeral containers of the environment (e.g. of parallel runs)",
+	},
+	&cli.StringFlag{
+		Name: "runtime",
+		Usage: "container runtime (docker, docker-compose, podman or podman-compose), " +
+			"detected if not set",
+	},
+	&cli.StringFlag{
+		Name: "migrations-path", Aliases: []string{"p"}, Value: "./migrations/zlab",
+		Usage: "(relative) path to the folder containing the database migrations",
+	},
+	&cli.StringFlag{
+		Name: "environment", Aliases: []string{"e"}, Value: "development",
+		Usage: "Name of the environment and the corresponding configuration",
+	},
+}
+
+// StopCommand stops and removes the local development database started by the start command
+var StopCommand = &cli.Command{
+	Name:   "stop",
+	Usage:  "stops and removes the local development database started by start",
+	Flags:  stopFlags,
+	Before: commands.NoArguments,
+	Action: func(c *cli.Context) error {
+		cfg, err := mockableLoadConfig(c.String("migrations-path"), c.String("environment"))
+		if err != nil {
+			return err
+		}
+		image := c.String("image")
+		if image == "" {
+			image = cfg.Image
+		}
+
+		if !c.Bool("ephemeral") && image == "" {
+			runtime, err := mockableGetRuntime(c.String("runtime"))
+			if err != nil {
+				return err
+			}
+			return stopDb(runtime, c.String("dc-file"), c.String("service"))
+		}
+
+		runtime, err := mockableGetEngine(c.String("runtime"))
+		if err != nil {
+			return err
+		}
+		if !c.Bool("ephemeral") {
+			name := container.ContainerName(cfg.Db.Name, cfg.Environment)
+			if err := runCommand(runtime.RemoveContainer(name)); err != nil {
+				return fmt.Errorf("Could not remove the container %s - Err: %v", name, err)
+			}
+			log.Infof("Removed container %s", name)
+			return nil
+		}
+
+		if c.IsSet("port") {
+			return removeEphemeralContainer(runtime, cfg, c.Uint("port"))
+		}
+		if !c.Bool("all") {
+			return fmt.Errorf("Stopping ephemeral containers requires --port or --all")
+		}
+
+		stdout, stderr, err := mockableRunWithOutput(
+			runtime.ListContainers(container.EphemeralLabel, cfg.Environment),
+		)
+		if err != nil {
+			log.Error(stderr)
+			return fmt.Errorf("Could not list the ephemeral containers - Err: %v", err)
+		}
+		ids := strings.Fields(stdout)
+		if len(ids) == 0 {
+			log.Infof("Found no ephemeral containers of %s", cfg.Environment)
+			return nil
+		}
+		if err := runCommand(runtime.RemoveContainer(ids...)); err != nil {
+			return fmt.Errorf("Could not remove the ephemeral containers - Err: %v", err)
+		}
+		log.Infof("Removed %d ephemeral containers of %s", len(ids), cfg.Environment)
+		return nil
+	},
+}
+
+// removeEphemeralContainer removes the ephemeral container of the environment on the port
+func removeEphemeralContainer(runtime container.Runtime, cfg config.Config, port uint) error {
+	if port == 0 || port > math.MaxUint16 {
+		return fmt.Errorf("Invalid port %d", port)
+	}
+	name := ephemeralContainerName(cfg, uint16(port))
+	if err := runCommand(runtime.RemoveContainer(name)); err != nil {
+		return fmt.Errorf("Could not remove the ephemeral container %s - Err: %v", name, err)
+	}
+	log.Infof("Removed ephemeral container %s", name)
+	return nil
+}
@@ -0,0 +1,213 @@
+package container
+
+import (
+	"fmt"
+	"net"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/utils"
+)
+
+var (
+	mockableLookPath      = exec.LookPath
+	mockableRunWithOutput = utils.RunWithOutput
+)
+
+// Runtime is a container engine together with its compose implementation
+type Runtime struct {
+	// Name selects the runtime (e.g. with the --runtime flag)
+	Name string
+	// Engine is the binary of the container engine
+	Engine string
+	// Compose is the command of the compose implementation
+	Compose []string
+}
+
+// Runtimes are the supported runtimes in the order of the auto-detection
+var Runtimes = []Runtime{
+	{Name: "docker", Engine: "docker", Compose: []string{"docker", "compose"}},
+	{Name: "docker-compose", Engine: "docker", Compose: []string{"docker-compose"}},
+	{Name: "podman", Engine: "podman", Compose: []string{"podman", "compose"}},
+	{Name: "podman-compose", Engine: "podman", Compose: []string{"podman-compose"}},
+}
+
+// GetRuntime returns the runtime with the name. Without a name the first available runtime
+// is detected
+func GetRuntime(name string) (Runtime, error) {
+	if name != "" {
+		for _, runtime := range Runtimes {
+			if runtime.Name == name {
+				return runtime, nil
+			}
+		}
+		return Runtime{}, fmt.Errorf(
+			"Unknown container runtime %s, expected one of: %s", name, runtimeNames(),
+		)
+	}
+
+	for _, runtime := range Runtimes {
+		if runtime.available() {
+			log.Debugf("Detected container runtime %s", runtime.Name)
+			return runtime, nil
+		}
+	}
+	return Runtime{}, fmt.Errorf("Found no container runtime, expected one of: %s", runtimeNames())
+}
+
+func runtimeNames() string {
+	names := []string{}
+	for _, runtime := range Runtimes {
+		names = append(names, runtime.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+func (r Runtime) available() bool {
+	if _, err := mockableLookPath(r.Compose[0]); err != nil {
+		return false
+	}
+	if len(r.Compose) == 1 {
+		return true
+	}
+	// the compose plugin of the engine is not necessarily installed
+	_, _, err := mockableRunWithOutput(r.ComposeCommand("version"))
+	return err == nil
+}
+
+// ComposeCommand creates a compose command with the arguments
+func (r Runtime) ComposeCommand(args ...string) *exec.Cmd {
+	cmdArgs := append([]string{}, r.Compose[1:]...)
+	return exec.Command(r.Compose[0], append(cmdArgs, args...)...)
+}
+
+// ComposeUp creates the command starting the service of the compose file in the background
+func (r Runtime) ComposeUp(composeFile, service string) *exec.Cmd {
+	return r.ComposeCommand("--file", composeFile, "up", "--detach", service)
+}
+
+// ComposeRemove creates the command stopping and removing the service of the compose file
+func (r Runtime) ComposeRemove(composeFile, service string) *exec.Cmd {
+	return r.ComposeCommand("--file", composeFile, "rm", "--force", "--stop", service)
+}
+
+// GetEngine returns the runtime with the name like GetRuntime. Without a name the first runtime
+// with an available engine is detected, no matter if its compose implementation is installed
+func GetEngine(name string) (Runtime, error) {
+	if name != "" {
+		return GetRuntime(name)
+	}
+
+	for _, runtime := range Runtimes {
+		if _, err := mockableLookPath(runtime.Engine); err == nil {
+			log.Debugf("Detected container engine %s", runtime.Engine)
+			return runtime, nil
+		}
+	}
+	return Runtime{}, fmt.Errorf("Found no container engine, expected docker or podman")
+}
+
+// InspectRunning creates the command printing whether the container is running (true/false).
+// The command fails if the container does not exist
+func (r Runtime) InspectRunning(name string) *exec.Cmd {
+	return exec.Command(r.Engine, "container", "inspect", "--format", "{{.State.Running}}", name)
+}
+
+// EphemeralLabel marks ephemeral containers, its value is the environment
+const EphemeralLabel = "go-migrations.ephemeral"
+
+// ContainerSpec describes a container running in the background
+type ContainerSpec struct {
+	Name  string
+	Image string
+	// HostPort publishes the ContainerPort on the host
+	HostPort      uint16
+	ContainerPort uint16
+	Env           map[string]string
+	Labels        map[string]string
+	// Timeout stops the container after the duration (without a timeout it runs until stopped).
+	// It requires the entrypoint of the postgres image
+	Timeout time.Duration
+	// Remove removes the container as soon as it stops
+	Remove bool
+}
+
+// RunContainer creates the command running the container in the background
+func (r Runtime) RunContainer(spec ContainerSpec) *exec.Cmd {
+	args := []string{
+		"run", "--detach", "--name", spec.Name,
+		"--publish", fmt.Sprintf("%d:%d", spec.HostPort, spec.ContainerPort),
+	}
+	if spec.Remove {
+		args = append(args, "--rm")
+	}
+	args = append(args, keyValueArgs("--env", spec.Env)...)
+	args = append(args, keyValueArgs("--label", spec.Labels)...)
+	if spec.Timeout > 0 {
+		// a fast shutdown (SIGINT) does not wait for open sessions
+		args = append(
+			args, "--entrypoint", "timeout", spec.Image,
+			"-s", "INT", fmt.Sprintf("%d", int(spec.Timeout.Seconds())),
+			"docker-entrypoint.sh", "postgres",
+		)
+		return exec.Command(r.Engine, args...)
+	}
+	return exec.Command(r.Engine, append(args, spec.Image)...)
+}
+
+// keyValueArgs returns the flag with key=value for every entry sorted by key
+func keyValueArgs(flag string, values map[string]string) []string {
+	keys := []string{}
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	args := []string{}
+	for _, key := range keys {
+		args = append(args, flag, fmt.Sprintf("%s=%s", key, values[key]))
+	}
+	return args
+}
+
+// ListContainers creates the command printing the IDs of all containers with the label
+func (r Runtime) ListContainers(label, value string) *exec.Cmd {
+	return exec.Command(
+		r.Engine, "ps", "--all", "--quiet", "--filter", fmt.Sprintf("label=%s=%s", label, value),
+	)
+}
+
+// StartContainer creates the command starting the stopped container
+func (r Runtime) StartContainer(name string) *exec.Cmd {
+	return exec.Command(r.Engine, "start", name)
+}
+
+// RemoveContainer creates the command stopping and removing the containers
+func (r Runtime) RemoveContainer(names ...string) *exec.Cmd {
+	return exec.Command(r.Engine, append([]string{"rm", "--force"}, names...)...)
+}
+
+// FreePort returns a currently unused port of the host
+func FreePort() (uint16, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, fmt.Errorf("Could not find a free port: %v", err)
+	}
+	defer listener.Close()
+	return uint16(listener.Addr().(*net.TCPAddr).Port), nil
+}
+
+var invalidNameChars = regexp.MustCompile(`[^a-zA-Z0-9_.-]`)
+
+// ContainerName returns the name of the container of a database and environment. The name stays
+// the same across runs, so the container is reused
+func ContainerName(dbName, environment string) string {
+	return invalidNameChars.ReplaceAllString(
+		fmt.Sprintf("go-migrations_%s_%s", dbName, environment), "_",
+	)
+}
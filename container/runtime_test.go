@@ -0,0 +1,138 @@
+package container
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+	"time"
+
+	"go-migrations/internal"
+	"go-migrations/utils"
+)
+
+func resetMockVariables() {
+	mockableLookPath = exec.LookPath
+	mockableRunWithOutput = utils.RunWithOutput
+}
+
+func TestGetRuntimeByName(t *testing.T) {
+	runtime, err := GetRuntime("podman-compose")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expected := []string{
+		"podman-compose", "--file", "dc.yaml", "up", "--detach", "database",
+	}
+	if args := runtime.ComposeUp("dc.yaml", "database").Args; !internal.StrSliceEqual(args, expected) {
+		t.Errorf("Expected the command '%v', but got %v", expected, args)
+	}
+
+	if _, err := GetRuntime("lxc"); err == nil {
+		t.Errorf("Expected an error for an unknown runtime")
+	}
+}
+
+func TestGetRuntimeDetection(t *testing.T) {
+	defer resetMockVariables()
+
+	testCases := []struct {
+		name           string
+		binaries       map[string]bool
+		composePlugins map[string]bool
+		expected       string
+	}{
+		{
+			"docker compose plugin",
+			map[string]bool{"docker": true, "docker-compose": true},
+			map[string]bool{"docker": true},
+			"docker",
+		},
+		{
+			"legacy docker-compose",
+			map[string]bool{"docker": true, "docker-compose": true},
+			map[string]bool{},
+			"docker-compose",
+		},
+		{
+			"podman compose plugin",
+			map[string]bool{"podman": true, "podman-compose": true},
+			map[string]bool{"podman": true},
+			"podman",
+		},
+		{
+			"podman-compose",
+			map[string]bool{"podman": true, "podman-compose": true},
+			map[string]bool{},
+			"podman-compose",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			mockableLookPath = func(file string) (string, error) {
+				if testCase.binaries[file] {
+					return "/usr/bin/" + file, nil
+				}
+				return "", errors.New("not found")
+			}
+			mockableRunWithOutput = func(cmd *exec.Cmd) (string, string, error) {
+				if testCase.composePlugins[cmd.Args[0]] {
+					return "", "", nil
+				}
+				return "", "unknown command", errors.New("exit status 125")
+			}
+
+			runtime, err := GetRuntime("")
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if runtime.Name != testCase.expected {
+				t.Errorf("Expected runtime %s, but got %s", testCase.expected, runtime.Name)
+			}
+		})
+	}
+}
+
+func TestGetRuntimeNoneAvailable(t *testing.T) {
+	defer resetMockVariables()
+	mockableLookPath = func(file string) (string, error) {
+		return "", errors.New("not found")
+	}
+
+	if _, err := GetRuntime(""); err == nil {
+		t.Errorf("Expected an error without a container runtime")
+	}
+}
+
+func TestContainerName(t *testing.T) {
+	if name := ContainerName("my db", "dev/ci"); name != "go-migrations_my_db_dev_ci" {
+		t.Errorf("Expected a sanitized container name, but got %s", name)
+	}
+}
+
+func TestRunContainer(t *testing.T) {
+	runtime, _ := GetEngine("podman")
+	cmd := runtime.RunContainer(ContainerSpec{
+		Name: "db", Image: "postgres:15", HostPort: 40001, ContainerPort: 5432,
+		Env:     map[string]string{"POSTGRES_USER": "admin", "POSTGRES_DB": "zlab"},
+		Labels:  map[string]string{EphemeralLabel: "ci"},
+		Timeout: 90 * time.Second, Remove: true,
+	})
+
+	expected := []string{
+		"podman", "run", "--detach", "--name", "db", "--publish", "40001:5432", "--rm",
+		"--env", "POSTGRES_DB=zlab", "--env", "POSTGRES_USER=admin",
+		"--label", "go-migrations.ephemeral=ci",
+		"--entrypoint", "timeout", "postgres:15", "-s", "INT", "90",
+		"docker-entrypoint.sh", "postgres",
+	}
+	if !internal.StrSliceEqual(cmd.Args, expected) {
+		t.Errorf("Expected the command %v, but got %v", expected, cmd.Args)
+	}
+}
+
+func TestFreePort(t *testing.T) {
+	port, err := FreePort()
+	if err != nil || port == 0 {
+		t.Errorf("Expected a free port, but got %d (%v)", port, err)
+	}
+}
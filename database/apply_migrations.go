@@ -4,6 +4,7 @@ import (
 	"database/sql"
 	"fmt"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -20,30 +21,46 @@ var (
 	mockableApplyVerify         = ApplyVerify
 )
 
-var ChangelogInsertSQL = "INSERT INTO %s (id, name, applied_at) VALUES ('%s', '%s', now())"
+// ChangelogInsertSQL inserts an applied migration (bound parameters: id, name)
+var ChangelogInsertSQL = "INSERT INTO %s (id, name, applied_at) VALUES ($1, $2, now())"
+
+// ChangelogInsertLiteralSQL inserts an applied migration with quoted literals (see QuoteLiteral)
+// for scripts like seeds, which are not executed with bound parameters
+var ChangelogInsertLiteralSQL = "INSERT INTO %s (id, name, applied_at) VALUES (%s, %s, now())"
+
+// ChangelogBaselineInsertSQL inserts a migration, which was not executed, but marked as applied
+// (bound parameters: id, name)
+var ChangelogBaselineInsertSQL = "INSERT INTO %s (id, name, applied_at, baseline) " +
+	"VALUES ($1, $2, now(), true)"
+
+// ChangelogDeleteSQL removes a migration from the changelog (bound parameter: id)
+var ChangelogDeleteSQL = "DELETE FROM %s WHERE id = $1"
 
 // FilterMigrationsByText filters the migrations by filename.
-// If more then one migration remains an error is thrown
+// If more then one migration remains an error is thrown.
+// An error is also returned if the migration would violate the required migrations
+// (an up migration with missing requirements or a down migration still required by others).
+// If applications are given only migrations of these applications are considered
 func FilterMigrationsByText(
 	filter string, dir direction.MigrateDirection,
-	fileMigrations []FileMigration, appliedMigrations []AppliedMigration,
+	fileMigrations []FileMigration, appliedMigrations []AppliedMigration, applications []string,
 ) (FileMigration, error) {
 	if dir == direction.Down {
-		return filterDownMigrationsByText(filter, fileMigrations, appliedMigrations)
+		return filterDownMigrationsByText(filter, fileMigrations, appliedMigrations, applications)
 	}
-	return filterUpMigrationsByText(filter, fileMigrations, appliedMigrations)
+	return filterUpMigrationsByText(filter, fileMigrations, appliedMigrations, applications)
 }
 
 func filterUpMigrationsByText(filter string, fileMigrations []FileMigration,
-	appliedMigrations []AppliedMigration) (filteredMigration FileMigration, err error,
-) {
+	appliedMigrations []AppliedMigration, applications []string,
+) (filteredMigration FileMigration, err error) {
 	appliedIDLookup := map[string]bool{}
 	for _, mig := range appliedMigrations {
 		appliedIDLookup[mig.ID] = true
 	}
 
 	foundMigrations := []FileMigration{}
-	for _, mig := range fileMigrations {
+	for _, mig := range FilterApplications(fileMigrations, applications) {
 		if strings.Contains(mig.Filename, filter) && !appliedIDLookup[mig.ID] {
 			foundMigrations = append(foundMigrations, mig)
 		}
@@ -63,24 +80,29 @@ func filterUpMigrationsByText(filter string, fileMigrations []FileMigration,
 
 	filteredMigration = foundMigrations[0]
 
+	err = ensureUpRequirements([]FileMigration{filteredMigration}, appliedMigrations)
+	if err != nil {
+		return FileMigration{}, err
+	}
+
 	return filteredMigration, nil
 }
 
 func filterDownMigrationsByText(filter string, fileMigrations []FileMigration,
-	appliedMigrations []AppliedMigration) (filteredMigration FileMigration, err error,
-) {
-	fileIDLookup := map[string]int{}
-	for idx, mig := range fileMigrations {
-		fileIDLookup[mig.ID] = idx
+	appliedMigrations []AppliedMigration, applications []string,
+) (filteredMigration FileMigration, err error) {
+	fileIDLookup := map[string]FileMigration{}
+	for _, mig := range FilterApplications(fileMigrations, applications) {
+		fileIDLookup[mig.ID] = mig
 	}
 
 	foundMigrations := []FileMigration{}
 	for _, mig := range appliedMigrations {
 		lookupText := fmt.Sprintf("%s_%s.sql", mig.ID, mig.Name)
 		if strings.Contains(lookupText, filter) {
-			idx, exists := fileIDLookup[mig.ID]
+			fileMigration, exists := fileIDLookup[mig.ID]
 			if exists {
-				foundMigrations = append(foundMigrations, fileMigrations[idx])
+				foundMigrations = append(foundMigrations, fileMigration)
 			}
 		}
 	}
@@ -99,31 +121,53 @@ func filterDownMigrationsByText(filter string, fileMigrations []FileMigration,
 
 	filteredMigration = foundMigrations[0]
 
+	err = ensureDownRequirements(
+		[]FileMigration{filteredMigration}, fileMigrations, appliedMigrations,
+	)
+	if err != nil {
+		return FileMigration{}, err
+	}
+
 	return filteredMigration, nil
 }
 
 // FilterMigrationsByCount filters the migrations for the next n migrations.
 // This relies on a "consistent changelog" and returns an error if no migrations are left
-// For down migrations the migrations are sorted reversed (descending by ID)
+// For down migrations the migrations are sorted reversed to the applied migrations
+// (descending by ID or by the apply order if the applied migrations are sorted that way).
+// Just like FilterMigrationsByText the required migrations are checked and
+// the migrations can be restricted to some applications
 func FilterMigrationsByCount(
 	count uint, all bool, dir direction.MigrateDirection,
-	fileMigrations []FileMigration, appliedMigrations []AppliedMigration,
+	fileMigrations []FileMigration, appliedMigrations []AppliedMigration, applications []string,
 ) ([]FileMigration, error) {
 	if dir == direction.Down {
-		return filterDownMigrationsByCount(count, all, fileMigrations, appliedMigrations)
+		return filterDownMigrationsByCount(
+			count, all, fileMigrations, appliedMigrations, applications,
+		)
 	}
-	return filterUpMigrationsByCount(count, all, fileMigrations, appliedMigrations)
+	return filterUpMigrationsByCount(count, all, fileMigrations, appliedMigrations, applications)
 }
 
 func filterUpMigrationsByCount(count uint, all bool, fileMigrations []FileMigration,
-	appliedMigrations []AppliedMigration) (migrations []FileMigration, err error,
-) {
-	appliedCount := len(appliedMigrations)
+	appliedMigrations []AppliedMigration, applications []string,
+) (migrations []FileMigration, err error) {
+	appliedIDLookup := map[string]bool{}
+	for _, mig := range appliedMigrations {
+		appliedIDLookup[mig.ID] = true
+	}
 
-	if appliedCount == len(fileMigrations) {
+	pendingMigrations := []FileMigration{}
+	for _, mig := range FilterApplications(fileMigrations, applications) {
+		if !appliedIDLookup[mig.ID] {
+			pendingMigrations = append(pendingMigrations, mig)
+		}
+	}
+
+	if len(pendingMigrations) == 0 {
 		return migrations, fmt.Errorf("No migrations left to apply")
 	}
-	if count > 0 && appliedCount+int(count) > len(fileMigrations) {
+	if count > 0 && int(count) > len(pendingMigrations) {
 		log.Warningf(
 			dedent.Dedent(`
 				The received count (%d) is bigger than the remaining migrations.
@@ -134,23 +178,38 @@ func filterUpMigrationsByCount(count uint, all bool, fileMigrations []FileMigrat
 	}
 
 	if all {
-		migrations = fileMigrations[appliedCount:]
+		migrations = pendingMigrations
 	} else {
-		migrations = fileMigrations[appliedCount : appliedCount+int(count)]
+		migrations = pendingMigrations[:count]
+	}
+
+	if err := ensureUpRequirements(migrations, appliedMigrations); err != nil {
+		return nil, err
 	}
 
 	return migrations, nil
 }
 
 func filterDownMigrationsByCount(count uint, all bool, fileMigrations []FileMigration,
-	appliedMigrations []AppliedMigration) (migrations []FileMigration, err error,
-) {
-	appliedCount := len(appliedMigrations)
-	if appliedCount == 0 {
+	appliedMigrations []AppliedMigration, applications []string,
+) (migrations []FileMigration, err error) {
+	fileIDLookup := map[string]FileMigration{}
+	for _, mig := range FilterApplications(fileMigrations, applications) {
+		fileIDLookup[mig.ID] = mig
+	}
+
+	removableMigrations := []FileMigration{}
+	for idx := len(appliedMigrations) - 1; idx >= 0; idx-- {
+		if mig, exists := fileIDLookup[appliedMigrations[idx].ID]; exists {
+			removableMigrations = append(removableMigrations, mig)
+		}
+	}
+
+	if len(removableMigrations) == 0 {
 		return migrations, fmt.Errorf("No migrations left to remove")
 	}
 
-	if appliedCount < int(count) {
+	if len(removableMigrations) < int(count) {
 		log.Warningf(
 			dedent.Dedent(`
 				The received count (%d) is bigger than the applied migrations.
@@ -160,32 +219,85 @@ func filterDownMigrationsByCount(count uint, all bool, fileMigrations []FileMigr
 		all = true
 	}
 
-	var lastIdx int
 	if all {
-		lastIdx = 0
+		migrations = removableMigrations
 	} else {
-		lastIdx = appliedCount - int(count)
+		migrations = removableMigrations[:count]
 	}
 
-	for idx := appliedCount - 1; idx >= lastIdx; idx-- {
-		migrations = append(migrations, fileMigrations[idx])
+	if err := ensureDownRequirements(migrations, fileMigrations, appliedMigrations); err != nil {
+		return nil, err
 	}
 
 	return migrations, nil
 }
 
+// FilterMigrationsUpToID returns all migrations up to (and including) the given ID.
+// The ID has to match one of the migrations
+func FilterMigrationsUpToID(toID string, fileMigrations []FileMigration) (
+	migrations []FileMigration, err error,
+) {
+	for _, mig := range fileMigrations {
+		if mig.ID <= toID {
+			migrations = append(migrations, mig)
+		}
+		if mig.ID == toID {
+			return migrations, nil
+		}
+	}
+	return nil, fmt.Errorf("Found no migration with the id %s", toID)
+}
+
 // ApplyMigration applies a migration in a transaction and updates the changelog
 // For up migrations a verify script is executed and rolled back in a separate transaction.
+// The before_each and after_each hooks of the direction run around the migration.
+// The observer is notified about the start, the verify and the end (or failure) of the migration
 func ApplyMigration(
 	db *sql.DB, migration FileMigration, changelogTable string, dir direction.MigrateDirection,
+	hooks Hooks, observer Observer,
 ) error {
+	if err := applyMigration(db, migration, changelogTable, dir, hooks, observer); err != nil {
+		return err
+	}
+	// the migration is reported as applied before, as a failed hook does not undo it
+	return hooks.run(db, hookPoints[dir].afterEach, migration, dir, observer)
+}
+
+func applyMigration(
+	db *sql.DB, migration FileMigration, changelogTable string, dir direction.MigrateDirection,
+	hooks Hooks, observer Observer,
+) (err error) {
+	event := Event{Direction: dir, Migration: migration}
+	observer.Notify(withType(event, MigrationStarted))
+	start := time.Now()
+	defer func() {
+		event.Duration = time.Since(start)
+		event.Err = err
+		if err != nil {
+			observer.Notify(withType(event, MigrationFailed))
+			return
+		}
+		observer.Notify(withType(event, MigrationFinished))
+	}()
+
 	if dir == direction.Down {
-		return applyDownMigration(db, migration, changelogTable)
+		return applyDownMigration(db, migration, changelogTable, hooks, observer)
 	}
-	return applyUpMigration(db, migration, changelogTable)
+	return applyUpMigration(db, migration, changelogTable, hooks, observer)
+}
+
+func withType(event Event, eventType EventType) Event {
+	event.Type = eventType
+	return event
 }
 
-func applyDownMigration(db *sql.DB, migration FileMigration, changelogTable string) error {
+func applyDownMigration(
+	db *sql.DB, migration FileMigration, changelogTable string, hooks Hooks, observer Observer,
+) error {
+	if err := hooks.run(db, BeforeEachDown, migration, direction.Down, observer); err != nil {
+		return err
+	}
+
 	if err := mockableMigrateDown(db, migration); err != nil {
 		return err
 	}
@@ -197,7 +309,13 @@ func applyDownMigration(db *sql.DB, migration FileMigration, changelogTable stri
 	return nil
 }
 
-func applyUpMigration(db *sql.DB, migration FileMigration, changelogTable string) error {
+func applyUpMigration(
+	db *sql.DB, migration FileMigration, changelogTable string, hooks Hooks, observer Observer,
+) error {
+	if err := hooks.run(db, BeforeEachUp, migration, direction.Up, observer); err != nil {
+		return err
+	}
+
 	if err := mockableMigrateUp(db, migration); err != nil {
 		return err
 	}
@@ -207,13 +325,17 @@ func applyUpMigration(db *sql.DB, migration FileMigration, changelogTable string
 	}
 
 	if err := mockableApplyVerify(db, migration); err != nil {
+		observer.Notify(Event{Type: VerifyFailed, Migration: migration, Err: err})
 		return err
 	}
+	if !migration.IsGo() {
+		observer.Notify(Event{Type: VerifyPassed, Migration: migration})
+	}
 
 	return nil
 }
 
-// ApplyUpSQL is an internal helper to apply the up migration in a transaction
+// ApplyUpSQL is an internal helper to apply the up migration (SQL or Go) in a transaction
 // it does not perform anything else (like verify execution)
 func ApplyUpSQL(db *sql.DB, migration FileMigration) error {
 	upTx, err := db.Begin()
@@ -221,7 +343,7 @@ func ApplyUpSQL(db *sql.DB, migration FileMigration) error {
 		return fmt.Errorf("Error opening transaction: %v", err)
 	}
 
-	_, err = upTx.Exec(migration.UpSQL)
+	err = execMigration(upTx, migration.UpSQL, migration.UpFunc)
 	if err != nil {
 		rollbackError := upTx.Rollback()
 		if rollbackError != nil {
@@ -245,7 +367,7 @@ func ApplyUpSQL(db *sql.DB, migration FileMigration) error {
 	return nil
 }
 
-// ApplyDownSQL is an internal helper to apply the down migration in a transaction
+// ApplyDownSQL is an internal helper to apply the down migration (SQL or Go) in a transaction
 // it does not perform anything else (like changelog update)
 func ApplyDownSQL(db *sql.DB, migration FileMigration) error {
 	tx, err := db.Begin()
@@ -253,7 +375,7 @@ func ApplyDownSQL(db *sql.DB, migration FileMigration) error {
 		return fmt.Errorf("Error opening transaction: %v", err)
 	}
 
-	_, err = tx.Exec(migration.DownSQL)
+	err = execMigration(tx, migration.DownSQL, migration.DownFunc)
 	if err != nil {
 		rollbackError := tx.Rollback()
 		if rollbackError != nil {
@@ -279,12 +401,9 @@ func ApplyDownSQL(db *sql.DB, migration FileMigration) error {
 
 // InsertToChangelog is an internal helper to insert the migration into the changelog
 func InsertToChangelog(db *sql.DB, migration FileMigration, changelogTable string) error {
-	_, err := db.Exec(fmt.Sprintf(
-		ChangelogInsertSQL,
-		changelogTable,
-		migration.ID,
-		migration.Description,
-	))
+	_, err := db.Exec(
+		fmt.Sprintf(ChangelogInsertSQL, changelogTable), migration.ID, migration.Description,
+	)
 	if err != nil {
 		return fmt.Errorf(
 			"Could not add the migration %s from the changelog: %v",
@@ -294,9 +413,44 @@ func InsertToChangelog(db *sql.DB, migration FileMigration, changelogTable strin
 	return nil
 }
 
+// InsertBaselineToChangelog marks the migrations as applied (as a baseline) without executing
+// them. All migrations are inserted in a single transaction
+func InsertBaselineToChangelog(
+	db *sql.DB, migrations []FileMigration, changelogTable string,
+) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error opening transaction: %v", err)
+	}
+
+	for _, migration := range migrations {
+		_, err = tx.Exec(
+			fmt.Sprintf(ChangelogBaselineInsertSQL, changelogTable),
+			migration.ID, migration.Description,
+		)
+		if err != nil {
+			rollbackError := tx.Rollback()
+			if rollbackError != nil {
+				return fmt.Errorf(
+					"Could not add the baseline of %s to the changelog: %v \n and rollback error: %v",
+					migration.Filename, err, rollbackError,
+				)
+			}
+			return fmt.Errorf(
+				"Could not add the baseline of %s to the changelog: %v", migration.Filename, err,
+			)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("Error during commit of the baseline: %v", err)
+	}
+	return nil
+}
+
 // RemoveFromChangelog is an internal helper to remove the migration from the changelog
 func RemoveFromChangelog(db *sql.DB, migration FileMigration, changelogTable string) error {
-	_, err := db.Exec(fmt.Sprintf(`DELETE FROM %s WHERE id = '%s'`, changelogTable, migration.ID))
+	_, err := db.Exec(fmt.Sprintf(ChangelogDeleteSQL, changelogTable), migration.ID)
 	if err != nil {
 		return fmt.Errorf(
 			"Could not remove the migration %s from the changelog: %v",
@@ -308,6 +462,9 @@ func RemoveFromChangelog(db *sql.DB, migration FileMigration, changelogTable str
 
 // ApplyVerify is an internal helper to apply the verify script in a transaction and roll it back
 func ApplyVerify(db *sql.DB, migration FileMigration) error {
+	if migration.IsGo() {
+		return nil
+	}
 	verifyTx, err := db.Begin()
 	if err != nil {
 		return fmt.Errorf("Error opening transaction for verify: %v", err)
@@ -28,7 +28,7 @@ func TestApplyDownMigration(t *testing.T) {
 	}
 
 	db, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	err := ApplyMigration(db, expectedMigration, "schema.sth", direction.Down)
+	err := ApplyMigration(db, expectedMigration, "schema.sth", direction.Down, Hooks{}, NoObserver)
 	if err != nil {
 		t.Errorf("Expected no error for applying up migrations, but got: %s", err)
 	}
@@ -62,7 +62,7 @@ func TestApplyDownMigrationDownMigrationError(t *testing.T) {
 	}
 
 	db, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	err := ApplyMigration(db, FileMigration{}, "sth", direction.Down)
+	err := ApplyMigration(db, FileMigration{}, "sth", direction.Down, Hooks{}, NoObserver)
 	if err == nil {
 		t.Errorf("Expected error for applying up migrations, but got nothing")
 	}
@@ -89,7 +89,7 @@ func TestApplyDownMigrationChangelogError(t *testing.T) {
 	}
 
 	db, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	err := ApplyMigration(db, FileMigration{}, "sth", direction.Down)
+	err := ApplyMigration(db, FileMigration{}, "sth", direction.Down, Hooks{}, NoObserver)
 	if err == nil {
 		t.Errorf("Expected error for applying up migrations, but got nothing")
 	}
@@ -142,7 +142,9 @@ func TestRemoveFromChangelog(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	migration := FileMigration{ID: "1"}
 
-	mock.ExpectExec(`DELETE FROM sth WHERE id = '1'`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM sth WHERE id = $1`).
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = RemoveFromChangelog(db, migration, "sth")
 	if err != nil {
@@ -200,7 +202,7 @@ func TestFilterDownMigrationsByCount(t *testing.T) {
 		}
 
 		migrations, err := FilterMigrationsByCount(
-			testCase.count, testCase.all, direction.Down, fileMigrations, appliedMigrations,
+			testCase.count, testCase.all, direction.Down, fileMigrations, appliedMigrations, nil,
 		)
 		if err != nil {
 			t.Errorf("Expected no error, but got: %s", err)
@@ -212,9 +214,27 @@ func TestFilterDownMigrationsByCount(t *testing.T) {
 	}
 }
 
+func TestFilterDownMigrationsByCountApplyOrder(t *testing.T) {
+	fileMigrations := []FileMigration{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	// the applied migrations are given in the order they were applied
+	appliedMigrations := []AppliedMigration{{ID: "1"}, {ID: "3"}, {ID: "2"}}
+
+	migrations, err := FilterMigrationsByCount(
+		2, false, direction.Down, fileMigrations, appliedMigrations, nil,
+	)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+
+	expectedMigrations := []FileMigration{{ID: "2"}, {ID: "3"}}
+	if diff := pretty.Compare(expectedMigrations, migrations); diff != "" {
+		t.Errorf("Did not follow the apply order:\n%s", diff)
+	}
+}
+
 func TestFilterDownMigrationsByCountNothingLeft(t *testing.T) {
 	_, err := FilterMigrationsByCount(
-		3, false, direction.Down, []FileMigration{{ID: "1"}}, []AppliedMigration{},
+		3, false, direction.Down, []FileMigration{{ID: "1"}}, []AppliedMigration{}, nil,
 	)
 	if err == nil {
 		t.Errorf("Expected error, but got  nothing")
@@ -266,7 +286,7 @@ func TestFilterDownMigrationsByText(t *testing.T) {
 	for _, testCase := range testCases {
 		migration, err := FilterMigrationsByText(
 			testCase.filter, direction.Down,
-			testCase.fileMigrations, testCase.appliedMigrations,
+			testCase.fileMigrations, testCase.appliedMigrations, nil,
 		)
 		if err != nil {
 			t.Errorf("Expected no error, but got: %s", err)
@@ -317,7 +337,7 @@ func TestFilterDownMigrationsByTextError(t *testing.T) {
 	for _, testCase := range testCases {
 		_, err := FilterMigrationsByText(
 			testCase.filter, direction.Down,
-			testCase.fileMigrations, testCase.appliedMigrations,
+			testCase.fileMigrations, testCase.appliedMigrations, nil,
 		)
 		if err == nil {
 			t.Errorf("Expected error, but got none")
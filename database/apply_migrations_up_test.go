@@ -35,11 +35,16 @@ func TestApplyUpMigration(t *testing.T) {
 		return nil
 	}
 
+	observer, eventTypes := recordEventTypes()
 	db, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	err := ApplyMigration(db, expectedMigration, "schema.sth", direction.Up)
+	err := ApplyMigration(db, expectedMigration, "schema.sth", direction.Up, Hooks{}, observer)
 	if err != nil {
 		t.Errorf("Expected no error for applying up migrations, but got: %s", err)
 	}
+	expectedTypes := []EventType{MigrationStarted, VerifyPassed, MigrationFinished}
+	if diff := pretty.Compare(*eventTypes, expectedTypes); diff != "" {
+		t.Errorf("Got unexpected events: (-got +want)\n%s", diff)
+	}
 
 	if diff := pretty.Compare(expectedMigration, migrateUpCall); diff != "" {
 		t.Errorf("Did not pass right FileMigrations to migrateUp:\n%s", diff)
@@ -79,7 +84,7 @@ func TestApplyUpMigrationUpMigrationError(t *testing.T) {
 	}
 
 	db, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	err := ApplyMigration(db, FileMigration{}, "sth", direction.Up)
+	err := ApplyMigration(db, FileMigration{}, "sth", direction.Up, Hooks{}, NoObserver)
 	if err == nil {
 		t.Errorf("Expected error for applying up migrations, but got nothing")
 	}
@@ -115,11 +120,16 @@ func TestApplyUpMigrationVerifyError(t *testing.T) {
 		return fmt.Errorf("test error")
 	}
 
+	observer, eventTypes := recordEventTypes()
 	db, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	err := ApplyMigration(db, FileMigration{}, "sth", direction.Up)
+	err := ApplyMigration(db, FileMigration{}, "sth", direction.Up, Hooks{}, observer)
 	if err == nil {
 		t.Errorf("Expected error for applying up migrations, but got nothing")
 	}
+	expectedTypes := []EventType{MigrationStarted, VerifyFailed, MigrationFailed}
+	if diff := pretty.Compare(*eventTypes, expectedTypes); diff != "" {
+		t.Errorf("Got unexpected events: (-got +want)\n%s", diff)
+	}
 
 	if !migrateUpCalled {
 		t.Errorf("Expected migrateUp to be called once")
@@ -152,7 +162,7 @@ func TestApplyUpMigrationChangelogError(t *testing.T) {
 	}
 
 	db, _, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	err := ApplyMigration(db, FileMigration{}, "sth", direction.Up)
+	err := ApplyMigration(db, FileMigration{}, "sth", direction.Up, Hooks{}, NoObserver)
 	if err == nil {
 		t.Errorf("Expected error for applying up migrations, but got nothing")
 	}
@@ -210,9 +220,27 @@ func TestInsertToChangelog(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
 	migration := FileMigration{ID: "1", Description: "a"}
 
-	mock.ExpectExec(
-		`INSERT INTO sth (id, name, applied_at) VALUES ('1', 'a', now())`,
-	).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO sth (id, name, applied_at) VALUES ($1, $2, now())`).
+		WithArgs("1", "a").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = InsertToChangelog(db, migration, "sth")
+	if err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertToChangelogSpecialCharacters(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	migration := FileMigration{ID: "1", Description: "fix_user's_größe"}
+
+	mock.ExpectExec(`INSERT INTO sth (id, name, applied_at) VALUES ($1, $2, now())`).
+		WithArgs("1", "fix_user's_größe").
+		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	err = InsertToChangelog(db, migration, "sth")
 	if err != nil {
@@ -298,7 +326,7 @@ func TestFilterUpMigrationsByText(t *testing.T) {
 	for _, testCase := range testCases {
 		migration, err := FilterMigrationsByText(
 			testCase.filter, direction.Up,
-			testCase.fileMigrations, testCase.appliedMigrations,
+			testCase.fileMigrations, testCase.appliedMigrations, nil,
 		)
 		if err != nil {
 			t.Errorf("Expected no error, but got: %s", err)
@@ -348,7 +376,7 @@ func TestFilterUpMigrationsByTextError(t *testing.T) {
 	for _, testCase := range testCases {
 		_, err := FilterMigrationsByText(
 			testCase.filter, direction.Up,
-			testCase.fileMigrations, testCase.appliedMigrations,
+			testCase.fileMigrations, testCase.appliedMigrations, nil,
 		)
 		if err == nil {
 			t.Errorf("Expected error, but got none")
@@ -405,7 +433,7 @@ func TestFilterUpMigrationsByCount(t *testing.T) {
 		}
 
 		migrations, err := FilterMigrationsByCount(
-			testCase.count, testCase.all, direction.Up, fileMigrations, appliedMigrations,
+			testCase.count, testCase.all, direction.Up, fileMigrations, appliedMigrations, nil,
 		)
 		if err != nil {
 			t.Errorf("Expected no error, but got: %s", err)
@@ -419,9 +447,98 @@ func TestFilterUpMigrationsByCount(t *testing.T) {
 
 func TestFilterUpMigrationsByCountNothingLeft(t *testing.T) {
 	_, err := FilterMigrationsByCount(
-		3, false, direction.Up, []FileMigration{{ID: "1"}}, []AppliedMigration{{ID: "1"}},
+		3, false, direction.Up, []FileMigration{{ID: "1"}}, []AppliedMigration{{ID: "1"}}, nil,
 	)
 	if err == nil {
 		t.Errorf("Expected error, but got  nothing")
 	}
 }
+
+func TestFilterMigrationsByCountWithApplications(t *testing.T) {
+	fileMigrations := []FileMigration{
+		{ID: "1", Application: "common"},
+		{ID: "2", Application: "sub_app"},
+		{ID: "3", Application: "common"},
+		{ID: "4", Application: "sub_app"},
+	}
+	appliedMigrations := []AppliedMigration{{ID: "1"}, {ID: "2"}}
+
+	migrations, err := FilterMigrationsByCount(
+		0, true, direction.Up, fileMigrations, appliedMigrations, []string{"sub_app"},
+	)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+	if diff := pretty.Compare([]FileMigration{fileMigrations[3]}, migrations); diff != "" {
+		t.Errorf("Did not filter the up migrations by application:\n%s", diff)
+	}
+
+	migrations, err = FilterMigrationsByCount(
+		1, false, direction.Down, fileMigrations, appliedMigrations, []string{"common"},
+	)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+	if diff := pretty.Compare([]FileMigration{fileMigrations[0]}, migrations); diff != "" {
+		t.Errorf("Did not filter the down migrations by application:\n%s", diff)
+	}
+}
+
+func TestFilterMigrationsUpToID(t *testing.T) {
+	fileMigrations := []FileMigration{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	migrations, err := FilterMigrationsUpToID("2", fileMigrations)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+	if diff := pretty.Compare(fileMigrations[:2], migrations); diff != "" {
+		t.Errorf("Did not return the migrations up to the ID:\n%s", diff)
+	}
+
+	if _, err := FilterMigrationsUpToID("4", fileMigrations); err == nil {
+		t.Errorf("Expected an error for an unknown ID, but got nothing")
+	}
+}
+
+func TestInsertBaselineToChangelog(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	migrations := []FileMigration{{ID: "1", Description: "a"}, {ID: "2", Description: "b"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(
+		`INSERT INTO sth (id, name, applied_at, baseline) VALUES ($1, $2, now(), true)`,
+	).WithArgs("1", "a").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(
+		`INSERT INTO sth (id, name, applied_at, baseline) VALUES ($1, $2, now(), true)`,
+	).WithArgs("2", "b").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	err = InsertBaselineToChangelog(db, migrations, "sth")
+	if err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestInsertBaselineToChangelogError(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	migrations := []FileMigration{{ID: "1", Description: "a"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(
+		`INSERT INTO sth (id, name, applied_at, baseline) VALUES ($1, $2, now(), true)`,
+	).WithArgs("1", "a").WillReturnError(fmt.Errorf("Some error"))
+	mock.ExpectRollback()
+
+	err = InsertBaselineToChangelog(db, migrations, "sth")
+	if err == nil {
+		t.Errorf("Expected error, but got nothing")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -4,7 +4,9 @@ import (
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
+	"time"
 
 	"gopkg.in/yaml.v2"
 )
@@ -17,15 +19,48 @@ type fileConfig struct {
 	DbName   string `yaml:"db_name"`
 	User     string `yaml:"user"`
 	Password string `yaml:"password"`
+
+	AllowOutOfOrder bool                  `yaml:"allow_out_of_order,omitempty"`
+	Vars            map[string]string     `yaml:"vars,omitempty"`
+	Image           string                `yaml:"image,omitempty"`
+	Owner           string                `yaml:"owner,omitempty"`
+	Template        string                `yaml:"template,omitempty"`
+	Protected       bool                  `yaml:"protected,omitempty"`
+	Hooks           map[string][]fileHook `yaml:"hooks,omitempty"`
+}
+
+// fileHook is a hook of the config YAML with its timeout as duration string (e.g. 5m)
+type fileHook struct {
+	SQL     string `yaml:"sql,omitempty"`
+	Command string `yaml:"command,omitempty"`
+	Timeout string `yaml:"timeout,omitempty"`
+}
+
+// Hook is SQL or a shell command run at a point of the migrations (e.g. after_up).
+// Without a timeout the default timeout of the hooks is used
+type Hook struct {
+	SQL     string
+	Command string
+	Timeout time.Duration
 }
 
 // Config stores configuration for database environment like host, port
 // and also migration parameters like the migration path
 type Config struct {
-	MigrationsPath string
-	Environment    string
-	ChangelogName  string
-	Db             struct {
+	MigrationsPath  string
+	Environment     string
+	ChangelogName   string
+	AllowOutOfOrder bool
+	Vars            map[string]string
+	Image           string
+	// Owner and Template are used to create the database (db create)
+	Owner    string
+	Template string
+	// Protected environments refuse destructive commands like db drop
+	Protected bool
+	// Hooks of the environment by their point (e.g. after_up)
+	Hooks map[string][]Hook
+	Db    struct {
 		Type     string
 		Host     string
 		Port     uint16
@@ -54,6 +89,52 @@ func LoadConfig(configPath, migrationsPath, environment string) (Config, error)
 	return databaseConfig, nil
 }
 
+// DSN returns the connection URL of the database
+func (config Config) DSN() string {
+	dsn := url.URL{
+		Scheme: "postgresql",
+		User:   url.UserPassword(config.Db.User, config.Db.Password),
+		Host:   fmt.Sprintf("%s:%d", config.Db.Host, config.Db.Port),
+		Path:   config.Db.Name,
+	}
+	return dsn.String()
+}
+
+// Marshal returns the configuration as YAML of an environment configuration file
+func Marshal(config Config) ([]byte, error) {
+	fConfig := fileConfig{
+		DbType:          config.Db.Type,
+		Host:            config.Db.Host,
+		Port:            config.Db.Port,
+		DbName:          config.Db.Name,
+		User:            config.Db.User,
+		Password:        config.Db.Password,
+		AllowOutOfOrder: config.AllowOutOfOrder,
+		Vars:            config.Vars,
+		Image:           config.Image,
+		Owner:           config.Owner,
+		Template:        config.Template,
+		Protected:       config.Protected,
+	}
+	for point, hooks := range config.Hooks {
+		if fConfig.Hooks == nil {
+			fConfig.Hooks = map[string][]fileHook{}
+		}
+		for _, hook := range hooks {
+			fHook := fileHook{SQL: hook.SQL, Command: hook.Command}
+			if hook.Timeout > 0 {
+				fHook.Timeout = hook.Timeout.String()
+			}
+			fConfig.Hooks[point] = append(fConfig.Hooks[point], fHook)
+		}
+	}
+	content, err := yaml.Marshal(fConfig)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't marshal yaml: %v", err)
+	}
+	return content, nil
+}
+
 func unmarshalConfig(path string) (Config, error) {
 	databaseConfig := Config{}
 	fConfig := fileConfig{}
@@ -79,6 +160,30 @@ func unmarshalConfig(path string) (Config, error) {
 	databaseConfig.Db.Name = fConfig.DbName
 	databaseConfig.Db.User = fConfig.User
 	databaseConfig.Db.Password = fConfig.Password
+	databaseConfig.AllowOutOfOrder = fConfig.AllowOutOfOrder
+	databaseConfig.Vars = fConfig.Vars
+	databaseConfig.Image = fConfig.Image
+	databaseConfig.Owner = fConfig.Owner
+	databaseConfig.Template = fConfig.Template
+	databaseConfig.Protected = fConfig.Protected
+
+	for point, fHooks := range fConfig.Hooks {
+		if databaseConfig.Hooks == nil {
+			databaseConfig.Hooks = map[string][]Hook{}
+		}
+		for _, fHook := range fHooks {
+			hook := Hook{SQL: fHook.SQL, Command: fHook.Command}
+			if fHook.Timeout != "" {
+				hook.Timeout, err = time.ParseDuration(fHook.Timeout)
+				if err != nil {
+					return databaseConfig, fmt.Errorf(
+						"Couldn't parse the timeout of a %s hook: %v", point, err,
+					)
+				}
+			}
+			databaseConfig.Hooks[point] = append(databaseConfig.Hooks[point], hook)
+		}
+	}
 
 	return databaseConfig, nil
 }
@@ -96,5 +201,12 @@ func validateConfig(config Config) error {
 	if config.Db.User == "" {
 		return errors.New("No username specified")
 	}
+	for point, hooks := range config.Hooks {
+		for _, hook := range hooks {
+			if (hook.SQL == "") == (hook.Command == "") {
+				return fmt.Errorf("A %s hook needs either sql or a command", point)
+			}
+		}
+	}
 	return nil
 }
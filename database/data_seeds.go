@@ -0,0 +1,162 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/lithammer/dedent"
+)
+
+// SeedsFolder is the folder within the migrations path containing the data seeds in a subfolder
+// per environment (_seeds/<environment>/*.sql)
+const SeedsFolder = "_seeds"
+
+// CreateSeedsChangelogSQL creates the changelog of the data seeds if necessary
+var CreateSeedsChangelogSQL = dedent.Dedent(`
+	CREATE TABLE IF NOT EXISTS %s (
+		  name TEXT NOT NULL PRIMARY KEY
+		, applied_at timestamptz NOT NULL
+	);
+`)
+
+// SeedsChangelogInsertSQL records an applied data seed (bound parameter: name)
+var SeedsChangelogInsertSQL = "INSERT INTO %s (name, applied_at) VALUES ($1, now())"
+
+// DataSeed is a file with (reference or test) data for one environment.
+// It is loaded once after the migrations and has no down migration
+type DataSeed struct {
+	SQL      string
+	Filename string
+}
+
+// LoadFromFile loads the data seed and renders it, if it is marked as template
+func (seed *DataSeed) LoadFromFile(seedPath string, vars map[string]string) error {
+	seed.Filename = filepath.Base(seedPath)
+
+	errs := FileErrors{}
+	content, err := ioutil.ReadFile(seedPath)
+	if err != nil {
+		errs.add(seedPath, KindUnreadable, 0, "Couldn't read seed file: %v", err)
+		return errs
+	}
+	rendered, err := RenderTemplate(seed.Filename, string(content), vars)
+	if err != nil {
+		errs.add(seedPath, KindTemplate, 0, "%v", err)
+		return errs
+	}
+	seed.SQL = strings.Trim(strings.Trim(rendered, "\n"), " ")
+	if seed.SQL == "" {
+		errs.add(seedPath, KindEmptyUp, 1, "The seed was empty")
+		return errs
+	}
+	return nil
+}
+
+// GetDataSeeds gets the data seeds of the environment sorted by filename, which is the order they
+// are applied in. Without a seeds folder for the environment there are no seeds
+func GetDataSeeds(migrationsPath, environment string, vars map[string]string) (
+	seeds []DataSeed, err error,
+) {
+	seedsPath := filepath.Join(migrationsPath, SeedsFolder, environment)
+	seedFiles, err := ioutil.ReadDir(seedsPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("Could not read content of seeds folder %s - Err: %v", seedsPath, err)
+	}
+
+	errs := FileErrors{}
+	for _, seedFile := range seedFiles {
+		if seedFile.IsDir() || filepath.Ext(seedFile.Name()) != ".sql" {
+			continue
+		}
+
+		seed := DataSeed{}
+		if err := seed.LoadFromFile(filepath.Join(seedsPath, seedFile.Name()), vars); err != nil {
+			errs = append(errs, err.(FileErrors)...)
+			continue
+		}
+		// ReadDir returns the files sorted by filename
+		seeds = append(seeds, seed)
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return seeds, nil
+}
+
+// EnsureSeedsChangelog creates the changelog of the data seeds if necessary
+func EnsureSeedsChangelog(db *sql.DB, changelogTable string) error {
+	_, err := db.Exec(fmt.Sprintf(CreateSeedsChangelogSQL, changelogTable))
+	if err != nil {
+		return fmt.Errorf("Error creating seeds changelog: %v", err)
+	}
+	return nil
+}
+
+// GetAppliedSeeds returns the names of the applied data seeds
+func GetAppliedSeeds(db *sql.DB, changelogTable string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf(`SELECT name FROM %s`, changelogTable))
+	if err != nil {
+		return nil, fmt.Errorf("Got error getting applied seeds: %v", err)
+	}
+	defer rows.Close()
+
+	applied := map[string]bool{}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("Error scanning row for applied seeds: %v", err)
+		}
+		applied[name] = true
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Error after row iteration for getting applied seeds: %v", err)
+	}
+	return applied, nil
+}
+
+// FilterPendingSeeds returns the data seeds, which were not applied yet
+func FilterPendingSeeds(seeds []DataSeed, applied map[string]bool) []DataSeed {
+	pending := []DataSeed{}
+	for _, seed := range seeds {
+		if !applied[seed.Filename] {
+			pending = append(pending, seed)
+		}
+	}
+	return pending
+}
+
+// ApplyDataSeed applies the data seed and records it in the changelog within a single
+// transaction
+func ApplyDataSeed(db *sql.DB, seed DataSeed, changelogTable string) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error opening transaction: %v", err)
+	}
+
+	statements := []statement{
+		{query: seed.SQL}, newStatement(SeedsChangelogInsertSQL, changelogTable, seed.Filename),
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(statement.query, statement.args...); err != nil {
+			rollbackError := tx.Rollback()
+			if rollbackError != nil {
+				return fmt.Errorf(
+					"Could not apply the seed %s: %v \n and rollback error: %v",
+					seed.Filename, err, rollbackError,
+				)
+			}
+			return fmt.Errorf("Could not apply the seed %s: %v", seed.Filename, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("Error during commit of %s: %v", seed.Filename, err)
+	}
+	return nil
+}
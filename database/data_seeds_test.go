@@ -0,0 +1,100 @@
+package database
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestGetDataSeeds(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	saveMigrationFor(basePath, "common", "20171101000001_foo.sql")
+	seedsPath := filepath.Join(basePath, SeedsFolder, "development")
+	os.MkdirAll(seedsPath, 0777)
+	os.MkdirAll(filepath.Join(basePath, SeedsFolder, "staging"), 0777)
+	ioutil.WriteFile(
+		filepath.Join(seedsPath, "02_orders.sql"), []byte("INSERT INTO orders VALUES (1);\n"), 0777,
+	)
+	ioutil.WriteFile(
+		filepath.Join(seedsPath, "01_users.sql"),
+		[]byte("-- //@TEMPLATE\nINSERT INTO users VALUES ('{{ .Vars.admin }}');\n"), 0777,
+	)
+	ioutil.WriteFile(filepath.Join(seedsPath, "README.md"), []byte("no seed"), 0777)
+
+	seeds, err := GetDataSeeds(basePath, "development", map[string]string{"admin": "root"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expected := []DataSeed{
+		{SQL: "-- //@TEMPLATE\nINSERT INTO users VALUES ('root');", Filename: "01_users.sql"},
+		{SQL: "INSERT INTO orders VALUES (1);", Filename: "02_orders.sql"},
+	}
+	if diff := pretty.Compare(expected, seeds); diff != "" {
+		t.Errorf("Did not load the seeds:\n%s", diff)
+	}
+
+	if seeds, err := GetDataSeeds(basePath, "production", nil); err != nil || len(seeds) > 0 {
+		t.Errorf("Expected no seeds without a folder, but got %v (%v)", seeds, err)
+	}
+
+	// seeds are no migrations
+	fileMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil || len(fileMigrations) != 1 {
+		t.Errorf("Expected only the versioned migration, but got %v (%v)", fileMigrations, err)
+	}
+}
+
+func TestFilterPendingSeeds(t *testing.T) {
+	seeds := []DataSeed{{Filename: "01_a.sql"}, {Filename: "02_b.sql"}, {Filename: "03_c.sql"}}
+
+	pending := FilterPendingSeeds(seeds, map[string]bool{"02_b.sql": true})
+	expected := []DataSeed{{Filename: "01_a.sql"}, {Filename: "03_c.sql"}}
+	if diff := pretty.Compare(expected, pending); diff != "" {
+		t.Errorf("Did not filter the applied seeds:\n%s", diff)
+	}
+}
+
+func TestApplyDataSeed(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	seed := DataSeed{SQL: "INSERT INTO users VALUES (1)", Filename: "01_users.sql"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users VALUES (1)").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec("INSERT INTO sth (name, applied_at) VALUES ($1, now())").
+		WithArgs("01_users.sql").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := ApplyDataSeed(db, seed, "sth"); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestApplyDataSeedError(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	seed := DataSeed{SQL: "INSERT INTO users VALUES (1)", Filename: "01_users.sql"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("INSERT INTO users VALUES (1)").WillReturnError(fmt.Errorf("Some error"))
+	mock.ExpectRollback()
+
+	if err := ApplyDataSeed(db, seed, "sth"); err == nil {
+		t.Errorf("Expected an error, but got nothing")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -1,44 +1,143 @@
 package database
 
 import (
-	"os"
+	"io"
 	"time"
 
-	"github.com/jedib0t/go-pretty/v6/progress"
-
 	"go-migrations/database/config"
 	"go-migrations/internal/direction"
 )
 
+// SeedOptions changes the SQL written by GenerateSeedSQL
+type SeedOptions struct {
+	// Transaction wraps the whole seed in a single transaction
+	Transaction bool
+	// Idempotent creates the changelog only if it does not exist and applies every migration
+	// only if it is not in the changelog yet, so the seed can be applied repeatedly
+	Idempotent bool
+	// ToID ends the seed with the migration of this ID (instead of the latest migration)
+	ToID string
+}
+
 // Database is an abstraction over the underlying database and configuration models
+// The methods applying migrations notify the observer about their progress
 type Database interface {
 	// WaitForStart tries to connect to the database within a timeout
 	WaitForStart(pollInterval time.Duration, retryCount int) error
 	// Bootstrap applies the bootstrap migration
-	Bootstrap() error
+	Bootstrap(observer Observer) error
 	// ApplyAllUpMigrations applies all up migrations
-	ApplyAllUpMigrations(pw progress.Writer) error
+	ApplyAllUpMigrations(observer Observer) error
 
-	// GenerateSeedSQL writes all migration into a single file as an SQL seed
-	GenerateSeedSQL(f *os.File) error
+	// GenerateSeedSQL writes all migration into a single SQL seed
+	GenerateSeedSQL(w io.Writer, options SeedOptions) error
 
+	// SetAllowOutOfOrder allows to apply skipped (older) migrations after newer ones
+	// Down migrations then follow the order of the changelog instead of the ID
+	SetAllowOutOfOrder(allow bool)
+	// AllowsOutOfOrder returns whether migrations may be applied out of order
+	// (either by configuration or SetAllowOutOfOrder)
+	AllowsOutOfOrder() bool
+	// IsProtected returns whether the environment is configured as protected
+	IsProtected() bool
+	// SetApplications restricts all following operations to migrations of the given
+	// applications (application folders). Without any applications all migrations are used
+	SetApplications(applications []string)
 	// GetFileMigrations returns the available migrations found locally (sorted by ID)
 	GetFileMigrations() ([]FileMigration, error)
 	// GetAppliedMigrations gets all applied migrations from the changelog (sorted by ID)
 	GetAppliedMigrations() ([]AppliedMigration, error)
 
+	// FindRepeatableMigrations returns the new or changed repeatable migrations, which
+	// ApplyRepeatableMigrations would apply once no versioned migration is pending
+	FindRepeatableMigrations() ([]RepeatableMigration, error)
+	// ApplyRepeatableMigrations applies the new or changed repeatable migrations and returns
+	// their number. It returns ErrPendingMigrations while versioned migrations are pending
+	ApplyRepeatableMigrations(observer Observer) (applied int, err error)
+
+	// ApplyDataSeeds applies the data seeds of the environment, which were not applied yet, and
+	// returns their number. It returns ErrPendingMigrations while versioned migrations are pending
+	ApplyDataSeeds(observer Observer) (applied int, err error)
+
 	// ApplySpecificMigration applies one migration based on a string search of the filename
-	ApplySpecificMigration(filter string, direction direction.MigrateDirection) error
+	ApplySpecificMigration(
+		filter string, direction direction.MigrateDirection, observer Observer,
+	) error
 	// ApplyUpMigrationsWithCount applies a number of up migration starting from the last
 	// by providing the "all" flag all remaining up migrations are applied
-	ApplyMigrationsWithCount(count uint, all bool, direction direction.MigrateDirection) error
+	ApplyMigrationsWithCount(
+		count uint, all bool, direction direction.MigrateDirection, observer Observer,
+	) error
+
+	// FindMigrationsWithCount returns the migrations ApplyMigrationsWithCount would apply
+	FindMigrationsWithCount(
+		count uint, all bool, direction direction.MigrateDirection,
+	) ([]FileMigration, error)
+	// FindMigration returns the one migration matching the filter, which could be migrated in
+	// the direction (pending for up, applied for down)
+	FindMigration(filter string, direction direction.MigrateDirection) (FileMigration, error)
+	// MarkMigration adds (up) or removes (down) a migration to / from the changelog
+	// without executing it and records the action in an audit trail
+	MarkMigration(migration FileMigration, direction direction.MigrateDirection) error
+
+	// Baseline marks all migrations up to the given ID as applied without executing them
+	// This is only possible for an empty changelog
+	Baseline(toID string) error
 
+	// Squash merges the migrations of the application up to (and including) the given ID into
+	// a single migration and archives the originals. The changelog is not changed
+	Squash(beforeID, application string) (FileMigration, error)
+	// FindChangelogSquashes returns the squash migrations, whose squashed migrations were all
+	// applied and are still in the changelog instead of the squash migration
+	FindChangelogSquashes() ([]FileMigration, error)
+	// SquashChangelog replaces the changelog entries of squashed migrations by their squash
+	// migration (see FindChangelogSquashes)
+	SquashChangelog() error
+
+	// PlanMigrationsChangelog returns the changes EnsureMigrationsChangelog would make (without
+	// changing the database)
+	PlanMigrationsChangelog() (plan []string, err error)
 	// EnsureMigrationsChangelog checks if a changelog table already exists and creates it if
-	// necessary
+	// necessary. Changelogs of older versions are upgraded
 	EnsureMigrationsChangelog() (created bool, err error)
 	// EnsureConsistentMigrations checks if all applied migrations exist as local files
-	// and if no local migration has been "skipped" (newer migrations applied)
+	// and if no local migration has been "skipped" (newer migrations applied).
+	// The order is checked for each application on its own
 	EnsureConsistentMigrations() error
 	// Init initializes the database with the given configuration
+	// and opens the connection, which is kept for the lifetime of the database
+	Init(config.Config) error
+	// Close closes the connection opened by Init
+	Close() error
+}
+
+// Admin manages the database of an environment itself over a maintenance connection to the server
+type Admin interface {
+	// WaitForStart tries to connect to the server within a timeout
+	WaitForStart(pollInterval time.Duration, retryCount int) error
+	// DatabaseExists checks whether the database of the environment exists
+	DatabaseExists() (bool, error)
+	// CreateDatabase creates the (empty) database of the environment with the owner and template
+	// of the configuration
+	CreateDatabase() error
+	// DropDatabase terminates all sessions of the database of the environment and drops it
+	// (if it exists)
+	DropDatabase() error
+
+	// SaveSnapshot copies the database of the environment into the snapshot of the given name and
+	// records the ID of its last applied migration. An existing snapshot of the name is only
+	// replaced once the copy is complete
+	SaveSnapshot(name, migrationID string) error
+	// RestoreSnapshot replaces the database of the environment by a copy of the snapshot. The
+	// database is only dropped once the copy is complete
+	RestoreSnapshot(name string) (Snapshot, error)
+	// ListSnapshots returns the snapshots of the database of the environment (sorted by name)
+	ListSnapshots() ([]Snapshot, error)
+	// DeleteSnapshot drops the snapshot
+	DeleteSnapshot(name string) error
+
+	// Init initializes the maintenance connection for the given configuration
 	Init(config.Config) error
+	// Close closes the connection opened by Init
+	Close() error
 }
@@ -0,0 +1,206 @@
+package database
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var migrationIDRegex = regexp.MustCompile(`^\d{14}$`)
+
+// ResolveRequirements replaces the references of the "-- //@REQUIRES" headers with the IDs of
+// the referenced migrations. A reference is either an ID or "<app>/<name>", where the name
+// can be given with or without the ID and the ".sql" suffix.
+// IDs of squashed migrations are resolved to the ID of their squash migration.
+// Missing migrations, requirements on newer migrations (e.g. after a rebase) and cyclic
+// dependencies are returned as an error
+func ResolveRequirements(migrations []FileMigration) error {
+	idLookup := map[string]string{}
+	for _, mig := range migrations {
+		for _, squashedID := range mig.Squashes {
+			idLookup[squashedID] = mig.ID
+		}
+	}
+	for _, mig := range migrations {
+		idLookup[mig.ID] = mig.ID
+	}
+
+	for idx, mig := range migrations {
+		if len(mig.Requires) == 0 {
+			continue
+		}
+
+		resolved := []string{}
+		for _, reference := range mig.Requires {
+			id, err := resolveReference(reference, migrations, idLookup)
+			if err != nil {
+				return fmt.Errorf(
+					"Invalid requirement of %s/%s: %v", mig.Application, mig.Filename, err,
+				)
+			}
+			if id == mig.ID {
+				return fmt.Errorf(
+					"The migration %s/%s requires itself", mig.Application, mig.Filename,
+				)
+			}
+			// the squash migration of a squashed ID may be newer than the requiring migration
+			referencedID := id
+			if migrationIDRegex.MatchString(reference) {
+				referencedID = reference
+			}
+			if referencedID > mig.ID {
+				return fmt.Errorf(
+					"The migration %s/%s requires the newer migration %s, "+
+						"rename it to a newer ID or reorder the migrations",
+					mig.Application, mig.Filename, referencedID,
+				)
+			}
+			resolved = append(resolved, id)
+		}
+		migrations[idx].Requires = resolved
+	}
+
+	return ensureNoCycles(migrations)
+}
+
+func resolveReference(
+	reference string, migrations []FileMigration, idLookup map[string]string,
+) (string, error) {
+	if migrationIDRegex.MatchString(reference) {
+		id, exists := idLookup[reference]
+		if !exists {
+			return "", fmt.Errorf("Found no migration with the id %s", reference)
+		}
+		return id, nil
+	}
+
+	parts := strings.SplitN(reference, "/", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", fmt.Errorf(
+			"The reference '%s' is neither an id nor of the form <app>/<name>", reference,
+		)
+	}
+	app, name := parts[0], strings.TrimSuffix(parts[1], ".sql")
+
+	for _, mig := range migrations {
+		if mig.Application != app {
+			continue
+		}
+		if mig.Description == name || strings.TrimSuffix(mig.Filename, ".sql") == name {
+			return mig.ID, nil
+		}
+	}
+	return "", fmt.Errorf("Found no migration matching %s", reference)
+}
+
+func ensureNoCycles(migrations []FileMigration) error {
+	const (
+		inProgress = iota + 1
+		done
+	)
+
+	requiresLookup := map[string][]string{}
+	for _, mig := range migrations {
+		requiresLookup[mig.ID] = mig.Requires
+	}
+
+	state := map[string]int{}
+	var path []string
+
+	var visit func(id string) error
+	visit = func(id string) error {
+		switch state[id] {
+		case done:
+			return nil
+		case inProgress:
+			cycleStart := 0
+			for idx, pathID := range path {
+				if pathID == id {
+					cycleStart = idx
+					break
+				}
+			}
+			cycle := append(append([]string{}, path[cycleStart:]...), id)
+			return fmt.Errorf("Found cyclic requirements: %s", strings.Join(cycle, " -> "))
+		}
+
+		state[id] = inProgress
+		path = append(path, id)
+		for _, requiredID := range requiresLookup[id] {
+			if err := visit(requiredID); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[id] = done
+		return nil
+	}
+
+	for _, mig := range migrations {
+		if err := visit(mig.ID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EnsureRequirementsIncluded checks that every requirement of the migrations is part of the
+// migrations and comes before it, e.g. for a seed of selected applications
+func EnsureRequirementsIncluded(migrations []FileMigration) error {
+	return ensureUpRequirements(migrations, nil)
+}
+
+// ensureUpRequirements checks that every requirement of the migrations to apply is either
+// already applied or applied before within the same run
+func ensureUpRequirements(migrations []FileMigration, appliedMigrations []AppliedMigration) error {
+	available := map[string]bool{}
+	for _, mig := range appliedMigrations {
+		available[mig.ID] = true
+	}
+
+	for _, mig := range migrations {
+		for _, requiredID := range mig.Requires {
+			if !available[requiredID] {
+				return fmt.Errorf(
+					"The migration %s/%s requires %s, which is not applied before it",
+					mig.Application, mig.Filename, requiredID,
+				)
+			}
+		}
+		available[mig.ID] = true
+	}
+	return nil
+}
+
+// ensureDownRequirements checks that no migration is removed while an applied migration
+// still requires it. Migrations removed earlier within the same run do not count
+func ensureDownRequirements(
+	migrations []FileMigration, fileMigrations []FileMigration, appliedMigrations []AppliedMigration,
+) error {
+	fileLookup := map[string]FileMigration{}
+	for _, mig := range fileMigrations {
+		fileLookup[mig.ID] = mig
+	}
+	stillApplied := map[string]bool{}
+	for _, mig := range appliedMigrations {
+		stillApplied[mig.ID] = true
+	}
+
+	for _, mig := range migrations {
+		delete(stillApplied, mig.ID)
+		for _, applied := range appliedMigrations {
+			if !stillApplied[applied.ID] {
+				continue
+			}
+			for _, requiredID := range fileLookup[applied.ID].Requires {
+				if requiredID == mig.ID {
+					return fmt.Errorf(
+						"The migration %s/%s is still required by the applied migration %s",
+						mig.Application, mig.Filename, applied.ID,
+					)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package database
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"go-migrations/internal/direction"
+)
+
+func TestResolveRequirements(t *testing.T) {
+	migrations := []FileMigration{
+		{ID: "20171101000001", Application: "common", Filename: "20171101000001_base.sql",
+			Description: "base"},
+		{ID: "20171101000002", Application: "common", Filename: "20171101000002_users.sql",
+			Description: "users"},
+		{ID: "20171101000003", Application: "sub_app", Filename: "20171101000003_sub.sql",
+			Description: "sub",
+			Requires: []string{
+				"20171101000001", "common/users", "common/20171101000002_users.sql",
+			},
+		},
+	}
+
+	if err := ResolveRequirements(migrations); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	expected := []string{"20171101000001", "20171101000002", "20171101000002"}
+	if diff := pretty.Compare(expected, migrations[2].Requires); diff != "" {
+		t.Errorf("Did not resolve the requirements correctly:\n%s", diff)
+	}
+}
+
+func TestResolveRequirementsErrors(t *testing.T) {
+	testCases := []struct {
+		name          string
+		migrations    []FileMigration
+		expectedError string
+	}{
+		{
+			name: "missing id",
+			migrations: []FileMigration{
+				{ID: "20171101000001", Requires: []string{"20171101000009"}},
+			},
+			expectedError: "Found no migration with the id 20171101000009",
+		},
+		{
+			name: "missing name",
+			migrations: []FileMigration{
+				{ID: "20171101000001", Requires: []string{"common/nope"}},
+			},
+			expectedError: "Found no migration matching common/nope",
+		},
+		{
+			name: "invalid reference",
+			migrations: []FileMigration{
+				{ID: "20171101000001", Requires: []string{"nope"}},
+			},
+			expectedError: "neither an id nor of the form <app>/<name>",
+		},
+		{
+			name: "self reference",
+			migrations: []FileMigration{
+				{ID: "20171101000001", Requires: []string{"20171101000001"}},
+			},
+			expectedError: "requires itself",
+		},
+		{
+			name: "newer migration",
+			migrations: []FileMigration{
+				{
+					ID: "20171101000001", Application: "common", Filename: "20171101000001_a.sql",
+					Requires: []string{"20171101000002"},
+				},
+				{ID: "20171101000002"},
+			},
+			expectedError: "The migration common/20171101000001_a.sql requires the newer " +
+				"migration 20171101000002, rename it to a newer ID or reorder the migrations",
+		},
+		{
+			name: "cycle",
+			migrations: []FileMigration{
+				{ID: "20171101000002", Requires: []string{"20171101000001"}},
+				{
+					ID: "20171101000003", Requires: []string{"20171101000002"},
+					Squashes: []string{"20171101000001"},
+				},
+			},
+			expectedError: "Found cyclic requirements: " +
+				"20171101000002 -> 20171101000003 -> 20171101000002",
+		},
+	}
+
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			err := ResolveRequirements(testCase.migrations)
+			if err == nil || !strings.Contains(err.Error(), testCase.expectedError) {
+				t.Errorf("Expected error containing '%s', but got: %v", testCase.expectedError, err)
+			}
+		})
+	}
+}
+
+func TestFilterMigrationsWithRequirements(t *testing.T) {
+	fileMigrations := []FileMigration{
+		{ID: "1", Filename: "1_a.sql"},
+		{ID: "2", Filename: "2_b.sql", Requires: []string{"3"}},
+		{ID: "3", Filename: "3_c.sql"},
+	}
+
+	_, err := FilterMigrationsByCount(1, false, direction.Up, fileMigrations, []AppliedMigration{
+		{ID: "1"},
+	}, nil)
+	if err == nil {
+		t.Errorf("Expected an error for an up migration with a missing requirement")
+	}
+
+	_, err = FilterMigrationsByText("2_b", direction.Up, fileMigrations, []AppliedMigration{
+		{ID: "1"}, {ID: "3"},
+	}, nil)
+	if err != nil {
+		t.Errorf("Expected no error for an up migration with an applied requirement: %v", err)
+	}
+
+	_, err = FilterMigrationsByText("3_c", direction.Down, fileMigrations, []AppliedMigration{
+		{ID: "1"}, {ID: "2"}, {ID: "3"},
+	}, nil)
+	if err == nil {
+		t.Errorf("Expected an error for a down migration still required by an applied one")
+	}
+
+	migrations, err := FilterMigrationsByCount(
+		2, false, direction.Down, fileMigrations, []AppliedMigration{
+			{ID: "1"}, {ID: "2"}, {ID: "3"},
+		}, nil,
+	)
+	if err == nil {
+		t.Errorf("Expected an error removing 3 before 2, but got: %v", migrations)
+	}
+}
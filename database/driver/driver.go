@@ -6,6 +6,7 @@ import (
 	"go-migrations/database"
 	"go-migrations/database/config"
 	"go-migrations/database/driver/postgres"
+	"go-migrations/logging"
 )
 
 // variables to allow mocking for tests
@@ -13,20 +14,40 @@ var (
 	loadConfig = config.LoadConfig
 )
 
+// LoadConfig loads the configuration of the environment
+func LoadConfig(migrationsPath, environment string) (config.Config, error) {
+	logging.SetField("environment", environment)
+	configPath := fmt.Sprintf("%s/_environments/%s.yaml", migrationsPath, environment)
+	return loadConfig(configPath, migrationsPath, environment)
+}
+
 // LoadDB loads a configuration and initializes a database on top of it
 func LoadDB(migrationsPath, environment string) (database.Database, error) {
-	configPath := fmt.Sprintf("%s/_environments/%s.yaml", migrationsPath, environment)
-	config, err := loadConfig(configPath, migrationsPath, environment)
+	config, err := LoadConfig(migrationsPath, environment)
 	if err != nil {
 		return nil, err
 	}
+	return InitDB(config)
+}
 
+// InitDB initializes a database on top of a configuration
+func InitDB(config config.Config) (database.Database, error) {
 	db := &postgres.Postgres{}
-	if db.Init(config); err != nil {
+	if err := db.Init(config); err != nil {
+		return nil, err
+	}
+
+	return db, nil
+}
+
+// InitAdmin initializes the management of the database of a configuration
+func InitAdmin(config config.Config) (database.Admin, error) {
+	admin := &postgres.Admin{}
+	if err := admin.Init(config); err != nil {
 		return nil, err
 	}
 
-	return db, err
+	return admin, nil
 }
 
 // Pseudo Code: migrate_up
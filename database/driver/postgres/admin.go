@@ -0,0 +1,324 @@
+package postgres
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/lithammer/dedent"
+
+	"go-migrations/database"
+	"go-migrations/database/config"
+)
+
+// maintenanceDatabase is connected to for creating and dropping the database of an environment
+const maintenanceDatabase = "postgres"
+
+var databaseExistsSQL = "SELECT EXISTS (SELECT 1 FROM pg_database WHERE datname = $1)"
+var terminateSessionsSQL = "SELECT pg_terminate_backend(pid) FROM pg_stat_activity " +
+	"WHERE datname = $1 AND pid <> pg_backend_pid()"
+
+var listSnapshotsSQL = dedent.Dedent(`
+	SELECT datname, COALESCE(shobj_description(oid, 'pg_database'), '')
+	FROM pg_database
+	WHERE left(datname, length($1)) = $1
+	ORDER BY datname
+`)
+
+// snapshotComment is recorded as JSON in the comment of a snapshot database
+type snapshotComment struct {
+	MigrationID string    `json:"migration_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// Admin manages the database of an environment over a connection to the maintenance database
+type Admin struct {
+	config config.Config
+	db     *sql.DB
+}
+
+// WaitForStart tries to connect to the server within a timeout
+func (admin *Admin) WaitForStart(pollInterval time.Duration, retryCount int) error {
+	return mockableWaitForStart(admin.db, pollInterval, retryCount)
+}
+
+// DatabaseExists checks whether the database of the environment exists
+func (admin *Admin) DatabaseExists() (exists bool, err error) {
+	return admin.databaseExists(admin.config.Db.Name)
+}
+
+// CreateDatabase creates the database of the environment with the owner and template of the
+// configuration
+func (admin *Admin) CreateDatabase() error {
+	return admin.createDatabase(admin.config.Db.Name, admin.config.Template)
+}
+
+// DropDatabase terminates all sessions of the database of the environment and drops it
+func (admin *Admin) DropDatabase() error {
+	return admin.dropDatabase(admin.config.Db.Name)
+}
+
+// SaveSnapshot copies the database of the environment into a snapshot database (as template)
+// and records the ID of the last applied migration in the comment of the snapshot. The copy is
+// made under a temporary name and replaces an existing snapshot of the name only once it is
+// complete
+func (admin *Admin) SaveSnapshot(name, migrationID string) error {
+	snapshotDb, err := database.SnapshotDatabaseName(admin.config.Db.Name, name)
+	if err != nil {
+		return err
+	}
+	savingDb, err := database.TemporarySnapshotDatabaseName(admin.config.Db.Name, name, "saving")
+	if err != nil {
+		return err
+	}
+	replacedDb, err := database.TemporarySnapshotDatabaseName(
+		admin.config.Db.Name, name, "replaced",
+	)
+	if err != nil {
+		return err
+	}
+	if err := admin.dropDatabase(savingDb); err != nil {
+		return err
+	}
+
+	// a template cannot be copied while other sessions are connected to it
+	if err := admin.terminateSessions(admin.config.Db.Name); err != nil {
+		return err
+	}
+	query := fmt.Sprintf(
+		"CREATE DATABASE %s TEMPLATE %s",
+		database.QuoteIdentifier(savingDb), database.QuoteIdentifier(admin.config.Db.Name),
+	)
+	if _, err := admin.db.Exec(query); err != nil {
+		return fmt.Errorf("Couldn't create snapshot %s: %v", name, err)
+	}
+
+	comment, err := json.Marshal(snapshotComment{MigrationID: migrationID, CreatedAt: mockableNow()})
+	if err != nil {
+		return fmt.Errorf("Couldn't marshal snapshot comment: %v", err)
+	}
+	query = fmt.Sprintf(
+		"COMMENT ON DATABASE %s IS %s",
+		database.QuoteIdentifier(savingDb), database.QuoteLiteral(string(comment)),
+	)
+	if _, err := admin.db.Exec(query); err != nil {
+		return fmt.Errorf("Couldn't record the migration of snapshot %s: %v", name, err)
+	}
+
+	return admin.replaceDatabase(snapshotDb, savingDb, replacedDb)
+}
+
+// RestoreSnapshot replaces the database of the environment by a copy of the snapshot. The copy
+// is made under a temporary name, the database is only dropped once the copy is complete
+func (admin *Admin) RestoreSnapshot(name string) (database.Snapshot, error) {
+	snapshot, err := admin.findSnapshot(name)
+	if err != nil {
+		return snapshot, err
+	}
+	restoringDb, err := database.TemporarySnapshotDatabaseName(
+		admin.config.Db.Name, name, "restoring",
+	)
+	if err != nil {
+		return snapshot, err
+	}
+	previousDb, err := database.TemporarySnapshotDatabaseName(
+		admin.config.Db.Name, name, "previous",
+	)
+	if err != nil {
+		return snapshot, err
+	}
+	if err := admin.dropDatabase(restoringDb); err != nil {
+		return snapshot, err
+	}
+
+	if err := admin.terminateSessions(snapshot.Database); err != nil {
+		return snapshot, err
+	}
+	if err := admin.createDatabase(restoringDb, snapshot.Database); err != nil {
+		return snapshot, err
+	}
+	return snapshot, admin.replaceDatabase(admin.config.Db.Name, restoringDb, previousDb)
+}
+
+// ListSnapshots returns the snapshots of the database of the environment (sorted by name)
+func (admin *Admin) ListSnapshots() ([]database.Snapshot, error) {
+	prefix, _ := database.SnapshotDatabaseName(admin.config.Db.Name, "")
+	rows, err := admin.db.Query(listSnapshotsSQL, prefix)
+	if err != nil {
+		return nil, fmt.Errorf("Couldn't list snapshots: %v", err)
+	}
+	defer rows.Close()
+
+	snapshots := []database.Snapshot{}
+	for rows.Next() {
+		var snapshotDb, comment string
+		if err := rows.Scan(&snapshotDb, &comment); err != nil {
+			return nil, fmt.Errorf("Couldn't read snapshot: %v", err)
+		}
+
+		snapshot := database.Snapshot{
+			Name: strings.TrimPrefix(snapshotDb, prefix), Database: snapshotDb,
+		}
+		// copies of an interrupted save or restore are not snapshots
+		if database.IsTemporarySnapshot(snapshot.Name) {
+			continue
+		}
+		var parsedComment snapshotComment
+		if err := json.Unmarshal([]byte(comment), &parsedComment); err == nil {
+			snapshot.MigrationID = parsedComment.MigrationID
+			snapshot.CreatedAt = parsedComment.CreatedAt
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("Couldn't list snapshots: %v", err)
+	}
+	return snapshots, nil
+}
+
+// DeleteSnapshot drops the snapshot database
+func (admin *Admin) DeleteSnapshot(name string) error {
+	snapshot, err := admin.findSnapshot(name)
+	if err != nil {
+		return err
+	}
+	return admin.dropDatabase(snapshot.Database)
+}
+
+func (admin *Admin) findSnapshot(name string) (database.Snapshot, error) {
+	snapshots, err := admin.ListSnapshots()
+	if err != nil {
+		return database.Snapshot{}, err
+	}
+	for _, snapshot := range snapshots {
+		if snapshot.Name == name {
+			return snapshot, nil
+		}
+	}
+	return database.Snapshot{}, fmt.Errorf("Snapshot %s does not exist", name)
+}
+
+func (admin *Admin) createDatabase(name, template string) error {
+	query := "CREATE DATABASE " + database.QuoteIdentifier(name)
+	if admin.config.Owner != "" {
+		query += " OWNER " + database.QuoteIdentifier(admin.config.Owner)
+	}
+	if template != "" {
+		query += " TEMPLATE " + database.QuoteIdentifier(template)
+	}
+
+	if _, err := admin.db.Exec(query); err != nil {
+		return fmt.Errorf("Couldn't create database %s: %v", name, err)
+	}
+	return nil
+}
+
+func (admin *Admin) dropDatabase(name string) error {
+	if err := admin.terminateSessions(name); err != nil {
+		return err
+	}
+
+	query := "DROP DATABASE IF EXISTS " + database.QuoteIdentifier(name)
+	if _, err := admin.db.Exec(query); err != nil {
+		return fmt.Errorf("Couldn't drop database %s: %v", name, err)
+	}
+	return nil
+}
+
+func (admin *Admin) renameDatabase(name, newName string) error {
+	query := fmt.Sprintf(
+		"ALTER DATABASE %s RENAME TO %s",
+		database.QuoteIdentifier(name), database.QuoteIdentifier(newName),
+	)
+	if _, err := admin.db.Exec(query); err != nil {
+		return fmt.Errorf("Couldn't rename database %s to %s: %v", name, newName, err)
+	}
+	return nil
+}
+
+// replaceDatabase replaces the target database by the copy. An existing target is renamed aside
+// first and only dropped once the copy took its name, otherwise it is renamed back
+func (admin *Admin) replaceDatabase(target, copyDb, asideDb string) error {
+	if err := admin.dropDatabase(asideDb); err != nil {
+		return err
+	}
+	exists, err := admin.databaseExists(target)
+	if err != nil {
+		return err
+	}
+	if exists {
+		// a database cannot be renamed while other sessions are connected to it
+		if err := admin.terminateSessions(target); err != nil {
+			return err
+		}
+		if err := admin.renameDatabase(target, asideDb); err != nil {
+			return err
+		}
+	}
+
+	if err := admin.renameDatabase(copyDb, target); err != nil {
+		if !exists {
+			return err
+		}
+		if restoreErr := admin.renameDatabase(asideDb, target); restoreErr != nil {
+			return fmt.Errorf("%v \n and restore error: %v", err, restoreErr)
+		}
+		return err
+	}
+	if exists {
+		return admin.dropDatabase(asideDb)
+	}
+	return nil
+}
+
+func (admin *Admin) databaseExists(name string) (exists bool, err error) {
+	row := admin.db.QueryRow(databaseExistsSQL, name)
+	if err := row.Scan(&exists); err != nil {
+		return false, fmt.Errorf("Couldn't check if database %s exists: %v", name, err)
+	}
+	return exists, nil
+}
+
+func (admin *Admin) terminateSessions(name string) error {
+	if _, err := admin.db.Exec(terminateSessionsSQL, name); err != nil {
+		return fmt.Errorf("Couldn't terminate sessions of %s: %v", name, err)
+	}
+	return nil
+}
+
+// Init opens the connection to the maintenance database of the server of the configuration
+func (admin *Admin) Init(config config.Config) error {
+	admin.config = config
+
+	dsn, err := url.Parse(config.DSN())
+	if err != nil {
+		return fmt.Errorf("Error parsing connection URL: %v", err)
+	}
+	dsn.Path = maintenanceDatabase
+
+	db, err := mockableSQLOpen("pgx", dsn.String())
+	if err != nil {
+		return fmt.Errorf("Error opening database: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	admin.db = db
+
+	return nil
+}
+
+// Close closes the connection to the maintenance database
+func (admin *Admin) Close() error {
+	if admin.db == nil {
+		return nil
+	}
+
+	err := admin.db.Close()
+	admin.db = nil
+	if err != nil {
+		return fmt.Errorf("Error closing database: %v", err)
+	}
+	return nil
+}
@@ -3,12 +3,13 @@ package postgres
 import (
 	"database/sql"
 	"fmt"
-	"os"
+	"io"
+	"sort"
+	"strings"
 	"time"
 
 	// import to register driver
 	_ "github.com/jackc/pgx/stdlib"
-	"github.com/jedib0t/go-pretty/v6/progress"
 	"github.com/lithammer/dedent"
 
 	"go-migrations/database"
@@ -22,82 +23,254 @@ var (
 	mockableBootstrap                  = database.ApplyBootstrapMigration
 	mockableEnsureConsistentMigrations = database.EnsureConsistentMigrations
 	mockableGetFileMigrations          = database.GetFileMigrations
-	mockableGetAppliedMigrations       = database.GetAppliedMigrations
+	mockableGetAppliedMigrations       = getAppliedMigrations
+	mockableGetChangelogState          = getChangelogState
 	mockableApplyMigration             = database.ApplyMigration
 	mockableFilterMigrationsByText     = database.FilterMigrationsByText
 	mockableFilterMigrationsByCount    = database.FilterMigrationsByCount
 	mockableGetBootstrapSQL            = database.GetBootstrapSQL
+	mockableFilterMigrationsUpToID     = database.FilterMigrationsUpToID
+	mockableInsertBaselineToChangelog  = database.InsertBaselineToChangelog
+	mockableMarkMigration              = database.MarkMigration
+	mockableSquashMigrations           = database.SquashMigrations
+	mockableSquashChangelog            = database.SquashChangelog
+	mockableGetRepeatableMigrations    = database.GetRepeatableMigrations
+	mockableEnsureRepeatableChangelog  = database.EnsureRepeatableChangelog
+	mockableRepeatableChangelogExists  = database.RepeatableChangelogExists
+	mockableGetAppliedChecksums        = database.GetAppliedChecksums
+	mockableApplyRepeatableMigration   = database.ApplyRepeatableMigration
+	mockableGetDataSeeds               = database.GetDataSeeds
+	mockableEnsureSeedsChangelog       = database.EnsureSeedsChangelog
+	mockableGetAppliedSeeds            = database.GetAppliedSeeds
+	mockableApplyDataSeed              = database.ApplyDataSeed
+	mockableGetHooks                   = database.GetHooks
+	mockableNow                        = time.Now
 )
 
 var changelogTable = "public.migrations_changelog"
+var auditTable = "public.migrations_audit"
+var repeatableChangelogTable = "public.migrations_repeatable_changelog"
+var seedsChangelogTable = "public.migrations_seeds_changelog"
 var createChangelogSQL = dedent.Dedent(`
 	CREATE TABLE public.migrations_changelog (
 		  id VARCHAR(14) NOT NULL PRIMARY KEY
 		, name TEXT NOT NULL
 		, applied_at timestamptz NOT NULL
+		, baseline BOOLEAN NOT NULL DEFAULT false
 	);
 `)
 
-var tracker progress.Tracker
+// upgradeChangelogSQL adds columns to changelogs created by older versions
+var upgradeChangelogSQL = dedent.Dedent(`
+	ALTER TABLE public.migrations_changelog
+		ADD COLUMN IF NOT EXISTS baseline BOOLEAN NOT NULL DEFAULT false;
+`)
+
+// idempotentMigrationSQL applies a migration in a seed only if it is not in the changelog yet
+var idempotentMigrationSQL = dedent.Dedent(`
+	DO $seed$
+	BEGIN
+		IF NOT EXISTS (SELECT 1 FROM %s WHERE id = %s) THEN
+			EXECUTE %s%s%s;
+			%s;
+		END IF;
+	END
+	$seed$;
+`)
 
 // Postgres is a model to apply migrations against a PostgreSQL database
 type Postgres struct {
 	config            config.Config
 	connectionURL     string
+	db                *sql.DB
+	applications      []string
 	fileMigrations    []database.FileMigration
 	appliedMigrations []database.AppliedMigration
+	hooks             *database.Hooks
 }
 
 // WaitForStart tries to connect to the database within a timeout
 func (pg *Postgres) WaitForStart(pollInterval time.Duration, retryCount int) error {
-	db, err := mockableSQLOpen("pgx", pg.connectionURL)
-	if err != nil {
-		return fmt.Errorf("Error opening database: %v", err)
-	}
-	defer db.Close()
-
-	return mockableWaitForStart(db, pollInterval, retryCount)
+	return mockableWaitForStart(pg.db, pollInterval, retryCount)
 }
 
 // Bootstrap applies the bootstrap migration
-func (pg *Postgres) Bootstrap() error {
-	db, err := mockableSQLOpen("pgx", pg.connectionURL)
-	if err != nil {
-		return fmt.Errorf("Error opening database: %v", err)
+func (pg *Postgres) Bootstrap(observer database.Observer) error {
+	if err := mockableBootstrap(pg.db, pg.config.MigrationsPath, pg.config.Vars); err != nil {
+		return err
 	}
-	defer db.Close()
-
-	return mockableBootstrap(db, pg.config.MigrationsPath)
+	observer.Notify(database.Event{Type: database.BootstrapApplied})
+	return nil
 }
 
 // GetFileMigrations returns the available migrations found locally (sorted by ID)
+// restricted to the applications set by SetApplications
 func (pg *Postgres) GetFileMigrations() (migrations []database.FileMigration, err error) {
-	if pg.fileMigrations != nil {
-		return pg.fileMigrations, nil
+	if pg.fileMigrations == nil {
+		pg.fileMigrations, err = mockableGetFileMigrations(pg.config.MigrationsPath, pg.config.Vars)
+		if err != nil {
+			return pg.fileMigrations, err
+		}
 	}
 
-	pg.fileMigrations, err = mockableGetFileMigrations(pg.config.MigrationsPath)
-	return pg.fileMigrations, err
+	return database.FilterApplications(pg.fileMigrations, pg.applications), nil
 }
 
 // GetAppliedMigrations gets all applied migrations from the changelog (sorted by ID)
+// restricted to the applications set by SetApplications.
+// Applied migrations not found locally are always returned
 func (pg *Postgres) GetAppliedMigrations() (migrations []database.AppliedMigration, err error) {
-	if pg.appliedMigrations != nil {
+	if pg.appliedMigrations == nil {
+		if err = pg.loadAppliedMigrations(); err != nil {
+			return pg.appliedMigrations, err
+		}
+	}
+
+	if len(pg.applications) == 0 {
 		return pg.appliedMigrations, nil
 	}
 
-	db, err := mockableSQLOpen("pgx", pg.connectionURL)
+	if _, err = pg.GetFileMigrations(); err != nil {
+		return nil, err
+	}
+	appLookup := map[string]string{}
+	for _, mig := range pg.fileMigrations {
+		appLookup[mig.ID] = mig.Application
+	}
+	selectedApps := map[string]bool{}
+	for _, app := range pg.applications {
+		selectedApps[app] = true
+	}
+
+	for _, mig := range pg.appliedMigrations {
+		app, exists := appLookup[mig.ID]
+		if !exists || selectedApps[app] {
+			migrations = append(migrations, mig)
+		}
+	}
+	return migrations, nil
+}
+
+func (pg *Postgres) loadAppliedMigrations() (err error) {
+	pg.appliedMigrations, err = mockableGetAppliedMigrations(pg.db, changelogTable)
+	return err
+}
+
+// getAppliedMigrations reads the changelog without changing it. A missing changelog has no
+// applied migrations
+func getAppliedMigrations(db *sql.DB, changelogTable string) (
+	[]database.AppliedMigration, error,
+) {
+	exists, upgraded, err := getChangelogState(db)
 	if err != nil {
-		return nil, fmt.Errorf("Error opening database: %v", err)
+		return nil, err
+	}
+	if !exists {
+		return []database.AppliedMigration{}, nil
+	}
+	if !upgraded {
+		return database.GetAppliedMigrationsWithoutBaseline(db, changelogTable)
+	}
+	return database.GetAppliedMigrations(db, changelogTable)
+}
+
+// FindChangelogSquashes returns the squash migrations, whose squashed migrations were all
+// applied and are still in the changelog instead of the squash migration
+func (pg *Postgres) FindChangelogSquashes() ([]database.FileMigration, error) {
+	if _, err := pg.GetFileMigrations(); err != nil {
+		return nil, err
+	}
+	if !hasSquashes(pg.fileMigrations) {
+		return nil, nil
+	}
+	if pg.appliedMigrations == nil {
+		if err := pg.loadAppliedMigrations(); err != nil {
+			return nil, err
+		}
+	}
+	return database.FindChangelogSquashes(pg.fileMigrations, pg.appliedMigrations), nil
+}
+
+func hasSquashes(fileMigrations []database.FileMigration) bool {
+	for _, migration := range fileMigrations {
+		if len(migration.Squashes) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// SquashChangelog replaces the changelog entries of squashed migrations by their squash
+// migration (see FindChangelogSquashes)
+func (pg *Postgres) SquashChangelog() error {
+	if _, err := pg.FindChangelogSquashes(); err != nil {
+		return err
+	}
+	changed, err := mockableSquashChangelog(
+		pg.db, pg.fileMigrations, pg.appliedMigrations, changelogTable,
+	)
+	if err != nil || !changed {
+		return err
+	}
+	return pg.loadAppliedMigrations()
+}
+
+// ensureChangelogSquashed refuses to continue while squashed migrations are in the changelog,
+// as the squash migration would be applied again
+func (pg *Postgres) ensureChangelogSquashed() error {
+	squashes, err := pg.FindChangelogSquashes()
+	if err != nil || len(squashes) == 0 {
+		return err
+	}
+	names := []string{}
+	for _, squash := range squashes {
+		names = append(names, fmt.Sprintf("%s/%s", squash.Application, squash.Filename))
+	}
+	return fmt.Errorf(
+		"The changelog still contains the migrations squashed into %s. "+
+			"Replace them with migrate squash-changelog", strings.Join(names, ", "),
+	)
+}
+
+// SetAllowOutOfOrder allows to apply skipped (older) migrations after newer ones.
+// Down migrations then follow the order of the changelog instead of the ID
+func (pg *Postgres) SetAllowOutOfOrder(allow bool) {
+	pg.config.AllowOutOfOrder = allow
+}
+
+// AllowsOutOfOrder returns whether migrations may be applied out of order
+func (pg *Postgres) AllowsOutOfOrder() bool {
+	return pg.config.AllowOutOfOrder
+}
+
+// appliedInApplyOrder returns the applied migrations sorted by the time they were applied
+// if migrations are allowed out of order. Otherwise they stay sorted by ID
+func (pg *Postgres) appliedInApplyOrder() []database.AppliedMigration {
+	if !pg.config.AllowOutOfOrder {
+		return pg.appliedMigrations
 	}
-	defer db.Close()
 
-	pg.appliedMigrations, err = mockableGetAppliedMigrations(db, changelogTable)
-	return pg.appliedMigrations, err
+	migrations := append([]database.AppliedMigration{}, pg.appliedMigrations...)
+	sort.SliceStable(migrations, func(i, j int) bool {
+		return migrations[i].AppliedAt.Before(migrations[j].AppliedAt)
+	})
+	return migrations
+}
+
+// IsProtected returns whether the environment is configured as protected
+func (pg *Postgres) IsProtected() bool {
+	return pg.config.Protected
+}
+
+// SetApplications restricts the following operations to migrations of the given applications
+// (application folders). Without any applications all migrations are used
+func (pg *Postgres) SetApplications(applications []string) {
+	pg.applications = applications
 }
 
-// ApplyAllUpMigrations applies all up migrations
-func (pg *Postgres) ApplyAllUpMigrations(pw progress.Writer) (err error) {
+// ApplyAllUpMigrations applies all up migrations in the order of their IDs. It fails if a
+// requirement is not part of the migrations (e.g. of another application)
+func (pg *Postgres) ApplyAllUpMigrations(observer database.Observer) (err error) {
 	if pg.fileMigrations == nil {
 		_, err = pg.GetFileMigrations()
 		if err != nil {
@@ -106,33 +279,64 @@ func (pg *Postgres) ApplyAllUpMigrations(pw progress.Writer) (err error) {
 
 	}
 
-	db, err := mockableSQLOpen("pgx", pg.connectionURL)
-	if err != nil {
-		return fmt.Errorf("Error opening database: %v", err)
+	migrations := database.FilterApplications(pg.fileMigrations, pg.applications)
+	if err := database.EnsureRequirementsIncluded(migrations); err != nil {
+		return err
+	}
+	return pg.applyMigrations(migrations, direction.Up, observer)
+}
+
+// getHooks loads the hooks of the environment once
+func (pg *Postgres) getHooks() (database.Hooks, error) {
+	if pg.hooks == nil {
+		hooks, err := mockableGetHooks(pg.config)
+		if err != nil {
+			return hooks, err
+		}
+		pg.hooks = &hooks
 	}
-	defer db.Close()
+	return *pg.hooks, nil
+}
 
-	tracker = progress.Tracker{
-		Message: "Applying migrations",
-		Total:   int64(len(pg.fileMigrations)),
+// applyMigrations applies the migrations as one run, which is reported to the observer.
+// The before and after hooks of the direction run around the migrations
+func (pg *Postgres) applyMigrations(
+	migrations []database.FileMigration, dir direction.MigrateDirection,
+	observer database.Observer,
+) (err error) {
+	hooks, err := pg.getHooks()
+	if err != nil {
+		return err
+	}
+	if dir == direction.Up {
+		if err = pg.ensureChangelogSquashed(); err != nil {
+			return err
+		}
 	}
 
-	pw.AppendTracker(&tracker)
+	observer.Notify(database.Event{Type: database.RunStarted, Direction: dir, Total: len(migrations)})
+	start := time.Now()
+	defer func() {
+		observer.Notify(database.Event{
+			Type: database.RunFinished, Direction: dir, Duration: time.Since(start), Err: err,
+		})
+	}()
 
-	for _, migration := range pg.fileMigrations {
-		err = mockableApplyMigration(db, migration, changelogTable, direction.Up)
+	if err = hooks.RunBefore(pg.db, dir, observer); err != nil {
+		return err
+	}
+	for _, migration := range migrations {
+		err = mockableApplyMigration(pg.db, migration, changelogTable, dir, hooks, observer)
 		if err != nil {
 			return err
 		}
-		tracker.Increment(1)
 	}
-	tracker.MarkAsDone()
-
-	return nil
+	return hooks.RunAfter(pg.db, dir, observer)
 }
 
-// GenerateSeedSQL writes all migration into a single file as an SQL seed
-func (pg *Postgres) GenerateSeedSQL(f *os.File) (err error) {
+// GenerateSeedSQL writes all migrations (of the applications and up to options.ToID) into a
+// single SQL seed
+func (pg *Postgres) GenerateSeedSQL(w io.Writer, options database.SeedOptions) (err error) {
 	if pg.fileMigrations == nil {
 		_, err = pg.GetFileMigrations()
 		if err != nil {
@@ -141,46 +345,79 @@ func (pg *Postgres) GenerateSeedSQL(f *os.File) (err error) {
 
 	}
 
-	_, err = f.WriteString(createChangelogSQL)
-	if err != nil {
-		return fmt.Errorf("Could not write to target file")
+	migrations := pg.fileMigrations
+	if options.ToID != "" {
+		migrations, err = mockableFilterMigrationsUpToID(options.ToID, migrations)
+		if err != nil {
+			return err
+		}
+	}
+	migrations = database.FilterApplications(migrations, pg.applications)
+	// the seeded changelog has to be consistent, so no requirement may be left out
+	if err := database.EnsureRequirementsIncluded(migrations); err != nil {
+		return fmt.Errorf("Could not generate the seed: %v", err)
+	}
+	for _, mig := range migrations {
+		if mig.IsGo() {
+			return fmt.Errorf("The migration %s is written in Go and has no SQL to seed", mig.Filename)
+		}
 	}
 
-	bootstrapSQL, err := mockableGetBootstrapSQL(pg.config.MigrationsPath)
+	bootstrapSQL, err := mockableGetBootstrapSQL(pg.config.MigrationsPath, pg.config.Vars)
 	if err != nil {
 		return err
 	}
+
+	var seed strings.Builder
+	if options.Transaction {
+		seed.WriteString("BEGIN;\n")
+	}
+	if options.Idempotent {
+		seed.WriteString(strings.Replace(
+			createChangelogSQL, "CREATE TABLE", "CREATE TABLE IF NOT EXISTS", 1,
+		))
+		seed.WriteString(upgradeChangelogSQL)
+	} else {
+		seed.WriteString(createChangelogSQL)
+	}
 	if bootstrapSQL != "" {
-		_, err = f.WriteString(fmt.Sprintf("%s\n", bootstrapSQL))
-		if err != nil {
-			return fmt.Errorf("Could not write to target file")
-		}
+		seed.WriteString(fmt.Sprintf("%s\n", bootstrapSQL))
 	}
-
-	for _, migration := range pg.fileMigrations {
-		_, err = f.WriteString(
-			fmt.Sprintf("%s;\n", migration.UpSQL),
-		)
-		if err != nil {
-			return fmt.Errorf("Could not write to target file")
+	if options.Idempotent {
+		for _, mig := range migrations {
+			seed.WriteString(idempotentUpSQL(mig))
 		}
+	} else {
+		seed.WriteString(database.ConcatenateUpSQL(migrations, changelogTable))
+	}
+	if options.Transaction {
+		seed.WriteString("COMMIT;\n")
+	}
 
-		insertSQL := fmt.Sprintf(
-			database.ChangelogInsertSQL, changelogTable, migration.ID, migration.Description,
-		)
-		_, err = f.WriteString(
-			fmt.Sprintf("%s;\n", insertSQL),
-		)
-		if err != nil {
-			return fmt.Errorf("Could not write to target file")
-		}
+	if _, err = io.WriteString(w, seed.String()); err != nil {
+		return fmt.Errorf("Could not write the seed: %v", err)
 	}
 	return nil
 }
 
+// idempotentUpSQL guards the up SQL and the changelog insert of the migration by its changelog
+// entry. The up SQL is dollar quoted with a tag unique to the migration
+func idempotentUpSQL(migration database.FileMigration) string {
+	quoteTag := fmt.Sprintf("$migration_%s$", migration.ID)
+	return fmt.Sprintf(
+		idempotentMigrationSQL,
+		changelogTable, database.QuoteLiteral(migration.ID),
+		quoteTag, migration.UpSQL, quoteTag,
+		fmt.Sprintf(
+			database.ChangelogInsertLiteralSQL, changelogTable,
+			database.QuoteLiteral(migration.ID), database.QuoteLiteral(migration.Description),
+		),
+	)
+}
+
 // ApplySpecificMigration applies one migration by a filter
 func (pg *Postgres) ApplySpecificMigration(
-	filter string, direction direction.MigrateDirection,
+	filter string, dir direction.MigrateDirection, observer database.Observer,
 ) (err error) {
 	if pg.fileMigrations == nil {
 		_, err = pg.GetFileMigrations()
@@ -190,12 +427,6 @@ func (pg *Postgres) ApplySpecificMigration(
 
 	}
 
-	db, err := mockableSQLOpen("pgx", pg.connectionURL)
-	if err != nil {
-		return fmt.Errorf("Error opening database: %v", err)
-	}
-	defer db.Close()
-
 	if pg.appliedMigrations == nil {
 		_, err = pg.GetAppliedMigrations()
 		if err != nil {
@@ -204,86 +435,324 @@ func (pg *Postgres) ApplySpecificMigration(
 	}
 
 	migration, err := mockableFilterMigrationsByText(
-		filter, direction, pg.fileMigrations, pg.appliedMigrations,
+		filter, dir, pg.fileMigrations, pg.appliedInApplyOrder(), pg.applications,
 	)
 	if err != nil {
 		return err
 	}
 
-	err = mockableApplyMigration(db, migration, changelogTable, direction)
+	return pg.applyMigrations([]database.FileMigration{migration}, dir, observer)
+}
+
+// FindRepeatableMigrations returns the repeatable migrations, which are new or changed since
+// they were applied the last time, without changing the database
+func (pg *Postgres) FindRepeatableMigrations() ([]database.RepeatableMigration, error) {
+	repeatables, err := mockableGetRepeatableMigrations(pg.config.MigrationsPath, pg.config.Vars)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	exists, err := mockableRepeatableChangelogExists(pg.db, repeatableChangelogTable)
+	if err != nil {
+		return nil, err
 	}
+	checksums := map[string]string{}
+	if exists {
+		if checksums, err = mockableGetAppliedChecksums(pg.db, repeatableChangelogTable); err != nil {
+			return nil, err
+		}
+	}
+	return database.FilterChangedRepeatables(
+		database.FilterRepeatableApplications(repeatables, pg.applications), checksums,
+	), nil
+}
 
+// ApplyRepeatableMigrations applies all repeatable migrations, which are new or changed since
+// they were applied the last time. They are only applied if no versioned migration is pending,
+// otherwise database.ErrPendingMigrations is returned
+func (pg *Postgres) ApplyRepeatableMigrations(
+	observer database.Observer,
+) (applied int, err error) {
+	if err := pg.ensureNoPendingMigrations(); err != nil {
+		return 0, err
+	}
+
+	repeatables, err := mockableGetRepeatableMigrations(pg.config.MigrationsPath, pg.config.Vars)
+	if err != nil {
+		return 0, err
+	}
+	if err := mockableEnsureRepeatableChangelog(pg.db, repeatableChangelogTable); err != nil {
+		return 0, err
+	}
+	checksums, err := mockableGetAppliedChecksums(pg.db, repeatableChangelogTable)
+	if err != nil {
+		return 0, err
+	}
+
+	changed := database.FilterChangedRepeatables(
+		database.FilterRepeatableApplications(repeatables, pg.applications), checksums,
+	)
+	for _, migration := range changed {
+		start := time.Now()
+		err = mockableApplyRepeatableMigration(pg.db, migration, repeatableChangelogTable)
+		event := database.Event{
+			Type: database.RepeatableApplied, Direction: direction.Up, File: migration.Name(),
+			Duration: time.Since(start), Err: err,
+		}
+		if err != nil {
+			event.Type = database.RepeatableFailed
+			observer.Notify(event)
+			return applied, err
+		}
+		observer.Notify(event)
+		applied++
+	}
+	return applied, nil
+}
+
+// ApplyDataSeeds applies the data seeds of the environment, which were not applied yet.
+// They are only applied if no versioned migration is pending, otherwise
+// database.ErrPendingMigrations is returned
+func (pg *Postgres) ApplyDataSeeds(observer database.Observer) (applied int, err error) {
+	if err := pg.ensureNoPendingMigrations(); err != nil {
+		return 0, err
+	}
+
+	seeds, err := mockableGetDataSeeds(
+		pg.config.MigrationsPath, pg.config.Environment, pg.config.Vars,
+	)
+	if err != nil {
+		return 0, err
+	}
+	if err := mockableEnsureSeedsChangelog(pg.db, seedsChangelogTable); err != nil {
+		return 0, err
+	}
+	appliedSeeds, err := mockableGetAppliedSeeds(pg.db, seedsChangelogTable)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, seed := range database.FilterPendingSeeds(seeds, appliedSeeds) {
+		start := time.Now()
+		err := mockableApplyDataSeed(pg.db, seed, seedsChangelogTable)
+		event := database.Event{
+			Type: database.SeedApplied, Direction: direction.Up, File: seed.Filename,
+			Duration: time.Since(start), Err: err,
+		}
+		if err != nil {
+			event.Type = database.SeedFailed
+			observer.Notify(event)
+			return applied, err
+		}
+		observer.Notify(event)
+		applied++
+	}
+	return applied, nil
+}
+
+// ensureNoPendingMigrations returns database.ErrPendingMigrations if any versioned migration
+// is not applied yet
+func (pg *Postgres) ensureNoPendingMigrations() error {
+	fileMigrations, err := pg.GetFileMigrations()
+	if err != nil {
+		return err
+	}
+	// the cache does not contain the migrations applied within this run
+	pg.appliedMigrations = nil
+	appliedMigrations, err := pg.GetAppliedMigrations()
+	if err != nil {
+		return err
+	}
+	pending := len(fileMigrations) - countApplied(fileMigrations, appliedMigrations)
+	if pending > 0 {
+		return fmt.Errorf("%d %w", pending, database.ErrPendingMigrations)
+	}
 	return nil
 }
 
-// ApplyMigrationsWithCount applies up migration by a count
-func (pg *Postgres) ApplyMigrationsWithCount(
-	count uint, all bool, dir direction.MigrateDirection,
-) (err error) {
+func countApplied(
+	fileMigrations []database.FileMigration, appliedMigrations []database.AppliedMigration,
+) (count int) {
+	appliedLookup := map[string]bool{}
+	for _, mig := range appliedMigrations {
+		appliedLookup[mig.ID] = true
+	}
+	for _, mig := range fileMigrations {
+		if appliedLookup[mig.ID] {
+			count++
+		}
+	}
+	return count
+}
+
+// FindMigration returns one migration by a filter
+func (pg *Postgres) FindMigration(
+	filter string, dir direction.MigrateDirection,
+) (migration database.FileMigration, err error) {
 	if pg.fileMigrations == nil {
-		pg.fileMigrations, err = mockableGetFileMigrations(pg.config.MigrationsPath)
+		_, err = pg.GetFileMigrations()
 		if err != nil {
-			return err
+			return migration, err
 		}
+	}
 
+	if pg.appliedMigrations == nil {
+		_, err = pg.GetAppliedMigrations()
+		if err != nil {
+			return migration, err
+		}
 	}
 
-	db, err := mockableSQLOpen("pgx", pg.connectionURL)
+	return mockableFilterMigrationsByText(
+		filter, dir, pg.fileMigrations, pg.appliedInApplyOrder(), pg.applications,
+	)
+}
+
+// MarkMigration adds or removes a migration to / from the changelog without executing it
+func (pg *Postgres) MarkMigration(
+	migration database.FileMigration, dir direction.MigrateDirection,
+) error {
+	return mockableMarkMigration(pg.db, migration, dir, changelogTable, auditTable)
+}
+
+// ApplyMigrationsWithCount applies up migration by a count
+func (pg *Postgres) ApplyMigrationsWithCount(
+	count uint, all bool, dir direction.MigrateDirection, observer database.Observer,
+) (err error) {
+	migrations, err := pg.FindMigrationsWithCount(count, all, dir)
 	if err != nil {
-		return fmt.Errorf("Error opening database: %v", err)
+		return err
+	}
+
+	return pg.applyMigrations(migrations, dir, observer)
+}
+
+// FindMigrationsWithCount returns the migrations ApplyMigrationsWithCount would apply
+func (pg *Postgres) FindMigrationsWithCount(
+	count uint, all bool, dir direction.MigrateDirection,
+) (migrations []database.FileMigration, err error) {
+	if pg.fileMigrations == nil {
+		pg.fileMigrations, err = mockableGetFileMigrations(pg.config.MigrationsPath, pg.config.Vars)
+		if err != nil {
+			return nil, err
+		}
+
 	}
-	defer db.Close()
 
 	if pg.appliedMigrations == nil {
 		_, err = pg.GetAppliedMigrations()
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
 
-	migrations, err := mockableFilterMigrationsByCount(
-		count, all, dir, pg.fileMigrations, pg.appliedMigrations,
+	return mockableFilterMigrationsByCount(
+		count, all, dir, pg.fileMigrations, pg.appliedInApplyOrder(), pg.applications,
 	)
+}
+
+// Baseline marks all file migrations up to (and including) the given ID as applied
+// without executing them. It refuses to run if the changelog already contains entries
+func (pg *Postgres) Baseline(toID string) (err error) {
+	migrations, err := pg.GetFileMigrations()
 	if err != nil {
 		return err
 	}
 
-	for _, migration := range migrations {
-		err = mockableApplyMigration(db, migration, changelogTable, dir)
+	if pg.appliedMigrations == nil {
+		_, err = pg.GetAppliedMigrations()
 		if err != nil {
 			return err
 		}
 	}
-	return nil
+	if len(pg.appliedMigrations) > 0 {
+		return fmt.Errorf(
+			"The changelog already contains %d migrations. A baseline requires an empty changelog",
+			len(pg.appliedMigrations),
+		)
+	}
+
+	baselineMigrations, err := mockableFilterMigrationsUpToID(toID, migrations)
+	if err != nil {
+		return err
+	}
+
+	return mockableInsertBaselineToChangelog(pg.db, baselineMigrations, changelogTable)
 }
 
-// EnsureMigrationsChangelog creates a migrations changelog if necessary
-func (pg *Postgres) EnsureMigrationsChangelog() (created bool, err error) {
-	db, err := mockableSQLOpen("pgx", pg.connectionURL)
+// Squash merges the migrations of the application up to (and including) the given ID into a
+// single migration and marks it as applied if all merged migrations were applied
+func (pg *Postgres) Squash(
+	beforeID, application string,
+) (squash database.FileMigration, err error) {
+	if _, err = pg.GetFileMigrations(); err != nil {
+		return squash, err
+	}
+
+	squash, err = mockableSquashMigrations(
+		pg.config.MigrationsPath, application, beforeID, pg.fileMigrations,
+	)
 	if err != nil {
-		return false, fmt.Errorf("Error opening database: %v", err)
+		return squash, err
 	}
-	defer db.Close()
 
-	existRow := db.QueryRow(dedent.Dedent(`
-		SELECT EXISTS (
-			SELECT FROM information_schema.tables
-			WHERE table_schema = 'public'
-				AND	table_name = 'migrations_changelog'
-		) AS exists
-	`))
+	pg.fileMigrations = nil
+	pg.appliedMigrations = nil
+	return squash, nil
+}
+
+// changelogColumnsSQL checks whether the changelog exists and has all columns of this version
+var changelogColumnsSQL = dedent.Dedent(`
+	SELECT count(*) > 0 AS exists, COALESCE(bool_or(column_name = 'baseline'), false) AS upgraded
+	FROM information_schema.columns
+	WHERE table_schema = 'public'
+		AND	table_name = 'migrations_changelog'
+`)
 
-	var exists bool
-	err = existRow.Scan(&exists)
+// getChangelogState returns whether the changelog exists and whether it was created or upgraded
+// by this version. It does not change the database
+func getChangelogState(db *sql.DB) (exists bool, upgraded bool, err error) {
+	err = db.QueryRow(changelogColumnsSQL).Scan(&exists, &upgraded)
 	if err != nil {
-		return false, fmt.Errorf("Error checking for migrations changelog existence: %v", err)
+		return false, false, fmt.Errorf(
+			"Error checking for migrations changelog existence: %v", err,
+		)
+	}
+	return exists, upgraded, nil
+}
+
+// PlanMigrationsChangelog returns the changes EnsureMigrationsChangelog would make
+func (pg *Postgres) PlanMigrationsChangelog() (plan []string, err error) {
+	exists, upgraded, err := mockableGetChangelogState(pg.db)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return []string{fmt.Sprintf("create the changelog table %s", changelogTable)}, nil
+	}
+	if !upgraded {
+		return []string{fmt.Sprintf("add the baseline column to %s", changelogTable)}, nil
+	}
+	return nil, nil
+}
+
+// EnsureMigrationsChangelog creates a migrations changelog if necessary and upgrades changelogs
+// created by older versions
+func (pg *Postgres) EnsureMigrationsChangelog() (created bool, err error) {
+	exists, upgraded, err := mockableGetChangelogState(pg.db)
+	if err != nil {
+		return false, err
 	}
 	if exists {
+		if upgraded {
+			return false, nil
+		}
+		_, err = pg.db.Exec(upgradeChangelogSQL)
+		if err != nil {
+			return false, fmt.Errorf("Error upgrading migrations changelog: %v", err)
+		}
 		return false, nil
 	}
-	_, err = db.Exec(createChangelogSQL)
+	_, err = pg.db.Exec(createChangelogSQL)
 	if err != nil {
 		return false, fmt.Errorf("Error creating migrations changelog: %v", err)
 	}
@@ -308,16 +777,41 @@ func (pg *Postgres) EnsureConsistentMigrations() (err error) {
 		}
 
 	}
+	if err = pg.ensureChangelogSquashed(); err != nil {
+		return err
+	}
 
-	return mockableEnsureConsistentMigrations(pg.fileMigrations, pg.appliedMigrations)
+	return mockableEnsureConsistentMigrations(
+		pg.fileMigrations, pg.appliedMigrations, pg.applications, pg.config.AllowOutOfOrder,
+	)
 }
 
 // Init initializes the database with the given configuration
 func (pg *Postgres) Init(config config.Config) error {
 	pg.config = config
-	pg.connectionURL = fmt.Sprintf(
-		"postgresql://%s:%s@%s:%d/%s",
-		config.Db.User, config.Db.Password, config.Db.Host, config.Db.Port, config.Db.Name,
-	)
+	pg.connectionURL = config.DSN()
+
+	db, err := mockableSQLOpen("pgx", pg.connectionURL)
+	if err != nil {
+		return fmt.Errorf("Error opening database: %v", err)
+	}
+	// a single connection keeps the session (search_path, locks, SET commands) for the whole run
+	db.SetMaxOpenConns(1)
+	pg.db = db
+
+	return nil
+}
+
+// Close closes the connection to the database
+func (pg *Postgres) Close() error {
+	if pg.db == nil {
+		return nil
+	}
+
+	err := pg.db.Close()
+	pg.db = nil
+	if err != nil {
+		return fmt.Errorf("Error closing database: %v", err)
+	}
 	return nil
 }
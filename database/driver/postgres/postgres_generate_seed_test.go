@@ -1,6 +1,9 @@
 package postgres
 
 import (
+	"bytes"
+	"context"
+	"database/sql"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -8,6 +11,9 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/kylelemons/godebug/pretty"
+	"github.com/lithammer/dedent"
+
 	"go-migrations/database"
 )
 
@@ -21,15 +27,15 @@ func TestGenerateSeedSQL(t *testing.T) {
 		{ID: "1", UpSQL: "SELECT 1"},
 		{ID: "2", UpSQL: "SELECT 2"},
 	}
-	mockableGetFileMigrations = func(a string) ([]database.FileMigration, error) {
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
 		return migrations, nil
 	}
-	mockableGetBootstrapSQL = func(p string) (string, error) {
+	mockableGetBootstrapSQL = func(p string, v map[string]string) (string, error) {
 		return "SELECT 'bootstrap';", nil
 	}
 
 	pg := Postgres{}
-	err := pg.GenerateSeedSQL(tmpFile)
+	err := pg.GenerateSeedSQL(tmpFile, database.SeedOptions{})
 	if err != nil {
 		t.Errorf("Expected no error, but got: %s", err)
 	}
@@ -84,12 +90,12 @@ func TestGenerateSeedSQLNoBootstrap(t *testing.T) {
 	migrations := []database.FileMigration{
 		{ID: "1", UpSQL: "SELECT 1"},
 	}
-	mockableGetFileMigrations = func(a string) ([]database.FileMigration, error) {
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
 		return migrations, nil
 	}
 
 	pg := Postgres{}
-	err := pg.GenerateSeedSQL(tmpFile)
+	err := pg.GenerateSeedSQL(tmpFile, database.SeedOptions{})
 	if err != nil {
 		t.Errorf("Expected no error, but got: %s", err)
 	}
@@ -129,3 +135,145 @@ func TestGenerateSeedSQLNoBootstrap(t *testing.T) {
 		)
 	}
 }
+
+func TestGenerateSeedSQLTransactionIdempotent(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{
+			{ID: "20200101000001", Description: "foo", UpSQL: "SELECT 1"},
+		}, nil
+	}
+	mockableGetBootstrapSQL = func(p string, v map[string]string) (string, error) {
+		return "", nil
+	}
+
+	var seed bytes.Buffer
+	pg := Postgres{}
+	err := pg.GenerateSeedSQL(&seed, database.SeedOptions{Transaction: true, Idempotent: true})
+	if err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+
+	expectedSeed := "BEGIN;\n" +
+		strings.Replace(createChangelogSQL, "CREATE TABLE", "CREATE TABLE IF NOT EXISTS", 1) +
+		upgradeChangelogSQL +
+		dedent.Dedent(`
+			DO $seed$
+			BEGIN
+				IF NOT EXISTS (SELECT 1 FROM public.migrations_changelog WHERE id = '20200101000001') THEN
+					EXECUTE $migration_20200101000001$SELECT 1$migration_20200101000001$;
+					INSERT INTO public.migrations_changelog (id, name, applied_at) VALUES ('20200101000001', 'foo', now());
+				END IF;
+			END
+			$seed$;
+		`) +
+		"COMMIT;\n"
+	if diff := pretty.Compare(expectedSeed, seed.String()); diff != "" {
+		t.Errorf("Did not get the expected seed:\n%s", diff)
+	}
+}
+
+func TestGenerateSeedSQLGoMigration(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
+		noop := func(ctx context.Context, tx *sql.Tx) error { return nil }
+		return []database.FileMigration{{ID: "1", UpFunc: noop, DownFunc: noop}}, nil
+	}
+
+	pg := Postgres{}
+	if err := pg.GenerateSeedSQL(&bytes.Buffer{}, database.SeedOptions{}); err == nil {
+		t.Errorf("Expected an error for a Go migration")
+	}
+}
+
+func TestGenerateSeedSQLToIDWithApplications(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{
+			{ID: "1", Description: "a", Application: "common", UpSQL: "SELECT 1"},
+			{ID: "2", Description: "b", Application: "sub_app", UpSQL: "SELECT 2"},
+			{ID: "3", Description: "c", Application: "common", UpSQL: "SELECT 3"},
+			{ID: "4", Description: "d", Application: "common", UpSQL: "SELECT 4"},
+		}, nil
+	}
+	mockableGetBootstrapSQL = func(p string, v map[string]string) (string, error) {
+		return "", nil
+	}
+
+	var seed bytes.Buffer
+	pg := Postgres{}
+	pg.SetApplications([]string{"common"})
+	if err := pg.GenerateSeedSQL(&seed, database.SeedOptions{ToID: "3"}); err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+
+	expectedSeed := createChangelogSQL + strings.Join([]string{
+		"SELECT 1;",
+		"INSERT INTO public.migrations_changelog (id, name, applied_at) VALUES ('1', 'a', now());",
+		"SELECT 3;",
+		"INSERT INTO public.migrations_changelog (id, name, applied_at) VALUES ('3', 'c', now());",
+		"",
+	}, "\n")
+	if diff := pretty.Compare(expectedSeed, seed.String()); diff != "" {
+		t.Errorf("Did not get the expected seed:\n%s", diff)
+	}
+
+	if err := pg.GenerateSeedSQL(&bytes.Buffer{}, database.SeedOptions{ToID: "5"}); err == nil {
+		t.Errorf("Expected an error for an unknown id")
+	}
+}
+
+func TestGenerateSeedSQLMissingRequirement(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{
+			{ID: "1", Application: "common", UpSQL: "SELECT 1"},
+			{ID: "2", Application: "sub_app", UpSQL: "SELECT 2", Requires: []string{"1"}},
+		}, nil
+	}
+
+	pg := Postgres{}
+	pg.SetApplications([]string{"sub_app"})
+	if err := pg.GenerateSeedSQL(&bytes.Buffer{}, database.SeedOptions{}); err == nil {
+		t.Errorf("Expected an error for a requirement of another application")
+	}
+}
+
+func TestGenerateSeedSQLSpecialCharacters(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{
+			{ID: "1", Description: "fix_user's_table", UpSQL: "SELECT 1"},
+			{ID: "2", Description: "größe_ändern", UpSQL: "SELECT 2"},
+			{ID: "3", Description: `back\slash`, UpSQL: "SELECT 3"},
+		}, nil
+	}
+	mockableGetBootstrapSQL = func(p string, v map[string]string) (string, error) {
+		return "", nil
+	}
+
+	var seed bytes.Buffer
+	pg := Postgres{}
+	if err := pg.GenerateSeedSQL(&seed, database.SeedOptions{}); err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+
+	insertSQL := "INSERT INTO public.migrations_changelog (id, name, applied_at) VALUES "
+	expectedSeed := createChangelogSQL + strings.Join([]string{
+		"SELECT 1;",
+		insertSQL + "('1', 'fix_user''s_table', now());",
+		"SELECT 2;",
+		insertSQL + "('2', 'größe_ändern', now());",
+		"SELECT 3;",
+		insertSQL + `('3', E'back\\slash', now());`,
+		"",
+	}, "\n")
+	if diff := pretty.Compare(expectedSeed, seed.String()); diff != "" {
+		t.Errorf("Did not get the expected seed:\n%s", diff)
+	}
+}
@@ -10,9 +10,9 @@ import (
 	"path/filepath"
 	"testing"
 
-	"github.com/jedib0t/go-pretty/v6/progress"
 	"github.com/lithammer/dedent"
 
+	"go-migrations/database"
 	"go-migrations/database/driver"
 	"go-migrations/internal/direction"
 	"go-migrations/utils"
@@ -49,7 +49,8 @@ func TestApplyBootstrap(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Returned error loading database: %v", err)
 	}
-	err = db.Bootstrap()
+	defer db.Close()
+	err = db.Bootstrap(database.NoObserver)
 	if err != nil {
 		t.Fatalf("Error during bootstrap: %v", err)
 	}
@@ -107,12 +108,13 @@ func TestApplyAllUpMigrations(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Returned error loading database: %v", err)
 	}
+	defer db.Close()
 
 	if _, err := db.EnsureMigrationsChangelog(); err != nil {
 		t.Fatalf("Error during changelog creation: %v", err)
 	}
 
-	if err := db.ApplyAllUpMigrations(progress.NewWriter()); err != nil {
+	if err := db.ApplyAllUpMigrations(database.NoObserver); err != nil {
 		t.Fatalf("Error during up migration: %v", err)
 	}
 
@@ -187,12 +189,13 @@ func TestApplyUpMigrationsWithCount(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Returned error loading database: %v", err)
 	}
+	defer db.Close()
 
 	if _, err := db.EnsureMigrationsChangelog(); err != nil {
 		t.Fatalf("Error during changelog creation: %v", err)
 	}
 
-	if err := db.ApplyMigrationsWithCount(3, false, direction.Up); err != nil {
+	if err := db.ApplyMigrationsWithCount(3, false, direction.Up, database.NoObserver); err != nil {
 		t.Fatalf("Error during up migration: %v", err)
 	}
 
@@ -2,11 +2,13 @@ package postgres
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
-	"github.com/jedib0t/go-pretty/v6/progress"
 	"github.com/kylelemons/godebug/pretty"
 
 	"go-migrations/database"
@@ -22,16 +24,15 @@ type migrateCallArgs struct {
 func TestApplyAllUpMigrations(t *testing.T) {
 	defer resetMockVariables()
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	mockableSQLOpen = func(a, b string) (*sql.DB, error) { return db, err }
-	mock.ExpectClose()
 
-	mockableGetFileMigrations = func(a string) ([]database.FileMigration, error) {
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
 		return []database.FileMigration{{ID: "1"}, {ID: "2"}}, nil
 	}
 
 	receivedMigrateArgs := []migrateCallArgs{}
 	mockableApplyMigration = func(
 		db *sql.DB, f database.FileMigration, c string, d direction.MigrateDirection,
+		h database.Hooks, o database.Observer,
 	) error {
 		receivedMigrateArgs = append(
 			receivedMigrateArgs,
@@ -45,8 +46,13 @@ func TestApplyAllUpMigrations(t *testing.T) {
 		{migration: database.FileMigration{ID: "2"}, changelogTable: changelogTable},
 	}
 
-	pg := Postgres{}
-	err = pg.ApplyAllUpMigrations(progress.NewWriter())
+	var events []database.Event
+	observer := database.ObserverFunc(func(event database.Event) {
+		events = append(events, event)
+	})
+
+	pg := Postgres{db: db}
+	err = pg.ApplyAllUpMigrations(observer)
 	if err != nil {
 		t.Errorf("Expected no error, but got: %s", err)
 	}
@@ -59,12 +65,39 @@ func TestApplyAllUpMigrations(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 
-	if tracker.Total != 2 {
-		t.Errorf("The progress tracker should have a length of 2")
+	if len(events) != 2 || events[0].Type != database.RunStarted || events[0].Total != 2 {
+		t.Fatalf("Expected the run to start with 2 migrations, but got %v", events)
+	}
+	if events[1].Type != database.RunFinished || events[1].Err != nil {
+		t.Errorf("Expected the run to finish without error, but got %v", events[1])
+	}
+}
+
+func TestApplyAllUpMigrationsRequirements(t *testing.T) {
+	defer resetMockVariables()
+	mockableGetFileMigrations = func(a string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{
+			{ID: "1", Application: "common"},
+			{ID: "2", Application: "sub_app", Filename: "2_b.sql", Requires: []string{"1"}},
+		}, nil
+	}
+	applied := 0
+	mockableApplyMigration = func(
+		db *sql.DB, f database.FileMigration, c string, d direction.MigrateDirection,
+		h database.Hooks, o database.Observer,
+	) error {
+		applied++
+		return nil
 	}
 
-	if !tracker.IsDone() {
-		t.Errorf("The progress tracker should be done")
+	// the requirement of another application is not applied before
+	pg := Postgres{applications: []string{"sub_app"}}
+	err := pg.ApplyAllUpMigrations(database.ObserverFunc(func(database.Event) {}))
+	if err == nil || !strings.Contains(err.Error(), "requires 1, which is not applied before it") {
+		t.Errorf("Expected an error for the missing requirement, but got: %v", err)
+	}
+	if applied > 0 {
+		t.Errorf("Expected no migration to be applied, but applied %d", applied)
 	}
 }
 
@@ -73,12 +106,11 @@ func TestApplyMigrationsWithCount(t *testing.T) {
 	for _, dir := range direction.Directions {
 
 		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		mockableSQLOpen = func(a, b string) (*sql.DB, error) { return db, err }
-		mock.ExpectClose()
 
 		receivedMigrateArgs := []migrateCallArgs{}
 		mockableApplyMigration = func(
 			db *sql.DB, f database.FileMigration, c string, d direction.MigrateDirection,
+			h database.Hooks, o database.Observer,
 		) error {
 			receivedMigrateArgs = append(
 				receivedMigrateArgs,
@@ -97,7 +129,7 @@ func TestApplyMigrationsWithCount(t *testing.T) {
 
 		var receivedFilterByCountArgs filterByCountArgs
 		mockableFilterMigrationsByCount = func(c uint, a bool, d direction.MigrateDirection,
-			f []database.FileMigration, app []database.AppliedMigration) (
+			f []database.FileMigration, app []database.AppliedMigration, apps []string) (
 			[]database.FileMigration, error,
 		) {
 			receivedFilterByCountArgs = filterByCountArgs{
@@ -126,11 +158,12 @@ func TestApplyMigrationsWithCount(t *testing.T) {
 			fileMigrations: fileMigrations, appliedMigrations: appliedMigrations,
 		}
 
-		pg := Postgres{}
+		pg := Postgres{db: db}
 		pg.fileMigrations = fileMigrations
 		pg.appliedMigrations = appliedMigrations
 		err = pg.ApplyMigrationsWithCount(
 			expectedFilterByCountArgs.count, expectedFilterByCountArgs.all, dir.Direction,
+			database.NoObserver,
 		)
 		if err != nil {
 			t.Errorf("Expected no error, but got: %s", err)
@@ -153,27 +186,26 @@ func TestApplyMigrationsWithCount(t *testing.T) {
 func TestApplyMigrationsWithCountError(t *testing.T) {
 	defer resetMockVariables()
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	mockableSQLOpen = func(a, b string) (*sql.DB, error) { return db, err }
-	mock.ExpectClose()
 
 	var migrateCalled bool
 	mockableApplyMigration = func(
 		db *sql.DB, f database.FileMigration, c string, d direction.MigrateDirection,
+		h database.Hooks, o database.Observer,
 	) error {
 		migrateCalled = true
 		return nil
 	}
 	mockableFilterMigrationsByCount = func(c uint, a bool, d direction.MigrateDirection,
-		f []database.FileMigration, app []database.AppliedMigration) (
+		f []database.FileMigration, app []database.AppliedMigration, apps []string) (
 		[]database.FileMigration, error,
 	) {
 		return []database.FileMigration{}, fmt.Errorf("test")
 	}
 
-	pg := Postgres{}
+	pg := Postgres{db: db}
 	pg.fileMigrations = []database.FileMigration{{ID: "1"}}
 	pg.appliedMigrations = []database.AppliedMigration{{ID: "1"}}
-	err = pg.ApplyMigrationsWithCount(3, false, direction.Up)
+	err = pg.ApplyMigrationsWithCount(3, false, direction.Up, database.NoObserver)
 	if err == nil {
 		t.Errorf("Expected error, but got nothing")
 	}
@@ -191,14 +223,12 @@ func TestApplySpecificMigration(t *testing.T) {
 	defer resetMockVariables()
 	for _, dir := range direction.Directions {
 		db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-		mockableSQLOpen = func(a, b string) (*sql.DB, error) { return db, err }
-		mock.ExpectClose()
 
 		var migrateMigration database.FileMigration
 		var migrateChangelog string
 		var migrateDirection direction.MigrateDirection
 		mockableApplyMigration = func(db *sql.DB, f database.FileMigration, c string,
-			d direction.MigrateDirection,
+			d direction.MigrateDirection, h database.Hooks, o database.Observer,
 		) error {
 			migrateMigration = f
 			migrateChangelog = c
@@ -210,16 +240,17 @@ func TestApplySpecificMigration(t *testing.T) {
 		var filterMigrationsByTextFilter string
 		var filterMigrationsByTextDirection direction.MigrateDirection
 		mockableFilterMigrationsByText = func(fi string, d direction.MigrateDirection,
-			f []database.FileMigration, a []database.AppliedMigration) (database.FileMigration, error) {
+			f []database.FileMigration, a []database.AppliedMigration, apps []string,
+		) (database.FileMigration, error) {
 			filterMigrationsByTextFilter = fi
 			filterMigrationsByTextDirection = d
 			return expectedMigration, nil
 		}
 
-		pg := Postgres{}
+		pg := Postgres{db: db}
 		pg.fileMigrations = []database.FileMigration{}
 		pg.appliedMigrations = []database.AppliedMigration{}
-		err = pg.ApplySpecificMigration("sth", dir.Direction)
+		err = pg.ApplySpecificMigration("sth", dir.Direction, database.NoObserver)
 		if err != nil {
 			t.Errorf("Expected no error, but got %v", err)
 		}
@@ -230,7 +261,7 @@ func TestApplySpecificMigration(t *testing.T) {
 		if migrateDirection != dir.Direction {
 			t.Errorf("Expected %s migration, but got the other direction", dir.Name)
 		}
-		if migrateMigration != expectedMigration {
+		if diff := pretty.Compare(expectedMigration, migrateMigration); diff != "" {
 			t.Errorf("Expected migration '%v', but got %v", expectedMigration, migrateMigration)
 		}
 		if filterMigrationsByTextFilter != "sth" {
@@ -255,26 +286,26 @@ func TestApplySpecificMigration(t *testing.T) {
 func TestApplySpecificMigrationError(t *testing.T) {
 	defer resetMockVariables()
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	mockableSQLOpen = func(a, b string) (*sql.DB, error) { return db, err }
-	mock.ExpectClose()
 
 	var migrateCalled bool
 	mockableApplyMigration = func(
 		db *sql.DB, f database.FileMigration, c string, d direction.MigrateDirection,
+		h database.Hooks, o database.Observer,
 	) error {
 		migrateCalled = true
 		return nil
 	}
 
 	mockableFilterMigrationsByText = func(fi string, d direction.MigrateDirection,
-		f []database.FileMigration, a []database.AppliedMigration) (database.FileMigration, error) {
+		f []database.FileMigration, a []database.AppliedMigration, apps []string,
+	) (database.FileMigration, error) {
 		return database.FileMigration{}, fmt.Errorf("test")
 	}
 
-	pg := Postgres{}
+	pg := Postgres{db: db}
 	pg.fileMigrations = []database.FileMigration{}
 	pg.appliedMigrations = []database.AppliedMigration{}
-	err = pg.ApplySpecificMigration("sth", direction.Up)
+	err = pg.ApplySpecificMigration("sth", direction.Up, database.NoObserver)
 	if err == nil {
 		t.Errorf("Expected error, but got none")
 	}
@@ -287,3 +318,393 @@ func TestApplySpecificMigrationError(t *testing.T) {
 		t.Errorf("there were unfulfilled expectations: %s", err)
 	}
 }
+
+func TestApplyMigrationsWithCountOutOfOrder(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableApplyMigration = func(
+		db *sql.DB, f database.FileMigration, c string, d direction.MigrateDirection,
+		h database.Hooks, o database.Observer,
+	) error {
+		return nil
+	}
+
+	var receivedApplied []database.AppliedMigration
+	mockableFilterMigrationsByCount = func(c uint, a bool, d direction.MigrateDirection,
+		f []database.FileMigration, app []database.AppliedMigration, apps []string) (
+		[]database.FileMigration, error,
+	) {
+		receivedApplied = app
+		return []database.FileMigration{}, nil
+	}
+
+	firstTime, _ := time.Parse(time.RFC3339, "2020-06-13T17:17:44.371Z")
+	secondTime, _ := time.Parse(time.RFC3339, "2020-06-14T17:17:44.371Z")
+	appliedMigrations := []database.AppliedMigration{
+		{ID: "1", AppliedAt: firstTime}, {ID: "2", AppliedAt: secondTime},
+		{ID: "3", AppliedAt: firstTime},
+	}
+
+	pg := Postgres{}
+	pg.fileMigrations = []database.FileMigration{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	pg.appliedMigrations = appliedMigrations
+	pg.SetAllowOutOfOrder(true)
+	if err := pg.ApplyMigrationsWithCount(1, false, direction.Down, database.NoObserver); err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+
+	expectedApplied := []database.AppliedMigration{
+		appliedMigrations[0], appliedMigrations[2], appliedMigrations[1],
+	}
+	if diff := pretty.Compare(expectedApplied, receivedApplied); diff != "" {
+		t.Errorf("Did not pass the applied migrations in apply order:\n%s", diff)
+	}
+}
+
+func TestBaseline(t *testing.T) {
+	defer resetMockVariables()
+
+	var receivedMigrations []database.FileMigration
+	var receivedChangelog string
+	mockableInsertBaselineToChangelog = func(
+		db *sql.DB, m []database.FileMigration, c string,
+	) error {
+		receivedMigrations = m
+		receivedChangelog = c
+		return nil
+	}
+
+	pg := Postgres{}
+	pg.fileMigrations = []database.FileMigration{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+	pg.appliedMigrations = []database.AppliedMigration{}
+	if err := pg.Baseline("2"); err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+
+	expectedMigrations := []database.FileMigration{{ID: "1"}, {ID: "2"}}
+	if diff := pretty.Compare(expectedMigrations, receivedMigrations); diff != "" {
+		t.Errorf("Did not pass the right migrations for the baseline:\n%s", diff)
+	}
+	if receivedChangelog != changelogTable {
+		t.Errorf("Expected changelogtable '%s', but got %s", changelogTable, receivedChangelog)
+	}
+}
+
+func TestBaselineNonEmptyChangelog(t *testing.T) {
+	defer resetMockVariables()
+
+	var insertCalled bool
+	mockableInsertBaselineToChangelog = func(
+		db *sql.DB, m []database.FileMigration, c string,
+	) error {
+		insertCalled = true
+		return nil
+	}
+
+	pg := Postgres{}
+	pg.fileMigrations = []database.FileMigration{{ID: "1"}, {ID: "2"}}
+	pg.appliedMigrations = []database.AppliedMigration{{ID: "1"}}
+	if err := pg.Baseline("2"); err == nil {
+		t.Errorf("Expected an error for a non empty changelog, but got nothing")
+	}
+	if insertCalled {
+		t.Errorf("Expected no baseline to be inserted")
+	}
+}
+
+func TestFindAndMarkMigration(t *testing.T) {
+	defer resetMockVariables()
+
+	expectedMigration := database.FileMigration{ID: "expected"}
+	var filterText string
+	mockableFilterMigrationsByText = func(fi string, d direction.MigrateDirection,
+		f []database.FileMigration, a []database.AppliedMigration, apps []string,
+	) (database.FileMigration, error) {
+		filterText = fi
+		return expectedMigration, nil
+	}
+
+	var markedMigration database.FileMigration
+	var markedChangelog, markedAudit string
+	mockableMarkMigration = func(db *sql.DB, m database.FileMigration,
+		d direction.MigrateDirection, c string, a string,
+	) error {
+		markedMigration = m
+		markedChangelog = c
+		markedAudit = a
+		return nil
+	}
+
+	pg := Postgres{}
+	pg.fileMigrations = []database.FileMigration{}
+	pg.appliedMigrations = []database.AppliedMigration{}
+	migration, err := pg.FindMigration("sth", direction.Up)
+	if err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	if filterText != "sth" {
+		t.Errorf("Expected filter 'sth', but got %s", filterText)
+	}
+
+	if err := pg.MarkMigration(migration, direction.Up); err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	if markedMigration.ID != expectedMigration.ID {
+		t.Errorf("Expected to mark %v, but got %v", expectedMigration, markedMigration)
+	}
+	if markedChangelog != changelogTable || markedAudit != auditTable {
+		t.Errorf("Expected tables '%s' and '%s', but got '%s' and '%s'",
+			changelogTable, auditTable, markedChangelog, markedAudit)
+	}
+}
+
+func TestApplyRepeatableMigrations(t *testing.T) {
+	defer resetMockVariables()
+
+	repeatables := []database.RepeatableMigration{
+		{Application: "a", Filename: "R_new.sql", Checksum: "1"},
+		{Application: "a", Filename: "R_same.sql", Checksum: "2"},
+		{Application: "b", Filename: "R_other_app.sql", Checksum: "3"},
+	}
+	mockableGetRepeatableMigrations = func(p string, v map[string]string) (
+		[]database.RepeatableMigration, error,
+	) {
+		return repeatables, nil
+	}
+	var ensuredTable string
+	mockableEnsureRepeatableChangelog = func(db *sql.DB, table string) error {
+		ensuredTable = table
+		return nil
+	}
+	mockableGetAppliedChecksums = func(db *sql.DB, table string) (map[string]string, error) {
+		return map[string]string{"a/R_same.sql": "2"}, nil
+	}
+	var appliedRepeatables []database.RepeatableMigration
+	mockableApplyRepeatableMigration = func(
+		db *sql.DB, m database.RepeatableMigration, table string,
+	) error {
+		appliedRepeatables = append(appliedRepeatables, m)
+		return nil
+	}
+	mockableGetFileMigrations = func(p string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{{ID: "1", Application: "a"}}, nil
+	}
+	mockableGetAppliedMigrations = func(db *sql.DB, cl string) (
+		[]database.AppliedMigration, error,
+	) {
+		return []database.AppliedMigration{{ID: "1"}}, nil
+	}
+
+	var events []database.Event
+	observer := database.ObserverFunc(func(event database.Event) {
+		events = append(events, event)
+	})
+
+	pg := Postgres{}
+	pg.SetApplications([]string{"a"})
+	applied, err := pg.ApplyRepeatableMigrations(observer)
+	if err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	if applied != 1 {
+		t.Errorf("Expected one applied repeatable migration, but got %d", applied)
+	}
+	if ensuredTable != repeatableChangelogTable {
+		t.Errorf("Expected table '%s', but got '%s'", repeatableChangelogTable, ensuredTable)
+	}
+	if diff := pretty.Compare(repeatables[:1], appliedRepeatables); diff != "" {
+		t.Errorf("Did not apply the changed repeatable migrations:\n%s", diff)
+	}
+	if len(events) != 1 || events[0].Type != database.RepeatableApplied ||
+		events[0].File != "a/R_new.sql" {
+		t.Errorf("Expected the applied repeatable migration as event, but got %v", events)
+	}
+}
+
+func TestFindRepeatableMigrations(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableGetRepeatableMigrations = func(p string, v map[string]string) (
+		[]database.RepeatableMigration, error,
+	) {
+		return []database.RepeatableMigration{
+			{Application: "a", Filename: "R_new.sql", Checksum: "1"},
+			{Application: "a", Filename: "R_same.sql", Checksum: "2"},
+		}, nil
+	}
+	mockableEnsureRepeatableChangelog = func(db *sql.DB, table string) error {
+		t.Errorf("Expected the repeatable changelog not to be created")
+		return nil
+	}
+	mockableRepeatableChangelogExists = func(db *sql.DB, table string) (bool, error) {
+		return true, nil
+	}
+	mockableGetAppliedChecksums = func(db *sql.DB, table string) (map[string]string, error) {
+		return map[string]string{"a/R_same.sql": "2"}, nil
+	}
+
+	pg := Postgres{}
+	repeatables, err := pg.FindRepeatableMigrations()
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	expected := []database.RepeatableMigration{
+		{Application: "a", Filename: "R_new.sql", Checksum: "1"},
+	}
+	if diff := pretty.Compare(repeatables, expected); diff != "" {
+		t.Errorf("Did not return the changed repeatables: (-got +want)\n%s", diff)
+	}
+
+	// without a changelog all repeatables are new
+	mockableRepeatableChangelogExists = func(db *sql.DB, table string) (bool, error) {
+		return false, nil
+	}
+	mockableGetAppliedChecksums = func(db *sql.DB, table string) (map[string]string, error) {
+		t.Errorf("Expected the missing repeatable changelog not to be read")
+		return nil, nil
+	}
+	if repeatables, _ = pg.FindRepeatableMigrations(); len(repeatables) != 2 {
+		t.Errorf("Expected 2 new repeatables, but got %v", repeatables)
+	}
+}
+
+func TestApplyRepeatableMigrationsPending(t *testing.T) {
+	defer resetMockVariables()
+
+	var loadedRepeatables bool
+	mockableGetRepeatableMigrations = func(p string, v map[string]string) (
+		[]database.RepeatableMigration, error,
+	) {
+		loadedRepeatables = true
+		return nil, nil
+	}
+	mockableGetFileMigrations = func(p string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{{ID: "1"}, {ID: "2"}}, nil
+	}
+	mockableGetAppliedMigrations = func(db *sql.DB, cl string) (
+		[]database.AppliedMigration, error,
+	) {
+		return []database.AppliedMigration{{ID: "1"}}, nil
+	}
+
+	pg := Postgres{}
+	_, err := pg.ApplyRepeatableMigrations(database.NoObserver)
+	if !errors.Is(err, database.ErrPendingMigrations) {
+		t.Errorf("Expected ErrPendingMigrations, but got %v", err)
+	}
+	if loadedRepeatables {
+		t.Errorf("Expected the repeatable migrations not to be loaded")
+	}
+}
+
+func TestApplyDataSeeds(t *testing.T) {
+	defer resetMockVariables()
+
+	seeds := []database.DataSeed{{Filename: "01_users.sql"}, {Filename: "02_orders.sql"}}
+	var seedsEnvironment string
+	mockableGetDataSeeds = func(p, env string, v map[string]string) ([]database.DataSeed, error) {
+		seedsEnvironment = env
+		return seeds, nil
+	}
+	var ensuredTable string
+	mockableEnsureSeedsChangelog = func(db *sql.DB, table string) error {
+		ensuredTable = table
+		return nil
+	}
+	mockableGetAppliedSeeds = func(db *sql.DB, table string) (map[string]bool, error) {
+		return map[string]bool{"01_users.sql": true}, nil
+	}
+	var appliedSeeds []database.DataSeed
+	mockableApplyDataSeed = func(db *sql.DB, s database.DataSeed, table string) error {
+		appliedSeeds = append(appliedSeeds, s)
+		return nil
+	}
+	mockableGetFileMigrations = func(p string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{{ID: "1"}}, nil
+	}
+	mockableGetAppliedMigrations = func(db *sql.DB, cl string) (
+		[]database.AppliedMigration, error,
+	) {
+		return []database.AppliedMigration{{ID: "1"}}, nil
+	}
+
+	var events []database.Event
+	observer := database.ObserverFunc(func(event database.Event) {
+		events = append(events, event)
+	})
+
+	pg := Postgres{}
+	pg.config.Environment = "staging"
+	applied, err := pg.ApplyDataSeeds(observer)
+	if err != nil {
+		t.Errorf("Expected no error, but got %v", err)
+	}
+	if applied != 1 {
+		t.Errorf("Expected one applied seed, but got %d", applied)
+	}
+	if seedsEnvironment != "staging" || ensuredTable != seedsChangelogTable {
+		t.Errorf("Expected seeds of staging in '%s', but got %s in '%s'",
+			seedsChangelogTable, seedsEnvironment, ensuredTable)
+	}
+	if diff := pretty.Compare(seeds[1:], appliedSeeds); diff != "" {
+		t.Errorf("Did not apply the pending seeds:\n%s", diff)
+	}
+	if len(events) != 1 || events[0].Type != database.SeedApplied ||
+		events[0].File != "02_orders.sql" {
+		t.Errorf("Expected the applied seed as event, but got %v", events)
+	}
+}
+
+func TestApplyDataSeedsFailed(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableGetDataSeeds = func(p, env string, v map[string]string) ([]database.DataSeed, error) {
+		return []database.DataSeed{{Filename: "01_users.sql"}}, nil
+	}
+	mockableEnsureSeedsChangelog = func(db *sql.DB, table string) error { return nil }
+	mockableGetAppliedSeeds = func(db *sql.DB, table string) (map[string]bool, error) {
+		return map[string]bool{}, nil
+	}
+	mockableApplyDataSeed = func(db *sql.DB, s database.DataSeed, table string) error {
+		return fmt.Errorf("Some error")
+	}
+	mockableGetFileMigrations = func(p string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{}, nil
+	}
+	mockableGetAppliedMigrations = func(db *sql.DB, cl string) (
+		[]database.AppliedMigration, error,
+	) {
+		return []database.AppliedMigration{}, nil
+	}
+	var events []database.Event
+	observer := database.ObserverFunc(func(event database.Event) {
+		events = append(events, event)
+	})
+
+	pg := Postgres{}
+	if _, err := pg.ApplyDataSeeds(observer); err == nil {
+		t.Errorf("Expected an error for the failed seed")
+	}
+	if len(events) != 1 || events[0].Type != database.SeedFailed || events[0].Err == nil {
+		t.Errorf("Expected the failed seed as event, but got %v", events)
+	}
+}
+
+func TestApplyDataSeedsPending(t *testing.T) {
+	defer resetMockVariables()
+
+	mockableGetFileMigrations = func(p string, v map[string]string) ([]database.FileMigration, error) {
+		return []database.FileMigration{{ID: "1"}, {ID: "2"}}, nil
+	}
+	mockableGetAppliedMigrations = func(db *sql.DB, cl string) (
+		[]database.AppliedMigration, error,
+	) {
+		return []database.AppliedMigration{{ID: "1"}}, nil
+	}
+
+	pg := Postgres{}
+	_, err := pg.ApplyDataSeeds(database.NoObserver)
+	if !errors.Is(err, database.ErrPendingMigrations) {
+		t.Errorf("Expected ErrPendingMigrations, but got %v", err)
+	}
+}
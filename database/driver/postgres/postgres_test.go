@@ -5,6 +5,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -17,11 +18,28 @@ func resetMockVariables() {
 	mockableBootstrap = database.ApplyBootstrapMigration
 	mockableEnsureConsistentMigrations = database.EnsureConsistentMigrations
 	mockableGetFileMigrations = database.GetFileMigrations
-	mockableGetAppliedMigrations = database.GetAppliedMigrations
+	mockableGetAppliedMigrations = getAppliedMigrations
+	mockableGetChangelogState = getChangelogState
 	mockableApplyMigration = database.ApplyMigration
 	mockableFilterMigrationsByText = database.FilterMigrationsByText
 	mockableFilterMigrationsByCount = database.FilterMigrationsByCount
 	mockableGetBootstrapSQL = database.GetBootstrapSQL
+	mockableFilterMigrationsUpToID = database.FilterMigrationsUpToID
+	mockableInsertBaselineToChangelog = database.InsertBaselineToChangelog
+	mockableMarkMigration = database.MarkMigration
+	mockableSquashMigrations = database.SquashMigrations
+	mockableSquashChangelog = database.SquashChangelog
+	mockableGetRepeatableMigrations = database.GetRepeatableMigrations
+	mockableEnsureRepeatableChangelog = database.EnsureRepeatableChangelog
+	mockableRepeatableChangelogExists = database.RepeatableChangelogExists
+	mockableGetAppliedChecksums = database.GetAppliedChecksums
+	mockableApplyRepeatableMigration = database.ApplyRepeatableMigration
+	mockableGetDataSeeds = database.GetDataSeeds
+	mockableEnsureSeedsChangelog = database.EnsureSeedsChangelog
+	mockableGetAppliedSeeds = database.GetAppliedSeeds
+	mockableApplyDataSeed = database.ApplyDataSeed
+	mockableGetHooks = database.GetHooks
+	mockableNow = time.Now
 }
 
 func TestMain(m *testing.M) {
@@ -0,0 +1,69 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// FileErrorKind classifies the problems of migration files
+type FileErrorKind string
+
+// All kinds of problems found while loading migration files
+const (
+	KindBadName       FileErrorKind = "bad name"
+	KindUnreadable    FileErrorKind = "unreadable"
+	KindMissingUndo   FileErrorKind = "missing undo"
+	KindEmptyUp       FileErrorKind = "empty up"
+	KindEmptyDown     FileErrorKind = "empty down"
+	KindMissingVerify FileErrorKind = "missing verify"
+	KindEmptyVerify   FileErrorKind = "empty verify"
+	KindDuplicateID   FileErrorKind = "duplicate id"
+	KindTemplate      FileErrorKind = "template"
+	KindBadTimeout    FileErrorKind = "bad timeout"
+)
+
+// FileError is a problem of a single migration file.
+// Line is 0 if the problem is not related to a line
+type FileError struct {
+	Path    string
+	Kind    FileErrorKind
+	Line    int
+	Message string
+}
+
+func (e *FileError) Error() string {
+	location := e.Path
+	if e.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, e.Line)
+	}
+	return fmt.Sprintf("%s: [%s] %s", location, e.Kind, e.Message)
+}
+
+// FileErrors collects all problems found while loading the migration files
+type FileErrors []*FileError
+
+func (e FileErrors) Error() string {
+	messages := []string{}
+	for _, fileError := range e {
+		messages = append(messages, fileError.Error())
+	}
+	return fmt.Sprintf(
+		"Found %d problems in the migration files:\n%s", len(e), strings.Join(messages, "\n"),
+	)
+}
+
+func (e *FileErrors) add(
+	path string, kind FileErrorKind, line int, message string, args ...interface{},
+) {
+	*e = append(*e, &FileError{
+		Path: path, Kind: kind, Line: line, Message: fmt.Sprintf(message, args...),
+	})
+}
+
+// errorOrNil avoids returning an empty (but non nil) error interface
+func (e FileErrors) errorOrNil() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e
+}
@@ -9,10 +9,20 @@ import (
 	"time"
 )
 
+// SkippedFolders are the special folders within the migrations path, which are no applications
+var SkippedFolders = map[string]bool{
+	"_environments": true, ArchiveFolder: true, SeedsFolder: true, HooksFolder: true,
+}
+
 // GetFileMigrations gets all migration files within the database/migration folder's subfolders
 // it returns a list of FileMigrations sorted (ascending) by the ID.
-func GetFileMigrations(migrationFolder string) (migrations []FileMigration, err error) {
-	skippedFolders := map[string]bool{"_environments": true}
+// Files marked as template are rendered with the vars and the registered Go migrations
+// (see RegisterGoMigration) are merged into the list.
+// Problems of the migration files are collected and returned together as FileErrors
+func GetFileMigrations(
+	migrationFolder string, vars map[string]string,
+) (migrations []FileMigration, err error) {
+	errs := FileErrors{}
 	fileMigrations := map[string]FileMigration{}
 
 	apps, err := ioutil.ReadDir(migrationFolder)
@@ -22,7 +32,7 @@ func GetFileMigrations(migrationFolder string) (migrations []FileMigration, err
 		)
 	}
 	for _, app := range apps {
-		if skippedFolders[app.Name()] || !app.IsDir() {
+		if SkippedFolders[app.Name()] || !app.IsDir() {
 			continue
 		}
 
@@ -34,42 +44,105 @@ func GetFileMigrations(migrationFolder string) (migrations []FileMigration, err
 		}
 
 		for _, migFile := range migFiles {
-			if migFile.IsDir() {
+			if migFile.IsDir() || IsRepeatable(migFile.Name()) {
 				continue
 			}
 
+			migPath := filepath.Join(migrationFolder, app.Name(), migFile.Name())
 			mig := FileMigration{}
-			err = mig.LoadFromFile(filepath.Join(migrationFolder, app.Name(), migFile.Name()))
-			if err != nil {
-				return nil, err
+			if err := mig.LoadFromFile(migPath, vars); err != nil {
+				fileErrs, ok := err.(FileErrors)
+				if !ok {
+					return nil, err
+				}
+				errs = append(errs, fileErrs...)
+			}
+			if mig.ID == "" {
+				continue
 			}
 
 			if prevMig, alreadyExists := fileMigrations[mig.ID]; alreadyExists {
-				return nil, fmt.Errorf(
-					"The id %s is not unique. It exists for %s and %s",
+				errs.add(
+					migPath, KindDuplicateID, 0, "The id %s is not unique. It exists for %s and %s",
 					mig.ID, prevMig.Filename, mig.Filename,
 				)
+				continue
 			}
 			fileMigrations[mig.ID] = mig
 		}
 
 	}
+	for _, mig := range registeredGoMigrations() {
+		if prevMig, alreadyExists := fileMigrations[mig.ID]; alreadyExists {
+			errs.add(
+				mig.Filename, KindDuplicateID, 0, "The id %s is not unique. It exists for %s and %s",
+				mig.ID, prevMig.Filename, mig.Filename,
+			)
+			continue
+		}
+		fileMigrations[mig.ID] = mig
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
 
 	for _, fileMigration := range fileMigrations {
 		migrations = append(migrations, fileMigration)
 	}
 	sort.Slice(migrations, func(i, j int) bool { return migrations[i].ID < migrations[j].ID })
+
+	if err := ResolveRequirements(migrations); err != nil {
+		return nil, err
+	}
 	return migrations, nil
 }
 
+// FilterApplications returns the migrations belonging to one of the given applications.
+// Without any applications all migrations are returned
+func FilterApplications(migrations []FileMigration, applications []string) []FileMigration {
+	if len(applications) == 0 {
+		return migrations
+	}
+
+	appLookup := map[string]bool{}
+	for _, app := range applications {
+		appLookup[app] = true
+	}
+
+	filtered := []FileMigration{}
+	for _, mig := range migrations {
+		if appLookup[mig.Application] {
+			filtered = append(filtered, mig)
+		}
+	}
+	return filtered
+}
+
 // GetAppliedMigrations gets all applied migrations from the changelog (sorted by ID)
 func GetAppliedMigrations(db *sql.DB, changelogTable string) (
 	migrations []AppliedMigration, err error,
 ) {
-	rows, err := db.Query(fmt.Sprintf(
-		`SELECT id, name, applied_at FROM %s ORDER BY id ASC`,
+	return queryAppliedMigrations(db, fmt.Sprintf(
+		`SELECT id, name, applied_at, baseline FROM %s ORDER BY id ASC`,
 		changelogTable,
 	))
+}
+
+// GetAppliedMigrationsWithoutBaseline gets all applied migrations from a changelog created by an
+// older version without the baseline column (sorted by ID)
+func GetAppliedMigrationsWithoutBaseline(db *sql.DB, changelogTable string) (
+	migrations []AppliedMigration, err error,
+) {
+	return queryAppliedMigrations(db, fmt.Sprintf(
+		`SELECT id, name, applied_at, false FROM %s ORDER BY id ASC`,
+		changelogTable,
+	))
+}
+
+func queryAppliedMigrations(db *sql.DB, query string) (
+	migrations []AppliedMigration, err error,
+) {
+	rows, err := db.Query(query)
 	if err != nil {
 		return nil, fmt.Errorf("Got error getting applied migrations: %v", err)
 	}
@@ -77,10 +150,13 @@ func GetAppliedMigrations(db *sql.DB, changelogTable string) (
 	for rows.Next() {
 		var id, name string
 		var appliedAt time.Time
-		if err := rows.Scan(&id, &name, &appliedAt); err != nil {
+		var baseline bool
+		if err := rows.Scan(&id, &name, &appliedAt, &baseline); err != nil {
 			return nil, fmt.Errorf("Error scanning row for applied migrations: %v", err)
 		}
-		migrations = append(migrations, AppliedMigration{ID: id, Name: name, AppliedAt: appliedAt})
+		migrations = append(migrations, AppliedMigration{
+			ID: id, Name: name, AppliedAt: appliedAt, Baseline: baseline,
+		})
 	}
 	if err := rows.Err(); err != nil {
 		return nil, fmt.Errorf("Error after row iteration for getting applied migrations: %v", err)
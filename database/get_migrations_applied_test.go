@@ -11,21 +11,21 @@ import (
 
 func TestGetAppliedMigrations(t *testing.T) {
 	db, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
-	mockRows := sqlmock.NewRows([]string{"id", "name", "applied_at"})
+	mockRows := sqlmock.NewRows([]string{"id", "name", "applied_at", "baseline"})
 
 	time1, _ := time.Parse(time.RFC3339, "2014-11-12T11:45:26.371Z")
 	time2, _ := time.Parse(time.RFC3339, "2015-12-11T10:46:23.378Z")
 	expectedMigrations := []AppliedMigration{
 		{ID: "20171101000001", Name: "foo", AppliedAt: time1},
-		{ID: "20171101000002", Name: "bar", AppliedAt: time2},
+		{ID: "20171101000002", Name: "bar", AppliedAt: time2, Baseline: true},
 	}
 
 	for _, mig := range expectedMigrations {
-		mockRows.AddRow(mig.ID, mig.Name, mig.AppliedAt)
+		mockRows.AddRow(mig.ID, mig.Name, mig.AppliedAt, mig.Baseline)
 	}
 
 	mock.ExpectQuery(dedent.Dedent(`
-		SELECT id, name, applied_at
+		SELECT id, name, applied_at, baseline
 		FROM schema.changelog
 		ORDER BY id ASC
 	`)).WillReturnRows(mockRows)
@@ -1,6 +1,7 @@
 package database
 
 import (
+	"errors"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -21,7 +22,7 @@ func TestGetFileMigrations(t *testing.T) {
 		saveMigrationFor(basePath, "a_other_app", "20171101000002_bar.sql"),
 	}
 
-	gotMigrations, err := GetFileMigrations(basePath)
+	gotMigrations, err := GetFileMigrations(basePath, nil)
 	if err != nil {
 		t.Fatalf("Got an error loading migrations: %v", err)
 	}
@@ -41,7 +42,7 @@ func TestIgnoreFilesWithoutApps(t *testing.T) {
 
 	ioutil.WriteFile(filepath.Join(basePath, "foo.sql"), []byte("foo"), 0777)
 
-	gotMigrations, err := GetFileMigrations(basePath)
+	gotMigrations, err := GetFileMigrations(basePath, nil)
 	if err != nil {
 		t.Fatalf("Got an error loading migrations: %v", err)
 	}
@@ -63,7 +64,7 @@ func TestIgnoreFoldersInApps(t *testing.T) {
 		filepath.Join(basePath, "some_app", "some_folder", "bar.sql"), []byte("bar"), 0777,
 	)
 
-	gotMigrations, err := GetFileMigrations(basePath)
+	gotMigrations, err := GetFileMigrations(basePath, nil)
 	if err != nil {
 		t.Fatalf("Got an error loading migrations: %v", err)
 	}
@@ -83,7 +84,7 @@ func TestIgnoreEnvironments(t *testing.T) {
 	os.Mkdir(filepath.Join(basePath, "_environments"), 0777)
 	ioutil.WriteFile(filepath.Join(basePath, "_environments", "some_env.yaml"), []byte("1"), 0777)
 
-	gotMigrations, err := GetFileMigrations(basePath)
+	gotMigrations, err := GetFileMigrations(basePath, nil)
 	if err != nil {
 		t.Fatalf("Got an error loading migrations: %v", err)
 	}
@@ -103,12 +104,68 @@ func TestNoDuplicateIDs(t *testing.T) {
 	saveMigrationFor(basePath, "my_app", "20171101000001_foo.sql")
 	saveMigrationFor(basePath, "other_app", "20171101000001_bar.sql")
 
-	_, err = GetFileMigrations(basePath)
+	_, err = GetFileMigrations(basePath, nil)
 	if err == nil {
 		t.Fatal("Got no error with duplicate IDs")
 	}
 }
 
+func TestCollectAllFileErrors(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	saveMigrationFor(basePath, "my_app", "20171101000001_foo.sql")
+	ioutil.WriteFile(filepath.Join(basePath, "my_app", "bad_name.sql"), []byte("foo"), 0777)
+	saveMigrationFor(basePath, "other_app", "20171101000001_bar.sql")
+	saveMigrationFor(basePath, "other_app", "20171101000002_baz.sql")
+	os.Remove(filepath.Join(basePath, "other_app", "verify", "20171101000002_baz.sql"))
+	ioutil.WriteFile(
+		filepath.Join(basePath, "other_app", "20171101000002_baz.sql"),
+		[]byte("\n-- //@UNDO\nSELECT 1;"), 0777,
+	)
+
+	_, err = GetFileMigrations(basePath, nil)
+	var fileErrors FileErrors
+	if !errors.As(err, &fileErrors) {
+		t.Fatalf("Expected FileErrors, but got: %v", err)
+	}
+
+	kinds := map[string]FileErrorKind{}
+	for _, fileError := range fileErrors {
+		kinds[filepath.Base(fileError.Path)+" "+string(fileError.Kind)] = fileError.Kind
+	}
+	expectedKinds := map[string]FileErrorKind{
+		"bad_name.sql bad name":                 KindBadName,
+		"20171101000001_bar.sql duplicate id":   KindDuplicateID,
+		"20171101000002_baz.sql empty up":       KindEmptyUp,
+		"20171101000002_baz.sql missing verify": KindMissingVerify,
+	}
+	if diff := pretty.Compare(expectedKinds, kinds); diff != "" {
+		t.Errorf("Did not collect all problems:\n%s", diff)
+	}
+}
+
+func TestFilterApplications(t *testing.T) {
+	migrations := []FileMigration{
+		{ID: "1", Application: "common"},
+		{ID: "2", Application: "sub_app"},
+		{ID: "3", Application: "other"},
+	}
+
+	if diff := pretty.Compare(migrations, FilterApplications(migrations, nil)); diff != "" {
+		t.Errorf("Expected all migrations without applications:\n%s", diff)
+	}
+
+	expected := []FileMigration{migrations[0], migrations[2]}
+	filtered := FilterApplications(migrations, []string{"other", "common"})
+	if diff := pretty.Compare(expected, filtered); diff != "" {
+		t.Errorf("Did not filter the applications correctly:\n%s", diff)
+	}
+}
+
 func saveMigrationFor(basePath, application, migrationName string) FileMigration {
 	os.Mkdir(filepath.Join(basePath, application), 0777)
 	os.Mkdir(filepath.Join(basePath, application, "verify"), 0777)
@@ -0,0 +1,68 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+var goDescriptionRegex = regexp.MustCompile(`^\w+$`)
+
+// GoMigrationFunc is the up or down part of a migration written in Go.
+// It is executed within the transaction of the migration
+type GoMigrationFunc func(ctx context.Context, tx *sql.Tx) error
+
+// goMigrations contains the registered Go migrations by ID
+var goMigrations = map[string]FileMigration{}
+
+// RegisterGoMigration registers a migration written in Go. The ID has to be unique across the Go
+// and file migrations. The Go migrations are merged with the file migrations by
+// GetFileMigrations and applied in ID order like every other migration
+func RegisterGoMigration(id, application, description string, up, down GoMigrationFunc) error {
+	if !migrationIDRegex.MatchString(id) {
+		return fmt.Errorf("The id %s of the Go migration is not a 14 digit id", id)
+	}
+	if !goDescriptionRegex.MatchString(description) || application == "" {
+		return fmt.Errorf(
+			"The Go migration %s requires an application and a description of word characters",
+			id,
+		)
+	}
+	if up == nil || down == nil {
+		return fmt.Errorf("The Go migration %s requires an up and a down function", id)
+	}
+	if prevMig, exists := goMigrations[id]; exists {
+		return fmt.Errorf("The id %s is already registered for %s", id, prevMig.Filename)
+	}
+
+	goMigrations[id] = FileMigration{
+		ID:          id,
+		Description: description,
+		Filename:    fmt.Sprintf("%s_%s.go", id, description),
+		Application: application,
+		UpFunc:      up,
+		DownFunc:    down,
+	}
+	return nil
+}
+
+// registeredGoMigrations returns the registered Go migrations sorted by ID
+func registeredGoMigrations() []FileMigration {
+	migrations := []FileMigration{}
+	for _, mig := range goMigrations {
+		migrations = append(migrations, mig)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].ID < migrations[j].ID })
+	return migrations
+}
+
+// execMigration executes the Go function of a migration if it has one, otherwise the SQL
+func execMigration(tx *sql.Tx, sqlText string, goFunc GoMigrationFunc) error {
+	if goFunc != nil {
+		return goFunc(context.Background(), tx)
+	}
+	_, err := tx.Exec(sqlText)
+	return err
+}
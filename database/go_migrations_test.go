@@ -0,0 +1,125 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func noopGoMigration(ctx context.Context, tx *sql.Tx) error {
+	return nil
+}
+
+func TestRegisterGoMigrationInvalid(t *testing.T) {
+	defer func() { goMigrations = map[string]FileMigration{} }()
+
+	if err := RegisterGoMigration(
+		"20200101000001", "common", "seed_users", noopGoMigration, noopGoMigration,
+	); err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	invalidRegistrations := []struct {
+		name, id, application, description string
+		up, down                           GoMigrationFunc
+	}{
+		{"invalid id", "2020", "common", "foo", noopGoMigration, noopGoMigration},
+		{"no application", "20200101000002", "", "foo", noopGoMigration, noopGoMigration},
+		{"invalid description", "20200101000002", "common", "foo bar", noopGoMigration, nil},
+		{"missing down", "20200101000002", "common", "foo", noopGoMigration, nil},
+		{"duplicate id", "20200101000001", "common", "foo", noopGoMigration, noopGoMigration},
+	}
+	for _, reg := range invalidRegistrations {
+		t.Run(reg.name, func(t *testing.T) {
+			err := RegisterGoMigration(reg.id, reg.application, reg.description, reg.up, reg.down)
+			if err == nil {
+				t.Errorf("Expected an error")
+			}
+		})
+	}
+}
+
+func TestGetFileMigrationsWithGoMigrations(t *testing.T) {
+	defer func() { goMigrations = map[string]FileMigration{} }()
+	basePath, _ := ioutil.TempDir("", "go_mig")
+	defer os.RemoveAll(basePath)
+
+	saveMigrationFor(basePath, "common", "20200101000001_foo.sql")
+	saveMigrationFor(basePath, "common", "20200101000003_bar.sql")
+	RegisterGoMigration(
+		"20200101000002", "common", "seed_users", noopGoMigration, noopGoMigration,
+	)
+
+	gotMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil {
+		t.Fatalf("Got an error loading migrations: %v", err)
+	}
+	if len(gotMigrations) != 3 {
+		t.Fatalf("Expected 3 migrations, but got %d", len(gotMigrations))
+	}
+	goMig := gotMigrations[1]
+	if !goMig.IsGo() || goMig.Filename != "20200101000002_seed_users.go" {
+		t.Errorf("Expected the Go migration in the middle, but got %s", goMig.Filename)
+	}
+
+	RegisterGoMigration(
+		"20200101000003", "common", "duplicate", noopGoMigration, noopGoMigration,
+	)
+	_, err = GetFileMigrations(basePath, nil)
+	fileErrs, ok := err.(FileErrors)
+	if !ok || len(fileErrs) != 1 || fileErrs[0].Kind != KindDuplicateID {
+		t.Errorf("Expected a duplicate id error, but got: %v", err)
+	}
+}
+
+func TestApplyUpSQLGoMigration(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	migration := FileMigration{
+		ID: "1", Description: "a",
+		UpFunc: func(ctx context.Context, tx *sql.Tx) error {
+			_, err := tx.ExecContext(ctx, "SELECT 2")
+			return err
+		},
+		DownFunc: noopGoMigration,
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT 2").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := ApplyUpSQL(db, migration); err != nil {
+		t.Errorf("Expected no error, but got: %s", err)
+	}
+	if err := ApplyVerify(db, migration); err != nil {
+		t.Errorf("Expected no verify for a Go migration, but got: %s", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestApplyDownSQLGoMigrationError(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	migration := FileMigration{
+		ID: "1", Description: "a", UpFunc: noopGoMigration,
+		DownFunc: func(ctx context.Context, tx *sql.Tx) error {
+			return sql.ErrNoRows
+		},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectRollback()
+
+	if err := ApplyDownSQL(db, migration); err == nil {
+		t.Errorf("Expected error, but got nothing")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -0,0 +1,308 @@
+package database
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database/config"
+	"go-migrations/internal/direction"
+)
+
+var mockableRunCommand = runCommand
+
+// HooksFolder is the folder within the migrations path containing the hooks of all environments
+// (_hooks/<point>[_<name>].sql or .sh, e.g. after_up_refresh_views.sql)
+const HooksFolder = "_hooks"
+
+// DefaultHookTimeout limits the hooks without a timeout
+const DefaultHookTimeout = 5 * time.Minute
+
+var hookNameRegex = regexp.MustCompile(
+	`^((?:before|after)(?:_each)?_(?:up|down))(_\w+)?\.(sql|sh)$`,
+)
+var hookTimeoutRegex = regexp.MustCompile(`(?m)^(?:--|#) //@TIMEOUT[ \t]+(\S+)[ \t]*$`)
+
+// HookSettingsSQL sets the migration of a hook as settings of its transaction, which the hook SQL
+// reads with current_setting('migrations.migration_id') (bound parameters: id, app, direction)
+var HookSettingsSQL = "SELECT set_config('migrations.migration_id', $1, true), " +
+	"set_config('migrations.app', $2, true), set_config('migrations.direction', $3, true)"
+
+// HookPoint is the point of the migrations a hook runs at
+type HookPoint string
+
+// All points hooks can run at. The before and after hooks run once around all migrations of a
+// command, the each hooks around every single migration
+const (
+	BeforeUp       HookPoint = "before_up"
+	BeforeEachUp   HookPoint = "before_each_up"
+	AfterEachUp    HookPoint = "after_each_up"
+	AfterUp        HookPoint = "after_up"
+	BeforeDown     HookPoint = "before_down"
+	BeforeEachDown HookPoint = "before_each_down"
+	AfterEachDown  HookPoint = "after_each_down"
+	AfterDown      HookPoint = "after_down"
+)
+
+// hookPoints are the points of a direction in the order they are run in
+var hookPoints = map[direction.MigrateDirection]struct {
+	before, beforeEach, afterEach, after HookPoint
+}{
+	direction.Up:   {BeforeUp, BeforeEachUp, AfterEachUp, AfterUp},
+	direction.Down: {BeforeDown, BeforeEachDown, AfterEachDown, AfterDown},
+}
+
+// Hook is SQL or a shell command run at a point of the migrations
+type Hook struct {
+	// Name identifies the hook in events and errors (its file or its entry in the configuration)
+	Name    string
+	Point   HookPoint
+	SQL     string
+	Command string
+	Timeout time.Duration
+}
+
+// Hooks are the hooks of an environment. Shell hooks run in the migrations path with the
+// additional environment variables Env
+type Hooks struct {
+	List    []Hook
+	WorkDir string
+	Env     []string
+}
+
+// GetHooks loads the hooks of the hooks folder (sorted by filename) followed by the hooks of the
+// environment configuration. SQL files marked as template are rendered with the vars.
+// A "-- //@TIMEOUT 10m" (or "# //@TIMEOUT 10m") line sets the timeout of a hook file
+func GetHooks(cfg config.Config) (hooks Hooks, err error) {
+	hooks.WorkDir = cfg.MigrationsPath
+	hooks.Env = []string{
+		"DATABASE_URL=" + cfg.DSN(), "MIGRATIONS_ENVIRONMENT=" + cfg.Environment,
+	}
+
+	hooksPath := filepath.Join(cfg.MigrationsPath, HooksFolder)
+	hookFiles, err := ioutil.ReadDir(hooksPath)
+	if err != nil && !os.IsNotExist(err) {
+		return hooks, fmt.Errorf(
+			"Could not read content of hooks folder %s - Err: %v", hooksPath, err,
+		)
+	}
+
+	errs := FileErrors{}
+	for _, hookFile := range hookFiles {
+		if hookFile.IsDir() {
+			continue
+		}
+		hook, err := loadHook(filepath.Join(hooksPath, hookFile.Name()), cfg.Vars)
+		if err != nil {
+			errs = append(errs, err.(FileErrors)...)
+			continue
+		}
+		hooks.List = append(hooks.List, hook)
+	}
+	if len(errs) > 0 {
+		return hooks, errs
+	}
+
+	// the points are sorted to load the hooks in a stable order
+	points := []string{}
+	for point := range cfg.Hooks {
+		points = append(points, point)
+	}
+	sort.Strings(points)
+	for _, point := range points {
+		if !isHookPoint(HookPoint(point)) {
+			return hooks, fmt.Errorf("Unknown hook point %s in the configuration", point)
+		}
+		for idx, configHook := range cfg.Hooks[point] {
+			hooks.List = append(hooks.List, Hook{
+				Name:  fmt.Sprintf("%s #%d of %s.yaml", point, idx+1, cfg.Environment),
+				Point: HookPoint(point), SQL: configHook.SQL, Command: configHook.Command,
+				Timeout: configHook.Timeout,
+			})
+		}
+	}
+	return hooks, nil
+}
+
+func isHookPoint(point HookPoint) bool {
+	for _, points := range hookPoints {
+		if point == points.before || point == points.beforeEach ||
+			point == points.afterEach || point == points.after {
+			return true
+		}
+	}
+	return false
+}
+
+func loadHook(hookPath string, vars map[string]string) (hook Hook, err error) {
+	filename := filepath.Base(hookPath)
+	hook.Name = filepath.Join(HooksFolder, filename)
+
+	errs := FileErrors{}
+	match := hookNameRegex.FindStringSubmatch(filename)
+	if match == nil {
+		errs.add(hookPath, KindBadName, 0, "The hook file name was invalid")
+		return hook, errs
+	}
+	hook.Point = HookPoint(match[1])
+
+	content, err := ioutil.ReadFile(hookPath)
+	if err != nil {
+		errs.add(hookPath, KindUnreadable, 0, "Couldn't read hook file: %v", err)
+		return hook, errs
+	}
+	if timeout := hookTimeoutRegex.FindStringSubmatch(string(content)); timeout != nil {
+		hook.Timeout, err = time.ParseDuration(timeout[1])
+		if err != nil {
+			errs.add(hookPath, KindBadTimeout, 0, "Couldn't parse the timeout: %v", err)
+			return hook, errs
+		}
+	}
+
+	if match[3] == "sh" {
+		hook.Command = string(content)
+		return hook, nil
+	}
+	rendered, err := RenderTemplate(filename, string(content), vars)
+	if err != nil {
+		errs.add(hookPath, KindTemplate, 0, "%v", err)
+		return hook, errs
+	}
+	hook.SQL = strings.Trim(strings.Trim(rendered, "\n"), " ")
+	if hook.SQL == "" {
+		errs.add(hookPath, KindEmptyUp, 1, "The hook was empty")
+		return hook, errs
+	}
+	return hook, nil
+}
+
+// RunBefore runs the hooks before all migrations of the direction
+func (hooks Hooks) RunBefore(db *sql.DB, dir direction.MigrateDirection, observer Observer) error {
+	return hooks.run(db, hookPoints[dir].before, FileMigration{}, dir, observer)
+}
+
+// RunAfter runs the hooks after all migrations of the direction
+func (hooks Hooks) RunAfter(db *sql.DB, dir direction.MigrateDirection, observer Observer) error {
+	return hooks.run(db, hookPoints[dir].after, FileMigration{}, dir, observer)
+}
+
+// run runs the hooks of the point in order and stops at the first failed hook.
+// The observer is notified about every finished or failed hook
+func (hooks Hooks) run(
+	db *sql.DB, point HookPoint, migration FileMigration, dir direction.MigrateDirection,
+	observer Observer,
+) error {
+	for _, hook := range hooks.List {
+		if hook.Point != point {
+			continue
+		}
+
+		start := time.Now()
+		err := hooks.runHook(db, hook, migration, dir)
+		event := Event{
+			Type: HookFinished, Direction: dir, Migration: migration, Hook: hook.Name,
+			Duration: time.Since(start), Err: err,
+		}
+		if err != nil {
+			event.Type = HookFailed
+			observer.Notify(event)
+			return fmt.Errorf("Hook %s failed: %v", hook.Name, err)
+		}
+		observer.Notify(event)
+	}
+	return nil
+}
+
+func (hooks Hooks) runHook(
+	db *sql.DB, hook Hook, migration FileMigration, dir direction.MigrateDirection,
+) (err error) {
+	timeout := hook.Timeout
+	if timeout == 0 {
+		timeout = DefaultHookTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	defer func() {
+		if err != nil && ctx.Err() == context.DeadlineExceeded {
+			err = fmt.Errorf("Timed out after %v", timeout)
+		}
+	}()
+
+	if hook.SQL != "" {
+		return execHookSQL(ctx, db, hook.SQL, migration, dir)
+	}
+
+	cmd := exec.Command("sh", "-c", hook.Command)
+	cmd.Dir = hooks.WorkDir
+	cmd.Env = append(append(os.Environ(), hooks.Env...),
+		"MIGRATION_ID="+migration.ID, "MIGRATION_APP="+migration.Application,
+		"MIGRATION_DIRECTION="+dir.Name(), "HOOK_POINT="+string(hook.Point),
+	)
+	stdout, stderr, err := mockableRunCommand(ctx, cmd)
+	if strings.TrimSpace(stdout) != "" {
+		log.Debugf("Output of hook %s: %s", hook.Name, strings.TrimSpace(stdout))
+	}
+	if err != nil {
+		return fmt.Errorf("%v %s", err, strings.TrimSpace(stderr))
+	}
+	return nil
+}
+
+// runCommand runs the command in its own process group and returns its output. When the context
+// is done, the whole group is killed, so children forked by the shell do not outlive the timeout
+func runCommand(ctx context.Context, cmd *exec.Cmd) (stdout, stderr string, err error) {
+	var stdoutBuffer, stderrBuffer bytes.Buffer
+	cmd.Stdout, cmd.Stderr = &stdoutBuffer, &stderrBuffer
+	setProcessGroup(cmd)
+	if err := cmd.Start(); err != nil {
+		return "", "", fmt.Errorf("Could not start cmd - %v", err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			killProcessGroup(cmd.Process)
+		case <-done:
+		}
+	}()
+
+	err = cmd.Wait()
+	return stdoutBuffer.String(), stderrBuffer.String(), err
+}
+
+// execHookSQL executes the SQL of a hook in a transaction with the settings of the migration.
+// The settings end with the transaction, so they do not leak into the session
+func execHookSQL(
+	ctx context.Context, db *sql.DB, hookSQL string, migration FileMigration,
+	dir direction.MigrateDirection,
+) error {
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("Could not begin a transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(
+		ctx, HookSettingsSQL, migration.ID, migration.Application, dir.Name(),
+	)
+	if err != nil {
+		return fmt.Errorf("Could not set the migration settings: %v", err)
+	}
+	if _, err = tx.ExecContext(ctx, hookSQL); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
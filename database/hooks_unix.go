@@ -0,0 +1,19 @@
+//go:build !windows
+// +build !windows
+
+package database
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+func setProcessGroup(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+}
+
+// killProcessGroup kills the process and all processes of its group
+func killProcessGroup(process *os.Process) {
+	syscall.Kill(-process.Pid, syscall.SIGKILL)
+}
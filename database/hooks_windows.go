@@ -0,0 +1,14 @@
+package database
+
+import (
+	"os"
+	"os/exec"
+)
+
+// setProcessGroup does nothing, as processes are not grouped on windows
+func setProcessGroup(cmd *exec.Cmd) {}
+
+// killProcessGroup only kills the process itself
+func killProcessGroup(process *os.Process) {
+	process.Kill()
+}
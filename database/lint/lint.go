@@ -0,0 +1,320 @@
+package lint
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"go-migrations/database"
+)
+
+// Rules lists all available rules with a short description
+var Rules = map[string]string{
+	"naming":              "migration files are named <14 digit id>_<description>.sql or R_<name>.sql",
+	"verify":              "every migration has a non-empty verify file",
+	"undo":                "every migration has an up and a down part separated by -- //@UNDO",
+	"duplicate-id":        "migration ids are unique across all applications",
+	"drop-table":          "DROP TABLE is used with IF EXISTS",
+	"add-column-not-null": "columns added as NOT NULL have a default",
+	"create-index":        "indexes on large tables are created CONCURRENTLY",
+	"truncate":            "TRUNCATE is not used",
+}
+
+var (
+	commentRegex     = regexp.MustCompile(`--[^\n]*`)
+	dropTableRegex   = regexp.MustCompile(`(?is)^DROP\s+TABLE\b`)
+	dropIfExistRegex = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+IF\s+EXISTS\b`)
+	alterTableRegex  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\b`)
+	addClauseRegex   = regexp.MustCompile(`(?is)\bADD\s+(?:COLUMN\s+)?([^,]*)`)
+	addOtherRegex    = regexp.MustCompile(`(?is)^(CONSTRAINT|PRIMARY|UNIQUE|FOREIGN|CHECK)\b`)
+	notNullRegex     = regexp.MustCompile(`(?is)\bNOT\s+NULL\b`)
+	defaultRegex     = regexp.MustCompile(`(?is)\bDEFAULT\b`)
+	createIndexRegex = regexp.MustCompile(
+		`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(CONCURRENTLY\s+)?.*?\bON\s+(?:ONLY\s+)?([\w."]+)`,
+	)
+	truncateRegex = regexp.MustCompile(`(?is)^TRUNCATE\b`)
+)
+
+// Finding is a problem found in a migration file
+type Finding struct {
+	Rule        string `json:"rule"`
+	Application string `json:"application"`
+	File        string `json:"file"`
+	Line        int    `json:"line,omitempty"`
+	Message     string `json:"message"`
+}
+
+func (f Finding) String() string {
+	location := fmt.Sprintf("%s/%s", f.Application, f.File)
+	if f.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, f.Line)
+	}
+	return fmt.Sprintf("%s: [%s] %s", location, f.Rule, f.Message)
+}
+
+// Config configures the linter. It is read from a YAML file like:
+//
+//	disabled:
+//	  - truncate
+//	large_tables:
+//	  - public.events
+//
+// Without large tables the create-index rule applies to all tables
+type Config struct {
+	Disabled    []string `yaml:"disabled"`
+	LargeTables []string `yaml:"large_tables"`
+}
+
+// LoadConfig reads the lint configuration. A missing file results in the default configuration,
+// unless it is required (e.g. because it was given explicitly)
+func LoadConfig(path string, required bool) (config Config, err error) {
+	content, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) && !required {
+		return config, nil
+	} else if err != nil {
+		return config, fmt.Errorf("Couldn't read lint config file: %v", err)
+	}
+
+	if err := yaml.UnmarshalStrict(content, &config); err != nil {
+		return config, fmt.Errorf("Couldn't unmarshal yaml: %v", err)
+	}
+	return config, config.validate()
+}
+
+func (config Config) validate() error {
+	for _, rule := range config.Disabled {
+		if _, exists := Rules[rule]; !exists {
+			return fmt.Errorf("Unknown lint rule: %s", rule)
+		}
+	}
+	return nil
+}
+
+// Lint checks all migration files within the migrations path without connecting to a database.
+// The findings are sorted by application, file and line
+func Lint(migrationsPath string, config Config) (findings []Finding, err error) {
+	if err := config.validate(); err != nil {
+		return nil, err
+	}
+	linter := linter{
+		disabled:    map[string]bool{},
+		largeTables: map[string]bool{},
+		ids:         map[string]string{},
+	}
+	for _, rule := range config.Disabled {
+		linter.disabled[rule] = true
+	}
+	for _, table := range config.LargeTables {
+		linter.largeTables[strings.ToLower(table)] = true
+	}
+
+	apps, err := ioutil.ReadDir(migrationsPath)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Could not read content of migrationFolder %s - Err: %v", migrationsPath, err,
+		)
+	}
+	for _, app := range apps {
+		if database.SkippedFolders[app.Name()] || !app.IsDir() {
+			continue
+		}
+
+		appPath := filepath.Join(migrationsPath, app.Name())
+		migFiles, err := ioutil.ReadDir(appPath)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Could not read content of appFolder %s - Err: %v", app.Name(), err,
+			)
+		}
+		for _, migFile := range migFiles {
+			if migFile.IsDir() {
+				continue
+			}
+			if err := linter.lintFile(appPath, app.Name(), migFile.Name()); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	sort.SliceStable(linter.findings, func(i, j int) bool {
+		a, b := linter.findings[i], linter.findings[j]
+		if a.Application != b.Application {
+			return a.Application < b.Application
+		}
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		return a.Line < b.Line
+	})
+	return linter.findings, nil
+}
+
+// WriteText writes one line per finding
+func WriteText(w io.Writer, findings []Finding) error {
+	for _, finding := range findings {
+		if _, err := fmt.Fprintln(w, finding.String()); err != nil {
+			return fmt.Errorf("Could not write the lint findings: %v", err)
+		}
+	}
+	return nil
+}
+
+// WriteJSON writes the findings as a JSON array
+func WriteJSON(w io.Writer, findings []Finding) error {
+	if findings == nil {
+		findings = []Finding{}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(findings); err != nil {
+		return fmt.Errorf("Could not write the lint findings: %v", err)
+	}
+	return nil
+}
+
+type linter struct {
+	disabled    map[string]bool
+	largeTables map[string]bool
+	// ids maps the IDs to the first application / file they were found in
+	ids      map[string]string
+	findings []Finding
+}
+
+func (l *linter) report(rule, app, file string, line int, message string, args ...interface{}) {
+	if l.disabled[rule] {
+		return
+	}
+	l.findings = append(l.findings, Finding{
+		Rule: rule, Application: app, File: file, Line: line,
+		Message: fmt.Sprintf(message, args...),
+	})
+}
+
+func (l *linter) lintFile(appPath, app, file string) error {
+	if database.IsRepeatable(file) {
+		return l.lintRepeatable(appPath, app, file)
+	}
+	if !database.MigrationNameRegex.MatchString(file) {
+		l.report("naming", app, file, 0, "The filename is not of the form <id>_<description>.sql")
+		return nil
+	}
+
+	id := file[:14]
+	if previous, exists := l.ids[id]; exists {
+		l.report("duplicate-id", app, file, 0, "The id %s is already used by %s", id, previous)
+	} else {
+		l.ids[id] = fmt.Sprintf("%s/%s", app, file)
+	}
+
+	verify, err := ioutil.ReadFile(filepath.Join(appPath, "verify", file))
+	if os.IsNotExist(err) {
+		l.report("verify", app, file, 0, "The verify file is missing")
+	} else if err != nil {
+		return fmt.Errorf("Couldn't read verify file: %v", err)
+	} else if strings.TrimSpace(string(verify)) == "" {
+		l.report("verify", app, file, 0, "The verify file is empty")
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(appPath, file))
+	if err != nil {
+		return fmt.Errorf("Couldn't read migration file: %v", err)
+	}
+	upDown := strings.Split(string(content), "\n-- //@UNDO\n")
+	if len(upDown) != 2 {
+		l.report(
+			"undo", app, file, 0, "Found %d -- //@UNDO separators instead of one", len(upDown)-1,
+		)
+	} else {
+		if strings.TrimSpace(commentRegex.ReplaceAllString(upDown[0], "")) == "" {
+			l.report("undo", app, file, 0, "The up migration is empty")
+		}
+		if strings.TrimSpace(commentRegex.ReplaceAllString(upDown[1], "")) == "" {
+			l.report("undo", app, file, 0, "The down migration is empty")
+		}
+	}
+
+	// dangerous patterns are only checked in the up migration, the down migration is
+	// expected to remove things
+	l.lintStatements(app, file, upDown[0])
+	return nil
+}
+
+// lintRepeatable checks repeatable migrations, which have neither a down part nor a verify file
+func (l *linter) lintRepeatable(appPath, app, file string) error {
+	if !database.RepeatableNameRegex.MatchString(file) {
+		l.report("naming", app, file, 0, "The filename is not of the form R_<name>.sql")
+		return nil
+	}
+
+	content, err := ioutil.ReadFile(filepath.Join(appPath, file))
+	if err != nil {
+		return fmt.Errorf("Couldn't read migration file: %v", err)
+	}
+	if strings.TrimSpace(commentRegex.ReplaceAllString(string(content), "")) == "" {
+		l.report("undo", app, file, 0, "The repeatable migration is empty")
+	}
+	l.lintStatements(app, file, string(content))
+	return nil
+}
+
+func (l *linter) lintStatements(app, file, upSQL string) {
+	line := 1
+	for _, statement := range strings.Split(commentRegex.ReplaceAllString(upSQL, ""), ";") {
+		trimmed := strings.TrimLeft(statement, " \t\r\n")
+		statementLine := line + strings.Count(statement[:len(statement)-len(trimmed)], "\n")
+		line += strings.Count(statement, "\n")
+		trimmed = strings.TrimSpace(trimmed)
+
+		if dropTableRegex.MatchString(trimmed) && !dropIfExistRegex.MatchString(trimmed) {
+			l.report("drop-table", app, file, statementLine, "DROP TABLE without IF EXISTS")
+		}
+
+		if alterTableRegex.MatchString(trimmed) {
+			for _, match := range addClauseRegex.FindAllStringSubmatch(trimmed, -1) {
+				clause := match[1]
+				if addOtherRegex.MatchString(clause) {
+					continue
+				}
+				if notNullRegex.MatchString(clause) && !defaultRegex.MatchString(clause) {
+					l.report(
+						"add-column-not-null", app, file, statementLine,
+						"ADD COLUMN ... NOT NULL without a DEFAULT",
+					)
+				}
+			}
+		}
+
+		if match := createIndexRegex.FindStringSubmatch(trimmed); match != nil {
+			if match[1] == "" && l.isLargeTable(match[2]) {
+				l.report(
+					"create-index", app, file, statementLine,
+					"CREATE INDEX on %s without CONCURRENTLY", match[2],
+				)
+			}
+		}
+
+		if truncateRegex.MatchString(trimmed) {
+			l.report("truncate", app, file, statementLine, "TRUNCATE removes all data")
+		}
+	}
+}
+
+func (l *linter) isLargeTable(table string) bool {
+	if len(l.largeTables) == 0 {
+		return true
+	}
+	table = strings.ToLower(strings.ReplaceAll(table, `"`, ""))
+	if l.largeTables[table] {
+		return true
+	}
+	parts := strings.Split(table, ".")
+	return l.largeTables[parts[len(parts)-1]]
+}
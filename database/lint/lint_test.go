@@ -0,0 +1,193 @@
+package lint
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func saveFile(t *testing.T, path, content string) {
+	if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+		t.Fatalf("Could not create folder: %v", err)
+	}
+	if err := ioutil.WriteFile(path, []byte(content), 0777); err != nil {
+		t.Fatalf("Could not write file: %v", err)
+	}
+}
+
+func setupMigrations(t *testing.T) string {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+
+	saveFile(t, filepath.Join(basePath, "_environments", "dev.yaml"), "not linted")
+	saveFile(t, filepath.Join(basePath, "common", "20171101000001_ok.sql"),
+		"CREATE TABLE a (id INT);\n-- //@UNDO\nDROP TABLE a;\n")
+	saveFile(t, filepath.Join(basePath, "common", "verify", "20171101000001_ok.sql"), "SELECT 1")
+
+	saveFile(t, filepath.Join(basePath, "common", "20171101000002_danger.sql"), strings.Join([]string{
+		"-- DROP TABLE in a comment is fine",
+		"DROP TABLE b;",
+		"DROP TABLE IF EXISTS c;",
+		"ALTER TABLE a",
+		"  ADD COLUMN x INT NOT NULL,",
+		"  ADD COLUMN y INT NOT NULL DEFAULT 1,",
+		"  ADD CONSTRAINT z CHECK (y IS NOT NULL);",
+		"CREATE INDEX idx_a ON public.a (x);",
+		"CREATE INDEX CONCURRENTLY idx_a2 ON public.a (y);",
+		"CREATE INDEX idx_small ON small (x);",
+		"truncate a;",
+		"-- //@UNDO",
+		"SELECT 1;",
+	}, "\n"))
+	saveFile(t, filepath.Join(basePath, "common", "verify", "20171101000002_danger.sql"), "\n")
+
+	saveFile(t, filepath.Join(basePath, "common", "R_views.sql"),
+		"CREATE OR REPLACE VIEW v AS SELECT 1;\n")
+	saveFile(t, filepath.Join(basePath, "sub_app", "20171101000001_dup.sql"), "SELECT 1;\n")
+	saveFile(t, filepath.Join(basePath, "sub_app", "bad_name.sql"), "SELECT 1;\n")
+	return basePath
+}
+
+func TestLint(t *testing.T) {
+	basePath := setupMigrations(t)
+	defer os.RemoveAll(basePath)
+
+	findings, err := Lint(basePath, Config{LargeTables: []string{"a"}})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	danger := "20171101000002_danger.sql"
+	expected := []Finding{
+		{Rule: "verify", Application: "common", File: danger, Message: "The verify file is empty"},
+		{Rule: "drop-table", Application: "common", File: danger, Line: 2,
+			Message: "DROP TABLE without IF EXISTS"},
+		{Rule: "add-column-not-null", Application: "common", File: danger, Line: 4,
+			Message: "ADD COLUMN ... NOT NULL without a DEFAULT"},
+		{Rule: "create-index", Application: "common", File: danger, Line: 8,
+			Message: "CREATE INDEX on public.a without CONCURRENTLY"},
+		{Rule: "truncate", Application: "common", File: danger, Line: 11,
+			Message: "TRUNCATE removes all data"},
+		{Rule: "duplicate-id", Application: "sub_app", File: "20171101000001_dup.sql",
+			Message: "The id 20171101000001 is already used by common/20171101000001_ok.sql"},
+		{Rule: "verify", Application: "sub_app", File: "20171101000001_dup.sql",
+			Message: "The verify file is missing"},
+		{Rule: "undo", Application: "sub_app", File: "20171101000001_dup.sql",
+			Message: "Found 0 -- //@UNDO separators instead of one"},
+		{Rule: "naming", Application: "sub_app", File: "bad_name.sql",
+			Message: "The filename is not of the form <id>_<description>.sql"},
+	}
+	if diff := pretty.Compare(expected, findings); diff != "" {
+		t.Errorf("Did not find the expected problems:\n%s", diff)
+	}
+}
+
+func TestLintNaming(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	// the loader and lint share the pattern, so quotes and unicode are fine but whitespace is not
+	for _, filename := range []string{
+		"20171101000001_fix_user's_table.sql", "20171101000002_größe_ändern.sql",
+		"20171101000003_foo bar.sql",
+	} {
+		saveFile(t, filepath.Join(basePath, "common", filename), "SELECT 1;\n-- //@UNDO\nSELECT 1;\n")
+		saveFile(t, filepath.Join(basePath, "common", "verify", filename), "SELECT 1;\n")
+	}
+
+	findings, err := Lint(basePath, Config{})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	expected := []Finding{
+		{Rule: "naming", Application: "common", File: "20171101000003_foo bar.sql",
+			Message: "The filename is not of the form <id>_<description>.sql"},
+	}
+	if diff := pretty.Compare(expected, findings); diff != "" {
+		t.Errorf("Did not find the expected problems:\n%s", diff)
+	}
+}
+
+func TestLintDisabledRules(t *testing.T) {
+	basePath := setupMigrations(t)
+	defer os.RemoveAll(basePath)
+
+	findings, err := Lint(basePath, Config{
+		Disabled: []string{"verify", "drop-table", "add-column-not-null", "truncate", "undo"},
+	})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	rules := []string{}
+	for _, finding := range findings {
+		rules = append(rules, finding.Rule)
+	}
+	// without large tables every index without CONCURRENTLY is reported
+	expected := []string{"create-index", "create-index", "duplicate-id", "naming"}
+	if diff := pretty.Compare(expected, rules); diff != "" {
+		t.Errorf("Did not respect the disabled rules:\n%s", diff)
+	}
+
+	if _, err := Lint(basePath, Config{Disabled: []string{"nope"}}); err == nil {
+		t.Errorf("Expected an error for an unknown rule")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	basePath, _ := ioutil.TempDir("", "go_mig")
+	defer os.RemoveAll(basePath)
+
+	config, err := LoadConfig(filepath.Join(basePath, "_lint.yaml"), false)
+	if err != nil {
+		t.Errorf("Expected no error for a missing config, but got: %v", err)
+	}
+	if diff := pretty.Compare(Config{}, config); diff != "" {
+		t.Errorf("Expected the default config:\n%s", diff)
+	}
+	if _, err := LoadConfig(filepath.Join(basePath, "_lint.yaml"), true); err == nil {
+		t.Errorf("Expected an error for a missing required config, but got nothing")
+	}
+
+	saveFile(t, filepath.Join(basePath, "_lint.yaml"),
+		"disabled:\n  - truncate\nlarge_tables:\n  - public.events\n")
+	config, err = LoadConfig(filepath.Join(basePath, "_lint.yaml"), true)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	expected := Config{Disabled: []string{"truncate"}, LargeTables: []string{"public.events"}}
+	if diff := pretty.Compare(expected, config); diff != "" {
+		t.Errorf("Did not load the config:\n%s", diff)
+	}
+}
+
+func TestWriteFindings(t *testing.T) {
+	findings := []Finding{
+		{Rule: "truncate", Application: "app", File: "1_a.sql", Line: 3, Message: "msg"},
+		{Rule: "naming", Application: "app", File: "b.sql", Message: "other"},
+	}
+
+	var text bytes.Buffer
+	WriteText(&text, findings)
+	expectedText := "app/1_a.sql:3: [truncate] msg\napp/b.sql: [naming] other\n"
+	if text.String() != expectedText {
+		t.Errorf("Expected text '%s', but got '%s'", expectedText, text.String())
+	}
+
+	var jsonOutput bytes.Buffer
+	WriteJSON(&jsonOutput, nil)
+	if strings.TrimSpace(jsonOutput.String()) != "[]" {
+		t.Errorf("Expected an empty JSON array, but got '%s'", jsonOutput.String())
+	}
+}
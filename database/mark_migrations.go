@@ -0,0 +1,87 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"os/user"
+
+	"github.com/lithammer/dedent"
+
+	"go-migrations/internal/direction"
+)
+
+var mockableCurrentUser = user.Current
+
+// CreateAuditSQL creates the audit trail of manually marked migrations if necessary
+var CreateAuditSQL = dedent.Dedent(`
+	CREATE TABLE IF NOT EXISTS %s (
+		  id VARCHAR(14) NOT NULL
+		, name TEXT NOT NULL
+		, action TEXT NOT NULL
+		, performed_by TEXT NOT NULL
+		, performed_at timestamptz NOT NULL
+	);
+`)
+
+// AuditInsertSQL records a manual action in the audit trail
+// (bound parameters: id, name, action, performed_by)
+var AuditInsertSQL = "INSERT INTO %s (id, name, action, performed_by, performed_at) " +
+	"VALUES ($1, $2, $3, $4, now())"
+
+// MarkAction returns the name of the audit action for marking a migration in a direction
+func MarkAction(dir direction.MigrateDirection) string {
+	if dir == direction.Down {
+		return "mark pending"
+	}
+	return "mark applied"
+}
+
+// MarkMigration adds (up) or removes (down) the migration to / from the changelog without
+// executing it. The action is recorded in the audit table within the same transaction
+func MarkMigration(
+	db *sql.DB, migration FileMigration, dir direction.MigrateDirection,
+	changelogTable, auditTable string,
+) error {
+	performedBy := "unknown"
+	if currentUser, err := mockableCurrentUser(); err == nil {
+		performedBy = currentUser.Username
+	}
+
+	changelogStatement := newStatement(
+		ChangelogInsertSQL, changelogTable, migration.ID, migration.Description,
+	)
+	if dir == direction.Down {
+		changelogStatement = newStatement(ChangelogDeleteSQL, changelogTable, migration.ID)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error opening transaction: %v", err)
+	}
+
+	statements := []statement{
+		newStatement(CreateAuditSQL, auditTable),
+		changelogStatement,
+		newStatement(
+			AuditInsertSQL, auditTable, migration.ID, migration.Description,
+			MarkAction(dir), performedBy,
+		),
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(statement.query, statement.args...); err != nil {
+			rollbackError := tx.Rollback()
+			if rollbackError != nil {
+				return fmt.Errorf(
+					"Could not %s %s: %v \n and rollback error: %v",
+					MarkAction(dir), migration.Filename, err, rollbackError,
+				)
+			}
+			return fmt.Errorf("Could not %s %s: %v", MarkAction(dir), migration.Filename, err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("Error during commit of %s: %v", MarkAction(dir), err)
+	}
+	return nil
+}
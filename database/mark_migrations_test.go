@@ -0,0 +1,93 @@
+package database
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"os/user"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lithammer/dedent"
+
+	"go-migrations/internal/direction"
+)
+
+func TestMarkMigration(t *testing.T) {
+	mockableCurrentUser = func() (*user.User, error) { return &user.User{Username: "jane"}, nil }
+	defer func() { mockableCurrentUser = user.Current }()
+
+	migration := FileMigration{ID: "1", Description: "hotfix", Filename: "1_hotfix.sql"}
+	createSQL := dedent.Dedent(`
+		CREATE TABLE IF NOT EXISTS audit (
+			  id VARCHAR(14) NOT NULL
+			, name TEXT NOT NULL
+			, action TEXT NOT NULL
+			, performed_by TEXT NOT NULL
+			, performed_at timestamptz NOT NULL
+		);
+	`)
+
+	testCases := []struct {
+		dir           direction.MigrateDirection
+		changelogSQL  string
+		changelogArgs []driver.Value
+		action        string
+	}{
+		{
+			dir:           direction.Up,
+			changelogSQL:  `INSERT INTO sth (id, name, applied_at) VALUES ($1, $2, now())`,
+			changelogArgs: []driver.Value{"1", "hotfix"},
+			action:        "mark applied",
+		},
+		{
+			dir:           direction.Down,
+			changelogSQL:  `DELETE FROM sth WHERE id = $1`,
+			changelogArgs: []driver.Value{"1"},
+			action:        "mark pending",
+		},
+	}
+
+	for _, testCase := range testCases {
+		db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+
+		mock.ExpectBegin()
+		mock.ExpectExec(createSQL).WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec(testCase.changelogSQL).
+			WithArgs(testCase.changelogArgs...).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectExec(
+			"INSERT INTO audit (id, name, action, performed_by, performed_at) "+
+				"VALUES ($1, $2, $3, $4, now())",
+		).
+			WithArgs("1", "hotfix", testCase.action, "jane").
+			WillReturnResult(sqlmock.NewResult(1, 1))
+		mock.ExpectCommit()
+
+		if err := MarkMigration(db, migration, testCase.dir, "sth", "audit"); err != nil {
+			t.Errorf("Expected no error, but got: %s", err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("there were unfulfilled expectations: %s", err)
+		}
+	}
+}
+
+func TestMarkMigrationError(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	migration := FileMigration{ID: "1", Description: "hotfix", Filename: "1_hotfix.sql"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(fmt.Sprintf(CreateAuditSQL, "audit")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(`DELETE FROM sth WHERE id = $1`).
+		WithArgs("1").
+		WillReturnError(fmt.Errorf("Some error"))
+	mock.ExpectRollback()
+
+	if err := MarkMigration(db, migration, direction.Down, "sth", "audit"); err == nil {
+		t.Errorf("Expected error, but got nothing")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"sort"
+	"time"
 
 	"github.com/jedib0t/go-pretty/v6/table"
 	"github.com/jedib0t/go-pretty/v6/text"
@@ -47,10 +48,11 @@ type MigrateStatusRow struct {
 var MigrateStatusHeader = []string{"ID", "Name", "Application", "Status", "Info"}
 
 // GetMigrationStatus returns the status of the current migrations
-// This is done by combining the information from the changelog and the local files
-func GetMigrationStatus(fileMigrations []FileMigration, appliedMigrations []AppliedMigration) (
-	rows []MigrateStatusRow, statusNote string, err error,
-) {
+// This is done by combining the information from the changelog and the local files.
+// With allowOutOfOrder gaps are not an inconsistency, but will be applied out of order
+func GetMigrationStatus(
+	fileMigrations []FileMigration, appliedMigrations []AppliedMigration, allowOutOfOrder bool,
+) (rows []MigrateStatusRow, statusNote string, err error) {
 	ids := map[string]bool{}
 	fileLookup := map[string]FileMigration{}
 	dbLookup := map[string]AppliedMigration{}
@@ -83,7 +85,11 @@ func GetMigrationStatus(fileMigrations []FileMigration, appliedMigrations []Appl
 
 		if applied {
 			timeString := dbLookup[id].AppliedAt.Format("2006-01-02 15:04:05")
-			row.Status = fmt.Sprintf("applied at %s UTC", timeString)
+			if dbLookup[id].Baseline {
+				row.Status = fmt.Sprintf("baseline at %s UTC", timeString)
+			} else {
+				row.Status = fmt.Sprintf("applied at %s UTC", timeString)
+			}
 		} else {
 			row.Status = "not applied"
 		}
@@ -95,11 +101,40 @@ func GetMigrationStatus(fileMigrations []FileMigration, appliedMigrations []Appl
 		return rows[i].ID < rows[j].ID
 	})
 
-	// we ignore the last entry as it cannot be inconsistent
-	for idx := 0; idx < len(rows)-1; idx++ {
-		if rows[idx].Status == "not applied" && rows[idx+1].Status != "not applied" {
-			inconsistentLog = true
-			rows[idx].Info = "Gap in migrations - inconsistency"
+	// gaps are judged per application as applications can be migrated independently
+	rowsByApp := map[string][]int{}
+	for idx, row := range rows {
+		rowsByApp[row.Application] = append(rowsByApp[row.Application], idx)
+	}
+	for _, appRows := range rowsByApp {
+		// we ignore the last entry as it cannot be inconsistent
+		for idx := 0; idx < len(appRows)-1; idx++ {
+			current, next := appRows[idx], appRows[idx+1]
+			if rows[current].Status == "not applied" && rows[next].Status != "not applied" {
+				if allowOutOfOrder {
+					rows[current].Info = "Will be applied out of order"
+				} else {
+					inconsistentLog = true
+					rows[current].Info = "Gap in migrations - inconsistency"
+				}
+			}
+		}
+
+		// a migration applied after a newer migration (of the same app) was applied out of order
+		var earliestNewer time.Time
+		for idx := len(appRows) - 1; idx >= 0; idx-- {
+			appliedMig, applied := dbLookup[rows[appRows[idx]].ID]
+			if !applied {
+				continue
+			}
+			if !earliestNewer.IsZero() && appliedMig.AppliedAt.After(earliestNewer) {
+				if rows[appRows[idx]].Info == "" {
+					rows[appRows[idx]].Info = "Applied out of order"
+				}
+			}
+			if earliestNewer.IsZero() || appliedMig.AppliedAt.Before(earliestNewer) {
+				earliestNewer = appliedMig.AppliedAt
+			}
 		}
 	}
 
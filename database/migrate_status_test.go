@@ -15,7 +15,7 @@ func TestGetMigrationStatus(t *testing.T) {
 	appliedTime, _ := time.Parse(time.RFC3339, "2020-06-13T17:17:44.371Z")
 	appliedMigrations := []AppliedMigration{{ID: "1", Name: "foo_bar", AppliedAt: appliedTime}}
 
-	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations)
+	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations, false)
 	if err != nil {
 		t.Errorf("Expected no error, but got: %v", err)
 	}
@@ -36,7 +36,7 @@ func TestGetMigrationStatusNotFoundLocally(t *testing.T) {
 	appliedTime, _ := time.Parse(time.RFC3339, "2020-06-13T17:17:44.371Z")
 	appliedMigrations := []AppliedMigration{{ID: "1", Name: "foo_bar", AppliedAt: appliedTime}}
 
-	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations)
+	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations, false)
 	if err != nil {
 		t.Errorf("Expected no error, but got: %v", err)
 	}
@@ -67,7 +67,7 @@ func TestGetMigrationStatusAppliedGap(t *testing.T) {
 		{ID: "3", Name: "three", AppliedAt: appliedTime},
 	}
 
-	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations)
+	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations, false)
 	if err != nil {
 		t.Errorf("Expected no error, but got: %v", err)
 	}
@@ -94,11 +94,81 @@ func TestGetMigrationStatusAppliedGap(t *testing.T) {
 	}
 }
 
+func TestGetMigrationStatusGapOtherApplication(t *testing.T) {
+	fileMigrations := []FileMigration{
+		{ID: "1", Application: "common", Description: "one"},
+		{ID: "2", Application: "sub_app", Description: "two"},
+		{ID: "3", Application: "common", Description: "three"},
+	}
+	appliedTime, _ := time.Parse(time.RFC3339, "2020-06-13T17:17:44.371Z")
+	appliedMigrations := []AppliedMigration{
+		{ID: "1", Name: "one", AppliedAt: appliedTime},
+		{ID: "3", Name: "three", AppliedAt: appliedTime},
+	}
+
+	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations, false)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if statusNote != "" {
+		t.Errorf("Expected empty statusNote but got: %s", statusNote)
+	}
+	if rows[1].Info != "" {
+		t.Errorf("Expected no info for the pending migration of sub_app but got: %s", rows[1].Info)
+	}
+}
+
+func TestGetMigrationStatusOutOfOrder(t *testing.T) {
+	fileMigrations := []FileMigration{
+		{ID: "1", Application: "common", Description: "one"},
+		{ID: "2", Application: "common", Description: "two"},
+		{ID: "3", Application: "common", Description: "three"},
+		{ID: "4", Application: "common", Description: "four"},
+	}
+	firstTime, _ := time.Parse(time.RFC3339, "2020-06-13T17:17:44.371Z")
+	secondTime, _ := time.Parse(time.RFC3339, "2020-06-14T17:17:44.371Z")
+	appliedMigrations := []AppliedMigration{
+		{ID: "1", Name: "one", AppliedAt: firstTime},
+		{ID: "2", Name: "two", AppliedAt: secondTime},
+		{ID: "4", Name: "four", AppliedAt: firstTime},
+	}
+
+	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations, true)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if statusNote != "" {
+		t.Errorf("Expected empty statusNote but got: %s", statusNote)
+	}
+	expectedInfos := []string{"", "Applied out of order", "Will be applied out of order", ""}
+	for idx, row := range rows {
+		if row.Info != expectedInfos[idx] {
+			t.Errorf("Expected info '%s' for %s, but got '%s'", expectedInfos[idx], row.ID, row.Info)
+		}
+	}
+}
+
+func TestGetMigrationStatusBaseline(t *testing.T) {
+	fileMigrations := []FileMigration{{ID: "1", Application: "buz", Description: "foo_bar"}}
+	appliedTime, _ := time.Parse(time.RFC3339, "2020-06-13T17:17:44.371Z")
+	appliedMigrations := []AppliedMigration{
+		{ID: "1", Name: "foo_bar", AppliedAt: appliedTime, Baseline: true},
+	}
+
+	rows, _, err := GetMigrationStatus(fileMigrations, appliedMigrations, false)
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if expected := "baseline at 2020-06-13 17:17:44 UTC"; rows[0].Status != expected {
+		t.Errorf("Expected status '%s', but got '%s'", expected, rows[0].Status)
+	}
+}
+
 func TestGetMigrationStatusEmpty(t *testing.T) {
 	appliedMigrations := []AppliedMigration{}
 	fileMigrations := []FileMigration{}
 
-	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations)
+	rows, statusNote, err := GetMigrationStatus(fileMigrations, appliedMigrations, false)
 	if err != nil {
 		t.Errorf("Expected no error, but got: %v", err)
 	}
@@ -1,7 +1,6 @@
 package database
 
 import (
-	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -10,6 +9,13 @@ import (
 	"time"
 )
 
+// MigrationNameRegex matches the filenames of migrations (<14 digit id>_<description>.sql).
+// The description may contain any characters besides whitespace (e.g. quotes or unicode)
+var MigrationNameRegex = regexp.MustCompile(`^\d{14}_\S+\.sql$`)
+
+var requiresRegex = regexp.MustCompile(`(?m)^-- //@REQUIRES[ \t]+(.+?)[ \t]*$`)
+var squashesRegex = regexp.MustCompile(`(?m)^-- //@SQUASHES[ \t]+(.+?)[ \t]*$`)
+
 // FileMigration is a struct around a local migration with all attached SQL files
 type FileMigration struct {
 	UpSQL       string
@@ -19,91 +25,144 @@ type FileMigration struct {
 	Description string
 	Filename    string
 	Application string
+	// Requires lists the migrations this migration depends on.
+	// After loading a single file it contains the references as written in the header,
+	// GetFileMigrations resolves them to migration IDs.
+	Requires []string
+	// Squashes lists the IDs of the migrations merged into this migration by "migrate squash"
+	Squashes []string
+	// Template is true if the migration was rendered as template (see RenderTemplate)
+	Template bool
+	// UpFunc and DownFunc are set for migrations written in Go (see RegisterGoMigration)
+	// instead of the SQL. Go migrations have no verify
+	UpFunc   GoMigrationFunc
+	DownFunc GoMigrationFunc
 }
 
-// LoadFromFile loads all properties based on the filepath of the migration itself
-func (mig *FileMigration) LoadFromFile(migrationPath string) error {
+// IsGo returns whether the migration is written in Go
+func (mig FileMigration) IsGo() bool {
+	return mig.UpFunc != nil
+}
+
+// LoadFromFile loads all properties based on the filepath of the migration itself.
+// Migration and verify files marked as template are rendered with the vars.
+// All problems of the migration are returned together as FileErrors
+func (mig *FileMigration) LoadFromFile(migrationPath string, vars map[string]string) error {
 	mig.Application = filepath.Base(filepath.Dir(migrationPath))
 	mig.Filename = filepath.Base(migrationPath)
 
-	validName := regexp.MustCompile(`^\d{14}_[\w]+\.sql$`).Match([]byte(mig.Filename))
-	if validName == false {
-		return fmt.Errorf("The migration file at '%s' was invalid", migrationPath)
-
+	errs := FileErrors{}
+	if !MigrationNameRegex.MatchString(mig.Filename) {
+		errs.add(migrationPath, KindBadName, 0, "The migration file name was invalid")
+		return errs
 	}
 
 	idMatch := regexp.MustCompile(`(^\d+)_`).FindStringSubmatch(mig.Filename)
 	if len(idMatch) < 2 {
-		return fmt.Errorf("Could not find id in filename '%s'", mig.Filename)
+		errs.add(migrationPath, KindBadName, 0, "Could not find id in filename")
+		return errs
 	}
 	mig.ID = idMatch[1]
 
 	runes := []rune(mig.Filename)
 	mig.Description = string(runes[15 : len(runes)-4])
 
-	if err := mig.loadMigration(migrationPath); err != nil {
-		return err
-	}
-	if err := mig.loadVerify(migrationPath); err != nil {
-		return err
-	}
+	mig.loadMigration(migrationPath, vars, &errs)
+	mig.loadVerify(migrationPath, vars, &errs)
 
-	return nil
+	return errs.errorOrNil()
 }
 
-func (mig *FileMigration) loadMigration(migrationPath string) error {
+func (mig *FileMigration) loadMigration(
+	migrationPath string, vars map[string]string, errs *FileErrors,
+) {
 
 	migrationFile, err := os.Open(migrationPath)
 	if err != nil {
-		return fmt.Errorf("Couldn't open migration file: %v", err)
+		errs.add(migrationPath, KindUnreadable, 0, "Couldn't open migration file: %v", err)
+		return
 	}
 	defer migrationFile.Close()
 	migration, err := ioutil.ReadAll(migrationFile)
 	if err != nil {
-		return fmt.Errorf("Couldn't read migration file: %v", err)
+		errs.add(migrationPath, KindUnreadable, 0, "Couldn't read migration file: %v", err)
+		return
 	}
 
 	if string(migration) == "" {
-		return fmt.Errorf("The migration at '%s' was empty", mig.Filename)
+		errs.add(migrationPath, KindEmptyUp, 1, "The migration was empty")
+		return
 	}
 
-	UpDownMigration := strings.Split(string(migration), "\n-- //@UNDO\n")
+	mig.Template = IsTemplate(string(migration))
+	rendered, err := RenderTemplate(mig.Filename, string(migration), vars)
+	if err != nil {
+		errs.add(migrationPath, KindTemplate, 0, "%v", err)
+		return
+	}
+
+	UpDownMigration := strings.Split(rendered, "\n-- //@UNDO\n")
 	if len(UpDownMigration) != 2 {
-		return fmt.Errorf("Could not find up and down migration in '%s'", mig.Filename)
+		errs.add(migrationPath, KindMissingUndo, 0, "Could not find up and down migration")
+		return
 	}
 
+	mig.Requires = parseHeaderReferences(requiresRegex, UpDownMigration[0])
+	mig.Squashes = parseHeaderReferences(squashesRegex, UpDownMigration[0])
+
 	mig.UpSQL = strings.Trim(strings.Trim(UpDownMigration[0], "\n"), " ")
 	if mig.UpSQL == "" {
-		return fmt.Errorf("The up migration at '%s' was empty", mig.Filename)
+		errs.add(migrationPath, KindEmptyUp, 1, "The up migration was empty")
 	}
 
 	mig.DownSQL = strings.Trim(strings.Trim(UpDownMigration[1], "\n"), " ")
 	if mig.DownSQL == "" {
-		return fmt.Errorf("The down migration at '%s' was empty", mig.Filename)
+		undoLine := strings.Count(UpDownMigration[0], "\n") + 2
+		errs.add(migrationPath, KindEmptyDown, undoLine, "The down migration was empty")
 	}
+}
 
-	return nil
+// parseHeaderReferences collects all references of header lines like
+// "-- //@REQUIRES <id or app/name>" or "-- //@SQUASHES <id>"
+func parseHeaderReferences(header *regexp.Regexp, upSQL string) (references []string) {
+	matches := header.FindAllStringSubmatch(upSQL, -1)
+	for _, match := range matches {
+		references = append(
+			references, strings.Fields(strings.ReplaceAll(match[1], ",", " "))...,
+		)
+	}
+	return references
 }
 
-func (mig *FileMigration) loadVerify(migrationPath string) error {
+func (mig *FileMigration) loadVerify(
+	migrationPath string, vars map[string]string, errs *FileErrors,
+) {
 	verifyPath := filepath.Join(
 		filepath.Dir(migrationPath), "verify", filepath.Base(migrationPath),
 	)
 	verifyFile, err := os.Open(verifyPath)
-	if err != nil {
-		return fmt.Errorf("Couldn't open verify file: %v", err)
+	if os.IsNotExist(err) {
+		errs.add(migrationPath, KindMissingVerify, 0, "Couldn't find verify file %s", verifyPath)
+		return
+	} else if err != nil {
+		errs.add(migrationPath, KindUnreadable, 0, "Couldn't open verify file: %v", err)
+		return
 	}
 	defer verifyFile.Close()
 	verify, err := ioutil.ReadAll(verifyFile)
 	if err != nil {
-		return fmt.Errorf("Couldn't read verify file: %v", err)
+		errs.add(migrationPath, KindUnreadable, 0, "Couldn't read verify file: %v", err)
+		return
+	}
+	rendered, err := RenderTemplate("verify/"+mig.Filename, string(verify), vars)
+	if err != nil {
+		errs.add(migrationPath, KindTemplate, 0, "Verify file: %v", err)
+		return
 	}
-	mig.VerifySQL = strings.Trim(string(verify), "\n")
+	mig.VerifySQL = strings.Trim(rendered, "\n")
 	if mig.VerifySQL == "" {
-		return fmt.Errorf("Verify file for %s was empty", migrationPath)
+		errs.add(migrationPath, KindEmptyVerify, 0, "Verify file %s was empty", verifyPath)
 	}
-
-	return nil
 }
 
 // AppliedMigration is a struct around a migration in the database / changelog table
@@ -111,4 +170,6 @@ type AppliedMigration struct {
 	ID        string
 	Name      string
 	AppliedAt time.Time
+	// Baseline is true for migrations marked as applied without being executed
+	Baseline bool
 }
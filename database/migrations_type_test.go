@@ -1,6 +1,7 @@
 package database
 
 import (
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -33,7 +34,7 @@ func TestLoadMigration(t *testing.T) {
 	)
 
 	migration := FileMigration{}
-	err := migration.LoadFromFile(filepath.Join(migrationPath, "_common", filename))
+	err := migration.LoadFromFile(filepath.Join(migrationPath, "_common", filename), nil)
 	if err != nil {
 		t.Errorf("Returned error loading migration: %v", err)
 	}
@@ -60,6 +61,7 @@ func TestInvalidFilenames(t *testing.T) {
 		"20171101000001_foo.pdf",
 		"2017_11_01_00_00_01_foo.sql",
 		"2017-11-01_00:00:01_foo.sql",
+		"20171101000001_foo bar.sql",
 	}
 	for _, filename := range filenames {
 		t.Run(filename, func(t *testing.T) {
@@ -67,13 +69,33 @@ func TestInvalidFilenames(t *testing.T) {
 			defer cleanup()
 
 			failingMigration := FileMigration{}
-			err := failingMigration.LoadFromFile(filepath.Join(appPath, filename))
+			err := failingMigration.LoadFromFile(filepath.Join(appPath, filename), nil)
 			if err == nil {
 				t.Errorf("Did not get an error for the invalid fileName '%s'", filename)
 			}
 		})
 	}
 }
+func TestLoadMigrationSpecialCharacters(t *testing.T) {
+	descriptions := []string{"fix_user's_table", "größe_ändern", `back\slash`}
+	for _, description := range descriptions {
+		t.Run(description, func(t *testing.T) {
+			filename := fmt.Sprintf("20171101000001_%s.sql", description)
+			appPath, cleanup := setupMigrationFor(t, filename)
+			defer cleanup()
+
+			migration := FileMigration{}
+			err := migration.LoadFromFile(filepath.Join(appPath, filename), nil)
+			if err != nil {
+				t.Errorf("Returned error loading migration: %v", err)
+			}
+			if migration.Description != description {
+				t.Errorf("Expected description '%s', but got '%s'", description, migration.Description)
+			}
+		})
+	}
+}
+
 func TestRequireVerifySqlFile(t *testing.T) {
 	filename := "20171101000001_foo.sql"
 	appPath, cleanup := setupMigrationFor(t, filename)
@@ -82,9 +104,9 @@ func TestRequireVerifySqlFile(t *testing.T) {
 	os.Remove(filepath.Join(appPath, "verify", filename))
 
 	failingMigration := FileMigration{}
-	err := failingMigration.LoadFromFile(filepath.Join(appPath, filename))
-	if err == nil {
-		t.Error("Did not get an error for a missing verify script")
+	err := failingMigration.LoadFromFile(filepath.Join(appPath, filename), nil)
+	if fileErrors, ok := err.(FileErrors); !ok || fileErrors[0].Kind != KindMissingVerify {
+		t.Errorf("Did not get a missing verify error, but got: %v", err)
 	}
 }
 
@@ -97,17 +119,22 @@ func TestRequireVerifySqlContent(t *testing.T) {
 	file.Truncate(0)
 
 	failingMigration := FileMigration{}
-	err := failingMigration.LoadFromFile(filepath.Join(appPath, filename))
-	if err == nil {
-		t.Error("Did not get an error for an empty verify script")
+	err := failingMigration.LoadFromFile(filepath.Join(appPath, filename), nil)
+	if fileErrors, ok := err.(FileErrors); !ok || fileErrors[0].Kind != KindEmptyVerify {
+		t.Errorf("Did not get an empty verify error, but got: %v", err)
 	}
 }
 
 func TestRequireMigrationContent(t *testing.T) {
-	var invalidContents = []struct{ name, migration string }{
-		{"empty migration", ""},
-		{"missing up", "\n-- //@UNDO\nDROP SCHEMA template;"},
-		{"missing down", "CREATE SCHEMA template;\n-- //@UNDO\n"},
+	var invalidContents = []struct {
+		name, migration string
+		kind            FileErrorKind
+		line            int
+	}{
+		{"empty migration", "", KindEmptyUp, 1},
+		{"missing undo", "CREATE SCHEMA template;", KindMissingUndo, 0},
+		{"missing up", "\n-- //@UNDO\nDROP SCHEMA template;", KindEmptyUp, 1},
+		{"missing down", "CREATE SCHEMA template;\n\n-- //@UNDO\n", KindEmptyDown, 3},
 	}
 	for _, content := range invalidContents {
 		t.Run(content.name, func(t *testing.T) {
@@ -120,9 +147,16 @@ func TestRequireMigrationContent(t *testing.T) {
 			ioutil.WriteFile(filepath.Join(appPath, filename), []byte(content.migration), 0777)
 
 			failingMigration := FileMigration{}
-			err := failingMigration.LoadFromFile(filepath.Join(appPath, filename))
-			if err == nil {
-				t.Errorf("Did not get an error for an invalid migration: %s", content.name)
+			err := failingMigration.LoadFromFile(filepath.Join(appPath, filename), nil)
+			fileErrors, ok := err.(FileErrors)
+			if !ok || len(fileErrors) != 1 {
+				t.Fatalf("Expected one FileError for %s, but got: %v", content.name, err)
+			}
+			if fileErrors[0].Kind != content.kind || fileErrors[0].Line != content.line {
+				t.Errorf(
+					"Expected a '%s' error at line %d, but got: %v",
+					content.kind, content.line, fileErrors[0],
+				)
 			}
 		})
 	}
@@ -163,3 +197,29 @@ func setupMigrationFor(t *testing.T, migrationName string) (
 
 	return basePath, cleanup
 }
+
+func TestLoadMigrationRequires(t *testing.T) {
+	filename := "20171101000002_foo.sql"
+	appPath, cleanup := setupMigrationFor(t, filename)
+	defer cleanup()
+
+	migrationSQL := []byte(dedent.Dedent(`
+		-- //@REQUIRES 20171101000001
+		-- //@REQUIRES common/base_schema, other/sth.sql
+		CREATE SCHEMA foo;
+		-- //@UNDO
+		DROP SCHEMA foo;
+	`))
+	ioutil.WriteFile(filepath.Join(appPath, filename), migrationSQL, 0777)
+
+	migration := FileMigration{}
+	err := migration.LoadFromFile(filepath.Join(appPath, filename), nil)
+	if err != nil {
+		t.Fatalf("Returned error loading migration: %v", err)
+	}
+
+	expectedRequires := []string{"20171101000001", "common/base_schema", "other/sth.sql"}
+	if diff := pretty.Compare(migration.Requires, expectedRequires); diff != "" {
+		t.Errorf("The requirements were not as expected:\n%s", diff)
+	}
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"time"
+
+	"go-migrations/internal/direction"
+)
+
+// EventType is the kind of an event sent to observers
+type EventType string
+
+const (
+	// RunStarted starts applying Event.Total migrations in Event.Direction
+	RunStarted EventType = "run_started"
+	// RunFinished ends the run (with Event.Err if it failed)
+	RunFinished EventType = "run_finished"
+	// MigrationStarted is sent before Event.Migration is applied
+	MigrationStarted EventType = "migration_started"
+	// MigrationFinished is sent after Event.Migration was applied within Event.Duration
+	MigrationFinished EventType = "migration_finished"
+	// MigrationFailed is sent if Event.Migration failed with Event.Err
+	MigrationFailed EventType = "migration_failed"
+	// VerifyPassed is sent after the verify of Event.Migration passed
+	VerifyPassed EventType = "verify_passed"
+	// VerifyFailed is sent if the verify of Event.Migration failed with Event.Err
+	VerifyFailed EventType = "verify_failed"
+	// BootstrapApplied is sent after the bootstrap migration was applied
+	BootstrapApplied EventType = "bootstrap_applied"
+	// HookFinished is sent after Event.Hook ran within Event.Duration (for Event.Migration if
+	// it is a hook of each migration)
+	HookFinished EventType = "hook_finished"
+	// HookFailed is sent if Event.Hook failed with Event.Err
+	HookFailed EventType = "hook_failed"
+	// RepeatableApplied is sent after the repeatable migration Event.File was applied within
+	// Event.Duration
+	RepeatableApplied EventType = "repeatable_applied"
+	// RepeatableFailed is sent if the repeatable migration Event.File failed with Event.Err
+	RepeatableFailed EventType = "repeatable_failed"
+	// SeedApplied is sent after the data seed Event.File was applied within Event.Duration
+	SeedApplied EventType = "seed_applied"
+	// SeedFailed is sent if the data seed Event.File failed with Event.Err
+	SeedFailed EventType = "seed_failed"
+)
+
+// Event describes the progress of applying migrations
+type Event struct {
+	Type      EventType
+	Direction direction.MigrateDirection
+	// Migration is set for migration and verify events
+	Migration FileMigration
+	// Hook is the name of the hook for hook events
+	Hook string
+	// File is the name of the repeatable migration (<app>/R_<name>.sql) or data seed for their
+	// events
+	File string
+	// Total is the number of migrations of a started run
+	Total int
+	// Duration is the time a migration took (or the whole run for RunFinished)
+	Duration time.Duration
+	Err      error
+}
+
+// Observer receives the events of applying migrations
+type Observer interface {
+	Notify(event Event)
+}
+
+// ObserverFunc is an observer implemented by a function
+type ObserverFunc func(event Event)
+
+// Notify calls the function
+func (f ObserverFunc) Notify(event Event) {
+	f(event)
+}
+
+// MultiObserver passes the events to all of its observers (in order)
+type MultiObserver []Observer
+
+// Notify passes the event to all observers
+func (observers MultiObserver) Notify(event Event) {
+	for _, observer := range observers {
+		observer.Notify(event)
+	}
+}
+
+// NoObserver ignores all events
+var NoObserver = ObserverFunc(func(Event) {})
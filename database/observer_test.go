@@ -0,0 +1,31 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+)
+
+// recordEventTypes returns an observer recording the types of the received events
+func recordEventTypes() (Observer, *[]EventType) {
+	types := []EventType{}
+	return ObserverFunc(func(event Event) {
+		types = append(types, event.Type)
+	}), &types
+}
+
+func TestMultiObserver(t *testing.T) {
+	first, firstTypes := recordEventTypes()
+	second, secondTypes := recordEventTypes()
+
+	MultiObserver{first, second}.Notify(Event{Type: RunStarted})
+	NoObserver.Notify(Event{Type: RunFinished})
+
+	expected := []EventType{RunStarted}
+	if diff := pretty.Compare(*firstTypes, expected); diff != "" {
+		t.Errorf("Got unexpected events of the first observer: (-got +want)\n%s", diff)
+	}
+	if diff := pretty.Compare(*secondTypes, expected); diff != "" {
+		t.Errorf("Got unexpected events of the second observer: (-got +want)\n%s", diff)
+	}
+}
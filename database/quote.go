@@ -0,0 +1,34 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+)
+
+// statement is an SQL statement with the values of its bound parameters
+type statement struct {
+	query string
+	args  []interface{}
+}
+
+// newStatement formats the query (e.g. with the table name) and binds the args
+func newStatement(format, table string, args ...interface{}) statement {
+	return statement{query: fmt.Sprintf(format, table), args: args}
+}
+
+// QuoteLiteral quotes the value as PostgreSQL string literal for scripts, which are not executed
+// with bound parameters (like seeds). Values containing backslashes are written as escape string
+// literals, so they are independent of the standard_conforming_strings setting
+func QuoteLiteral(value string) string {
+	value = strings.ReplaceAll(value, `'`, `''`)
+	if strings.Contains(value, `\`) {
+		return fmt.Sprintf(`E'%s'`, strings.ReplaceAll(value, `\`, `\\`))
+	}
+	return fmt.Sprintf(`'%s'`, value)
+}
+
+// QuoteIdentifier quotes the name as PostgreSQL identifier (e.g. of a database), which cannot be
+// passed as bound parameter
+func QuoteIdentifier(name string) string {
+	return fmt.Sprintf(`"%s"`, strings.ReplaceAll(name, `"`, `""`))
+}
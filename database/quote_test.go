@@ -0,0 +1,35 @@
+package database
+
+import "testing"
+
+func TestQuoteLiteral(t *testing.T) {
+	testCases := []struct{ value, expected string }{
+		{"foo", `'foo'`},
+		{"fix_user's_table", `'fix_user''s_table'`},
+		{"größe_ändern", `'größe_ändern'`},
+		{`back\slash's`, `E'back\\slash''s'`},
+		{"", `''`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.value, func(t *testing.T) {
+			if quoted := QuoteLiteral(testCase.value); quoted != testCase.expected {
+				t.Errorf("Expected %s, but got %s", testCase.expected, quoted)
+			}
+		})
+	}
+}
+
+func TestQuoteIdentifier(t *testing.T) {
+	testCases := []struct{ name, expected string }{
+		{"zlab", `"zlab"`},
+		{"Zlab-Dev", `"Zlab-Dev"`},
+		{`my"db`, `"my""db"`},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			if quoted := QuoteIdentifier(testCase.name); quoted != testCase.expected {
+				t.Errorf("Expected %s, but got %s", testCase.expected, quoted)
+			}
+		})
+	}
+}
@@ -0,0 +1,260 @@
+package database
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/lithammer/dedent"
+)
+
+// RepeatablePrefix marks repeatable migrations (R_<name>.sql) within the application folders
+const RepeatablePrefix = "R_"
+
+// RepeatableNameRegex matches the filenames of repeatable migrations (R_<name>.sql)
+var RepeatableNameRegex = regexp.MustCompile(`^R_[\w]+\.sql$`)
+
+// CreateRepeatableChangelogSQL creates the changelog of the repeatable migrations if necessary
+var CreateRepeatableChangelogSQL = dedent.Dedent(`
+	CREATE TABLE IF NOT EXISTS %s (
+		  name TEXT NOT NULL PRIMARY KEY
+		, checksum TEXT NOT NULL
+		, applied_at timestamptz NOT NULL
+	);
+`)
+
+// RepeatableChangelogUpsertSQL records the checksum of an applied repeatable migration
+// (bound parameters: name, checksum)
+var RepeatableChangelogUpsertSQL = "INSERT INTO %s (name, checksum, applied_at) " +
+	"VALUES ($1, $2, now()) " +
+	"ON CONFLICT (name) DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = now()"
+
+// ErrPendingMigrations is returned if repeatable migrations are not applied, because versioned
+// migrations are still pending
+var ErrPendingMigrations = errors.New("versioned migrations are pending")
+
+// RepeatableMigration is a migration, which is re-applied whenever its content changes.
+// It has no down migration and no verify file
+type RepeatableMigration struct {
+	SQL         string
+	Checksum    string
+	Filename    string
+	Application string
+}
+
+// Name identifies the repeatable migration in the changelog
+func (mig RepeatableMigration) Name() string {
+	return fmt.Sprintf("%s/%s", mig.Application, mig.Filename)
+}
+
+// IsRepeatable returns whether the file is a repeatable migration
+func IsRepeatable(filename string) bool {
+	return strings.HasPrefix(filename, RepeatablePrefix)
+}
+
+// LoadFromFile loads the repeatable migration and calculates the checksum of its (rendered)
+// content
+func (mig *RepeatableMigration) LoadFromFile(migrationPath string, vars map[string]string) error {
+	mig.Application = filepath.Base(filepath.Dir(migrationPath))
+	mig.Filename = filepath.Base(migrationPath)
+
+	errs := FileErrors{}
+	if !RepeatableNameRegex.MatchString(mig.Filename) {
+		errs.add(migrationPath, KindBadName, 0, "The repeatable migration file name was invalid")
+		return errs
+	}
+
+	content, err := ioutil.ReadFile(migrationPath)
+	if err != nil {
+		errs.add(migrationPath, KindUnreadable, 0, "Couldn't read migration file: %v", err)
+		return errs
+	}
+	rendered, err := RenderTemplate(mig.Filename, string(content), vars)
+	if err != nil {
+		errs.add(migrationPath, KindTemplate, 0, "%v", err)
+		return errs
+	}
+	mig.SQL = strings.Trim(strings.Trim(rendered, "\n"), " ")
+	if mig.SQL == "" {
+		errs.add(migrationPath, KindEmptyUp, 1, "The repeatable migration was empty")
+		return errs
+	}
+	mig.Checksum = fmt.Sprintf("%x", sha256.Sum256([]byte(rendered)))
+
+	return nil
+}
+
+// GetRepeatableMigrations gets all repeatable migrations within the application folders.
+// They are sorted by filename (and application), which is the order they are applied in.
+// Files marked as template are rendered with the vars
+func GetRepeatableMigrations(migrationFolder string, vars map[string]string) (
+	migrations []RepeatableMigration, err error,
+) {
+	errs := FileErrors{}
+	apps, err := ioutil.ReadDir(migrationFolder)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"Could not read content of migrationFolder %s - Err: %v", migrationFolder, err,
+		)
+	}
+	for _, app := range apps {
+		if SkippedFolders[app.Name()] || !app.IsDir() {
+			continue
+		}
+
+		migFiles, err := ioutil.ReadDir(filepath.Join(migrationFolder, app.Name()))
+		if err != nil {
+			return nil, fmt.Errorf(
+				"Could not read content of appFolder %s - Err: %v", app.Name(), err,
+			)
+		}
+		for _, migFile := range migFiles {
+			if migFile.IsDir() || !IsRepeatable(migFile.Name()) {
+				continue
+			}
+
+			mig := RepeatableMigration{}
+			migPath := filepath.Join(migrationFolder, app.Name(), migFile.Name())
+			if err := mig.LoadFromFile(migPath, vars); err != nil {
+				errs = append(errs, err.(FileErrors)...)
+				continue
+			}
+			migrations = append(migrations, mig)
+		}
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		if migrations[i].Filename != migrations[j].Filename {
+			return migrations[i].Filename < migrations[j].Filename
+		}
+		return migrations[i].Application < migrations[j].Application
+	})
+	return migrations, nil
+}
+
+// FilterRepeatableApplications returns the repeatable migrations belonging to one of the given
+// applications. Without any applications all migrations are returned
+func FilterRepeatableApplications(
+	migrations []RepeatableMigration, applications []string,
+) []RepeatableMigration {
+	if len(applications) == 0 {
+		return migrations
+	}
+
+	appLookup := map[string]bool{}
+	for _, app := range applications {
+		appLookup[app] = true
+	}
+
+	filtered := []RepeatableMigration{}
+	for _, mig := range migrations {
+		if appLookup[mig.Application] {
+			filtered = append(filtered, mig)
+		}
+	}
+	return filtered
+}
+
+// EnsureRepeatableChangelog creates the changelog of the repeatable migrations if necessary
+func EnsureRepeatableChangelog(db *sql.DB, changelogTable string) error {
+	_, err := db.Exec(fmt.Sprintf(CreateRepeatableChangelogSQL, changelogTable))
+	if err != nil {
+		return fmt.Errorf("Error creating repeatable migrations changelog: %v", err)
+	}
+	return nil
+}
+
+// RepeatableChangelogExists checks whether the changelog of the repeatable migrations exists
+func RepeatableChangelogExists(db *sql.DB, changelogTable string) (exists bool, err error) {
+	err = db.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, changelogTable).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf(
+			"Error checking for repeatable migrations changelog existence: %v", err,
+		)
+	}
+	return exists, nil
+}
+
+// GetAppliedChecksums returns the checksums of the applied repeatable migrations by their name
+func GetAppliedChecksums(db *sql.DB, changelogTable string) (map[string]string, error) {
+	rows, err := db.Query(fmt.Sprintf(`SELECT name, checksum FROM %s`, changelogTable))
+	if err != nil {
+		return nil, fmt.Errorf("Got error getting applied repeatable migrations: %v", err)
+	}
+	defer rows.Close()
+
+	checksums := map[string]string{}
+	for rows.Next() {
+		var name, checksum string
+		if err := rows.Scan(&name, &checksum); err != nil {
+			return nil, fmt.Errorf("Error scanning row for repeatable migrations: %v", err)
+		}
+		checksums[name] = checksum
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf(
+			"Error after row iteration for getting repeatable migrations: %v", err,
+		)
+	}
+	return checksums, nil
+}
+
+// FilterChangedRepeatables returns the repeatable migrations, which were not applied yet or
+// whose checksum changed since
+func FilterChangedRepeatables(
+	migrations []RepeatableMigration, checksums map[string]string,
+) []RepeatableMigration {
+	changed := []RepeatableMigration{}
+	for _, mig := range migrations {
+		if checksums[mig.Name()] != mig.Checksum {
+			changed = append(changed, mig)
+		}
+	}
+	return changed
+}
+
+// ApplyRepeatableMigration applies the repeatable migration and records its checksum in the
+// changelog within a single transaction
+func ApplyRepeatableMigration(
+	db *sql.DB, migration RepeatableMigration, changelogTable string,
+) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("Error opening transaction: %v", err)
+	}
+
+	statements := []statement{
+		{query: migration.SQL},
+		newStatement(
+			RepeatableChangelogUpsertSQL, changelogTable, migration.Name(), migration.Checksum,
+		),
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(statement.query, statement.args...); err != nil {
+			rollbackError := tx.Rollback()
+			if rollbackError != nil {
+				return fmt.Errorf(
+					"Could not apply the repeatable migration %s: %v \n and rollback error: %v",
+					migration.Name(), err, rollbackError,
+				)
+			}
+			return fmt.Errorf(
+				"Could not apply the repeatable migration %s: %v", migration.Name(), err,
+			)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("Error during commit of %s: %v", migration.Name(), err)
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package database
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestGetRepeatableMigrations(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	saveMigrationFor(basePath, "sub_app", "20171101000001_foo.sql")
+	os.Mkdir(filepath.Join(basePath, "common"), 0777)
+	views := "CREATE OR REPLACE VIEW v AS SELECT 1;\n"
+	ioutil.WriteFile(filepath.Join(basePath, "sub_app", "R_b_views.sql"), []byte(views), 0777)
+	grants := "GRANT SELECT ON v TO viewer;\n"
+	ioutil.WriteFile(filepath.Join(basePath, "common", "R_c_grants.sql"), []byte(grants), 0777)
+	ioutil.WriteFile(filepath.Join(basePath, "common", "R_a_funcs.sql"), []byte(views), 0777)
+
+	repeatables, err := GetRepeatableMigrations(basePath, nil)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expected := []RepeatableMigration{
+		{
+			SQL: "CREATE OR REPLACE VIEW v AS SELECT 1;", Filename: "R_a_funcs.sql",
+			Application: "common", Checksum: fmt.Sprintf("%x", sha256.Sum256([]byte(views))),
+		},
+		{
+			SQL: "CREATE OR REPLACE VIEW v AS SELECT 1;", Filename: "R_b_views.sql",
+			Application: "sub_app", Checksum: fmt.Sprintf("%x", sha256.Sum256([]byte(views))),
+		},
+		{
+			SQL: "GRANT SELECT ON v TO viewer;", Filename: "R_c_grants.sql",
+			Application: "common", Checksum: fmt.Sprintf("%x", sha256.Sum256([]byte(grants))),
+		},
+	}
+	if diff := pretty.Compare(expected, repeatables); diff != "" {
+		t.Errorf("Did not load the repeatable migrations:\n%s", diff)
+	}
+
+	// repeatable migrations are no versioned migrations
+	fileMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil || len(fileMigrations) != 1 {
+		t.Errorf("Expected only the versioned migration, but got %v (%v)", fileMigrations, err)
+	}
+}
+
+func TestGetRepeatableMigrationsErrors(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	os.Mkdir(filepath.Join(basePath, "common"), 0777)
+	appPath := filepath.Join(basePath, "common")
+	ioutil.WriteFile(filepath.Join(appPath, "R_bad-name.sql"), []byte("SELECT 1"), 0777)
+	ioutil.WriteFile(filepath.Join(appPath, "R_empty.sql"), []byte("\n"), 0777)
+
+	_, err = GetRepeatableMigrations(basePath, nil)
+	fileErrors, ok := err.(FileErrors)
+	if !ok || len(fileErrors) != 2 {
+		t.Fatalf("Expected two FileErrors, but got: %v", err)
+	}
+	if fileErrors[0].Kind != KindBadName || fileErrors[1].Kind != KindEmptyUp {
+		t.Errorf("Got unexpected kinds of errors: %v", fileErrors)
+	}
+}
+
+func TestFilterChangedRepeatables(t *testing.T) {
+	migrations := []RepeatableMigration{
+		{Application: "a", Filename: "R_new.sql", Checksum: "1"},
+		{Application: "a", Filename: "R_changed.sql", Checksum: "2"},
+		{Application: "a", Filename: "R_same.sql", Checksum: "3"},
+	}
+	checksums := map[string]string{"a/R_changed.sql": "1", "a/R_same.sql": "3"}
+
+	changed := FilterChangedRepeatables(migrations, checksums)
+	if diff := pretty.Compare(migrations[:2], changed); diff != "" {
+		t.Errorf("Did not filter the changed repeatable migrations:\n%s", diff)
+	}
+}
+
+func TestGetAppliedChecksums(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+
+	rows := sqlmock.NewRows([]string{"name", "checksum"}).
+		AddRow("a/R_views.sql", "1").
+		AddRow("b/R_grants.sql", "2")
+	mock.ExpectQuery(`SELECT name, checksum FROM sth`).WillReturnRows(rows)
+
+	checksums, err := GetAppliedChecksums(db, "sth")
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	expected := map[string]string{"a/R_views.sql": "1", "b/R_grants.sql": "2"}
+	if diff := pretty.Compare(expected, checksums); diff != "" {
+		t.Errorf("Did not return the checksums:\n%s", diff)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestApplyRepeatableMigration(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	migration := RepeatableMigration{
+		SQL: "CREATE VIEW v AS SELECT 1", Application: "a", Filename: "R_v.sql", Checksum: "abc",
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE VIEW v AS SELECT 1").WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(
+		"INSERT INTO sth (name, checksum, applied_at) VALUES ($1, $2, now()) "+
+			"ON CONFLICT (name) DO UPDATE SET checksum = EXCLUDED.checksum, applied_at = now()",
+	).WithArgs("a/R_v.sql", "abc").WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	if err := ApplyRepeatableMigration(db, migration, "sth"); err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestApplyRepeatableMigrationError(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	migration := RepeatableMigration{SQL: "CREATE VIEW v AS SELECT 1"}
+
+	mock.ExpectBegin()
+	mock.ExpectExec("CREATE VIEW v AS SELECT 1").WillReturnError(fmt.Errorf("Some error"))
+	mock.ExpectRollback()
+
+	if err := ApplyRepeatableMigration(db, migration, "sth"); err == nil {
+		t.Errorf("Expected an error, but got nothing")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
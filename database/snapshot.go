@@ -0,0 +1,94 @@
+package database
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+)
+
+// MaxIdentifierLength is the maximal length of a PostgreSQL identifier (e.g. a database name)
+const MaxIdentifierLength = 63
+
+var snapshotNameRegex = regexp.MustCompile(`^[a-z0-9_]+$`)
+
+// temporarySeparator separates the purpose of a temporary snapshot database from the snapshot
+// name (<database>_snapshot_<name>-<purpose>). Snapshot names cannot contain it
+const temporarySeparator = "-"
+
+// Snapshot is a copy of the database of an environment, which can be restored
+type Snapshot struct {
+	Name     string
+	Database string
+	// MigrationID is the ID of the last applied migration of the snapshot (empty without any)
+	MigrationID string
+	CreatedAt   time.Time
+}
+
+// SnapshotDatabaseName returns the name of the database of a snapshot (<database>_snapshot_<name>)
+// An empty name returns the prefix of all snapshots of the database
+func SnapshotDatabaseName(dbName, name string) (string, error) {
+	snapshotDb := fmt.Sprintf("%s_snapshot_%s", dbName, name)
+	if name == "" {
+		return snapshotDb, nil
+	}
+
+	if !snapshotNameRegex.MatchString(name) {
+		return "", fmt.Errorf(
+			"Invalid snapshot name %s, only lower case letters, digits and _ are allowed", name,
+		)
+	}
+	if len(snapshotDb) > MaxIdentifierLength {
+		return "", fmt.Errorf(
+			"Snapshot name %s is too long for a database name (%s)", name, snapshotDb,
+		)
+	}
+	return snapshotDb, nil
+}
+
+// TemporarySnapshotDatabaseName returns the name of a database, which temporarily holds a copy
+// while the snapshot is saved or restored (<database>_snapshot_<name>-<purpose>). The snapshot
+// name is shortened to fit the maximum identifier length
+func TemporarySnapshotDatabaseName(dbName, name, purpose string) (string, error) {
+	snapshotDb, err := SnapshotDatabaseName(dbName, name)
+	if err != nil {
+		return "", err
+	}
+	prefix, _ := SnapshotDatabaseName(dbName, "")
+	suffix := temporarySeparator + purpose
+	if len(prefix)+1+len(suffix) > MaxIdentifierLength {
+		return "", fmt.Errorf(
+			"Database name %s is too long for temporary snapshot databases", dbName,
+		)
+	}
+	if len(snapshotDb)+len(suffix) > MaxIdentifierLength {
+		snapshotDb = snapshotDb[:MaxIdentifierLength-len(suffix)]
+	}
+	return snapshotDb + suffix, nil
+}
+
+// IsTemporarySnapshot returns whether the snapshot name (the database name without the prefix
+// of the snapshots) belongs to a temporary snapshot database
+func IsTemporarySnapshot(name string) bool {
+	return strings.Contains(name, temporarySeparator)
+}
+
+// PrintSnapshotTable prints the snapshots as table
+func PrintSnapshotTable(w io.Writer, snapshots []Snapshot) {
+	t := table.NewWriter()
+	t.SetOutputMirror(w)
+	t.AppendHeader(table.Row{"Name", "Migration", "Created At"})
+
+	for _, snapshot := range snapshots {
+		createdAt := ""
+		if !snapshot.CreatedAt.IsZero() {
+			createdAt = snapshot.CreatedAt.Format(time.RFC3339)
+		}
+		t.AppendRow([]interface{}{snapshot.Name, snapshot.MigrationID, createdAt})
+	}
+
+	t.Render()
+}
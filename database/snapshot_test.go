@@ -0,0 +1,61 @@
+package database
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestTemporarySnapshotDatabaseName(t *testing.T) {
+	testCases := []struct {
+		dbName, name, expected string
+		expectError            bool
+	}{
+		{"zlab", "base", "zlab_snapshot_base-saving", false},
+		{
+			"zlab", strings.Repeat("a", 49),
+			"zlab_snapshot_" + strings.Repeat("a", 42) + "-saving", false,
+		},
+		{"zlab", "Base", "", true},
+		{strings.Repeat("z", 47), "a", "", true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			tmpDb, err := TemporarySnapshotDatabaseName(testCase.dbName, testCase.name, "saving")
+			if (err != nil) != testCase.expectError {
+				t.Errorf("Expected error %t, but got %v", testCase.expectError, err)
+			}
+			if tmpDb != testCase.expected {
+				t.Errorf("Expected %s, but got %s", testCase.expected, tmpDb)
+			}
+			name := strings.TrimPrefix(tmpDb, "zlab_snapshot_")
+			if !testCase.expectError && !IsTemporarySnapshot(name) {
+				t.Errorf("Expected %s to be a temporary snapshot", tmpDb)
+			}
+		})
+	}
+}
+
+func TestSnapshotDatabaseName(t *testing.T) {
+	testCases := []struct {
+		name, expected string
+		expectError    bool
+	}{
+		{"base", "zlab_snapshot_base", false},
+		{"before_import_2", "zlab_snapshot_before_import_2", false},
+		{"", "zlab_snapshot_", false},
+		{"Base", "", true},
+		{`a"b`, "", true},
+		{strings.Repeat("a", 50), "", true},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			snapshotDb, err := SnapshotDatabaseName("zlab", testCase.name)
+			if (err != nil) != testCase.expectError {
+				t.Errorf("Expected error %t, but got %v", testCase.expectError, err)
+			}
+			if snapshotDb != testCase.expected {
+				t.Errorf("Expected %s, but got %s", testCase.expected, snapshotDb)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package database
+
+import (
+	"database/sql"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ArchiveFolder is the folder within the migrations path, where squashed migrations are moved to.
+// It keeps the layout of the application folders
+const ArchiveFolder = "_archive"
+
+var mockableRename = os.Rename
+
+// ConcatenateUpSQL concatenates the up SQL of the migrations into a single script.
+// With a changelog table every migration is followed by its insert into the changelog
+func ConcatenateUpSQL(migrations []FileMigration, changelogTable string) string {
+	var builder strings.Builder
+	for _, migration := range migrations {
+		builder.WriteString(fmt.Sprintf("%s;\n", migration.UpSQL))
+		if changelogTable != "" {
+			builder.WriteString(fmt.Sprintf(
+				"%s;\n",
+				fmt.Sprintf(
+					ChangelogInsertLiteralSQL, changelogTable,
+					QuoteLiteral(migration.ID), QuoteLiteral(migration.Description),
+				),
+			))
+		}
+	}
+	return builder.String()
+}
+
+// ConcatenateDownSQL concatenates the down SQL of the migrations in reverse order
+func ConcatenateDownSQL(migrations []FileMigration) string {
+	var builder strings.Builder
+	for idx := len(migrations) - 1; idx >= 0; idx-- {
+		builder.WriteString(fmt.Sprintf("%s;\n", migrations[idx].DownSQL))
+	}
+	return builder.String()
+}
+
+// SquashMigrations merges all migrations of the application up to (and including) the given ID
+// into a single migration. The squash migration takes the ID of the last merged migration, its
+// down script is a best-effort concatenation of the down scripts in reverse order. Its verify is
+// the verify of the last merged migration, as the verify scripts of the others were written for
+// intermediate states of the schema. The original migrations are moved to the archive folder and
+// moved back, if the squash migration cannot be written
+func SquashMigrations(
+	migrationsPath, application, beforeID string, fileMigrations []FileMigration,
+) (squash FileMigration, err error) {
+	squashed := []FileMigration{}
+	squashedLookup := map[string]bool{}
+	for _, mig := range fileMigrations {
+		if mig.Application == application && mig.ID <= beforeID {
+			if mig.Template {
+				// the squash would contain the SQL rendered for the current environment only
+				return squash, fmt.Errorf(
+					"The migration %s is a template and cannot be squashed", mig.Filename,
+				)
+			}
+			if mig.IsGo() {
+				return squash, fmt.Errorf(
+					"The migration %s is written in Go and cannot be squashed", mig.Filename,
+				)
+			}
+			squashed = append(squashed, mig)
+			squashedLookup[mig.ID] = true
+		}
+	}
+	if len(squashed) < 2 {
+		return squash, fmt.Errorf(
+			"Found %d migrations of %s up to %s, at least two are required for a squash",
+			len(squashed), application, beforeID,
+		)
+	}
+
+	requires := []string{}
+	requiresLookup := map[string]bool{}
+	for _, mig := range squashed {
+		for _, requiredID := range mig.Requires {
+			if !squashedLookup[requiredID] && !requiresLookup[requiredID] {
+				requires = append(requires, requiredID)
+				requiresLookup[requiredID] = true
+			}
+		}
+	}
+
+	squashedIDs := []string{}
+	for idx, mig := range squashed {
+		squashedIDs = append(squashedIDs, mig.ID)
+		// the headers are replaced by the headers of the squash migration
+		squashed[idx].UpSQL = strings.Trim(
+			squashesRegex.ReplaceAllString(requiresRegex.ReplaceAllString(mig.UpSQL, ""), ""),
+			"\n",
+		)
+	}
+
+	lastMigration := squashed[len(squashed)-1]
+	squash = FileMigration{
+		ID:          lastMigration.ID,
+		Description: "squashed",
+		Filename:    fmt.Sprintf("%s_squashed.sql", lastMigration.ID),
+		Application: application,
+		Requires:    requires,
+		Squashes:    squashedIDs,
+		DownSQL:     strings.TrimSuffix(ConcatenateDownSQL(squashed), "\n"),
+		VerifySQL:   lastMigration.VerifySQL,
+	}
+
+	header := fmt.Sprintf("-- //@SQUASHES %s\n", strings.Join(squashedIDs, " "))
+	if len(requires) > 0 {
+		header = fmt.Sprintf("%s-- //@REQUIRES %s\n", header, strings.Join(requires, " "))
+	}
+	squash.UpSQL = header + strings.TrimSuffix(ConcatenateUpSQL(squashed, ""), "\n")
+
+	// the squash migration may replace a squashed migration of the same name (e.g. a previous
+	// squash), so the originals are archived first and moved back on errors
+	archived, err := archiveMigrations(migrationsPath, squashed)
+	if err != nil {
+		return squash, err
+	}
+	if err := writeSquash(filepath.Join(migrationsPath, application), squash); err != nil {
+		if restoreErr := restoreMigrations(archived); restoreErr != nil {
+			return squash, fmt.Errorf("%v \n and restore error: %v", err, restoreErr)
+		}
+		return squash, err
+	}
+
+	return squash, nil
+}
+
+// writeSquash writes the squash migration and its verify. Written files are removed on errors
+func writeSquash(appPath string, squash FileMigration) error {
+	migrationPath := filepath.Join(appPath, squash.Filename)
+	err := ioutil.WriteFile(
+		migrationPath,
+		[]byte(fmt.Sprintf("%s\n-- //@UNDO\n%s\n", squash.UpSQL, squash.DownSQL)),
+		0644,
+	)
+	if err != nil {
+		os.Remove(migrationPath)
+		return fmt.Errorf("Could not write the squash migration: %v", err)
+	}
+
+	verifyPath := filepath.Join(appPath, "verify", squash.Filename)
+	err = ioutil.WriteFile(verifyPath, []byte(squash.VerifySQL+"\n"), 0644)
+	if err != nil {
+		os.Remove(migrationPath)
+		os.Remove(verifyPath)
+		return fmt.Errorf("Could not write the verify of the squash migration: %v", err)
+	}
+	return nil
+}
+
+// archivedFile is a file moved from its path in the application folder to the archive
+type archivedFile struct {
+	path        string
+	archivePath string
+}
+
+// archiveMigrations moves the migrations and their verify to the archive folder. On errors the
+// files archived so far are moved back
+func archiveMigrations(
+	migrationsPath string, migrations []FileMigration,
+) (archived []archivedFile, err error) {
+	for _, mig := range migrations {
+		archivePath := filepath.Join(migrationsPath, ArchiveFolder, mig.Application)
+		if err := os.MkdirAll(filepath.Join(archivePath, "verify"), 0755); err != nil {
+			return nil, fmt.Errorf("Could not create the archive folder: %v", err)
+		}
+
+		appPath := filepath.Join(migrationsPath, mig.Application)
+		for _, subPath := range []string{mig.Filename, filepath.Join("verify", mig.Filename)} {
+			file := archivedFile{
+				path: filepath.Join(appPath, subPath), archivePath: filepath.Join(archivePath, subPath),
+			}
+			if err := mockableRename(file.path, file.archivePath); err != nil {
+				err = fmt.Errorf("Could not archive %s/%s: %v", mig.Application, subPath, err)
+				if restoreErr := restoreMigrations(archived); restoreErr != nil {
+					return nil, fmt.Errorf("%v \n and restore error: %v", err, restoreErr)
+				}
+				return nil, err
+			}
+			archived = append(archived, file)
+		}
+	}
+	return archived, nil
+}
+
+// restoreMigrations moves archived files back to the application folder (in reverse order)
+func restoreMigrations(archived []archivedFile) error {
+	for idx := len(archived) - 1; idx >= 0; idx-- {
+		file := archived[idx]
+		if err := mockableRename(file.archivePath, file.path); err != nil {
+			return fmt.Errorf("Could not restore %s from the archive: %v", file.path, err)
+		}
+	}
+	return nil
+}
+
+// FindChangelogSquashes returns the squash migrations, whose squashed migrations were all
+// applied, but are still in the changelog instead of the squash migration
+func FindChangelogSquashes(
+	fileMigrations []FileMigration, appliedMigrations []AppliedMigration,
+) (squashes []FileMigration) {
+	appliedLookup := map[string]AppliedMigration{}
+	for _, mig := range appliedMigrations {
+		appliedLookup[mig.ID] = mig
+	}
+
+	for _, mig := range fileMigrations {
+		if len(mig.Squashes) == 0 {
+			continue
+		}
+		if applied, exists := appliedLookup[mig.ID]; exists && applied.Name == mig.Description {
+			continue
+		}
+
+		allApplied := true
+		for _, squashedID := range mig.Squashes {
+			if _, exists := appliedLookup[squashedID]; !exists {
+				allApplied = false
+				break
+			}
+		}
+		if allApplied {
+			squashes = append(squashes, mig)
+		}
+	}
+	return squashes
+}
+
+// SquashChangelog replaces the changelog entries of squashed migrations by the squash migration,
+// if all of them were applied (see FindChangelogSquashes). All replacements are done in a single
+// transaction. It returns whether the changelog was changed
+func SquashChangelog(
+	db *sql.DB, fileMigrations []FileMigration, appliedMigrations []AppliedMigration,
+	changelogTable string,
+) (changed bool, err error) {
+	statements := []statement{}
+	for _, mig := range FindChangelogSquashes(fileMigrations, appliedMigrations) {
+		for _, squashedID := range mig.Squashes {
+			statements = append(
+				statements, newStatement(ChangelogDeleteSQL, changelogTable, squashedID),
+			)
+		}
+		statements = append(
+			statements, newStatement(ChangelogInsertSQL, changelogTable, mig.ID, mig.Description),
+		)
+	}
+	if len(statements) == 0 {
+		return false, nil
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return false, fmt.Errorf("Error opening transaction: %v", err)
+	}
+	for _, statement := range statements {
+		if _, err = tx.Exec(statement.query, statement.args...); err != nil {
+			rollbackError := tx.Rollback()
+			if rollbackError != nil {
+				return false, fmt.Errorf(
+					"Could not mark squashed migrations: %v \n and rollback error: %v",
+					err, rollbackError,
+				)
+			}
+			return false, fmt.Errorf("Could not mark squashed migrations: %v", err)
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return false, fmt.Errorf("Error during commit of squashed migrations: %v", err)
+	}
+	return true, nil
+}
@@ -0,0 +1,291 @@
+package database
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/kylelemons/godebug/pretty"
+)
+
+func TestSquashMigrations(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	saveMigrationFor(basePath, "common", "20171101000001_foo.sql")
+	saveMigrationFor(basePath, "common", "20171101000002_bar.sql")
+	saveMigrationFor(basePath, "common", "20171101000003_baz.sql")
+	saveMigrationFor(basePath, "sub_app", "20171101000004_sub.sql")
+	ioutil.WriteFile(
+		filepath.Join(basePath, "sub_app", "20171101000004_sub.sql"),
+		[]byte("-- //@REQUIRES 20171101000001\nSELECT 1;\n-- //@UNDO\nSELECT 2;"), 0777,
+	)
+
+	fileMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil {
+		t.Fatalf("Got an error loading migrations: %v", err)
+	}
+
+	squash, err := SquashMigrations(basePath, "common", "20171101000002", fileMigrations)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	expectedSquashes := []string{"20171101000001", "20171101000002"}
+	if diff := pretty.Compare(expectedSquashes, squash.Squashes); diff != "" {
+		t.Errorf("Did not squash the right migrations:\n%s", diff)
+	}
+
+	for _, archived := range []string{
+		"20171101000001_foo.sql", "20171101000002_bar.sql",
+		filepath.Join("verify", "20171101000001_foo.sql"),
+	} {
+		archivePath := filepath.Join(basePath, ArchiveFolder, "common", archived)
+		if _, err := os.Stat(archivePath); err != nil {
+			t.Errorf("Expected %s to be archived: %v", archived, err)
+		}
+	}
+
+	gotMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil {
+		t.Fatalf("Got an error loading the squashed migrations: %v", err)
+	}
+	expectedMigrations := []FileMigration{
+		{
+			Filename:    "20171101000002_squashed.sql",
+			ID:          "20171101000002",
+			Description: "squashed",
+			Application: "common",
+			UpSQL: "-- //@SQUASHES 20171101000001 20171101000002\n" +
+				"CREATE SCHEMA template;;\nCREATE SCHEMA template;;",
+			DownSQL:   "DROP SCHEMA template;;\nDROP SCHEMA template;;",
+			VerifySQL: "SELECT 1",
+			Squashes:  []string{"20171101000001", "20171101000002"},
+		},
+		{
+			Filename:    "20171101000003_baz.sql",
+			ID:          "20171101000003",
+			Description: "baz",
+			Application: "common",
+			UpSQL:       "CREATE SCHEMA template;",
+			DownSQL:     "DROP SCHEMA template;",
+			VerifySQL:   "SELECT 1",
+		},
+	}
+	if diff := pretty.Compare(expectedMigrations, gotMigrations[:2]); diff != "" {
+		t.Errorf("Did not load the squashed migrations:\n%s", diff)
+	}
+	// requirements of squashed migrations point to the squash migration
+	if diff := pretty.Compare([]string{"20171101000002"}, gotMigrations[2].Requires); diff != "" {
+		t.Errorf("Did not resolve the squashed requirement:\n%s", diff)
+	}
+}
+
+func TestSquashMigrationsVerify(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	// the verify of the first migration fails after the second one was applied
+	saveMigrationFor(basePath, "common", "20171101000001_add.sql")
+	saveMigrationFor(basePath, "common", "20171101000002_drop.sql")
+	appPath := filepath.Join(basePath, "common")
+	ioutil.WriteFile(
+		filepath.Join(appPath, "verify", "20171101000001_add.sql"),
+		[]byte("SELECT size FROM a"), 0777,
+	)
+	ioutil.WriteFile(
+		filepath.Join(appPath, "20171101000002_drop.sql"),
+		[]byte("ALTER TABLE a DROP COLUMN size;\n-- //@UNDO\nALTER TABLE a ADD COLUMN size INT;"),
+		0777,
+	)
+	ioutil.WriteFile(
+		filepath.Join(appPath, "verify", "20171101000002_drop.sql"),
+		[]byte("SELECT 1 FROM a"), 0777,
+	)
+
+	fileMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil {
+		t.Fatalf("Got an error loading migrations: %v", err)
+	}
+	squash, err := SquashMigrations(basePath, "common", "20171101000002", fileMigrations)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if squash.VerifySQL != "SELECT 1 FROM a" {
+		t.Errorf("Expected the verify of the last migration, but got %s", squash.VerifySQL)
+	}
+
+	verify, _ := ioutil.ReadFile(filepath.Join(appPath, "verify", squash.Filename))
+	if string(verify) != "SELECT 1 FROM a\n" {
+		t.Errorf("Expected the verify of the last migration, but wrote %s", verify)
+	}
+}
+
+func TestSquashMigrationsRestoresOnError(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	saveMigrationFor(basePath, "common", "20171101000001_foo.sql")
+	saveMigrationFor(basePath, "common", "20171101000002_bar.sql")
+	// the verify of the squash migration cannot be written in place of a folder
+	appPath := filepath.Join(basePath, "common")
+	os.Mkdir(filepath.Join(appPath, "verify", "20171101000002_squashed.sql"), 0777)
+
+	fileMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil {
+		t.Fatalf("Got an error loading migrations: %v", err)
+	}
+	if _, err := SquashMigrations(basePath, "common", "20171101000002", fileMigrations); err == nil {
+		t.Fatalf("Expected an error writing the verify of the squash migration")
+	}
+
+	for _, restored := range []string{
+		"20171101000001_foo.sql", "20171101000002_bar.sql",
+		filepath.Join("verify", "20171101000001_foo.sql"),
+		filepath.Join("verify", "20171101000002_bar.sql"),
+	} {
+		if _, err := os.Stat(filepath.Join(appPath, restored)); err != nil {
+			t.Errorf("Expected %s to be moved back: %v", restored, err)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(appPath, "20171101000002_squashed.sql")); err == nil {
+		t.Errorf("Expected the squash migration to be removed")
+	}
+}
+
+func TestSquashMigrationsArchiveError(t *testing.T) {
+	basePath, err := ioutil.TempDir("", "go_mig")
+	if err != nil {
+		t.Fatalf("Returned error setting up the tmp directory: %v", err)
+	}
+	defer os.RemoveAll(basePath)
+
+	saveMigrationFor(basePath, "common", "20171101000001_foo.sql")
+	saveMigrationFor(basePath, "common", "20171101000002_bar.sql")
+	renames := 0
+	mockableRename = func(oldpath, newpath string) error {
+		renames++
+		if renames == 3 {
+			return fmt.Errorf("Some error")
+		}
+		return os.Rename(oldpath, newpath)
+	}
+	defer func() { mockableRename = os.Rename }()
+
+	fileMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil {
+		t.Fatalf("Got an error loading migrations: %v", err)
+	}
+	if _, err := SquashMigrations(basePath, "common", "20171101000002", fileMigrations); err == nil {
+		t.Fatalf("Expected an error archiving the migrations")
+	}
+
+	gotMigrations, err := GetFileMigrations(basePath, nil)
+	if err != nil {
+		t.Fatalf("Got an error loading the migrations: %v", err)
+	}
+	if len(gotMigrations) != 2 || gotMigrations[0].Filename != "20171101000001_foo.sql" {
+		t.Errorf("Expected the archived migrations to be moved back, but got %v", gotMigrations)
+	}
+}
+
+func TestSquashMigrationsTooFew(t *testing.T) {
+	fileMigrations := []FileMigration{
+		{ID: "20171101000001", Application: "common"},
+		{ID: "20171101000002", Application: "sub_app"},
+	}
+	_, err := SquashMigrations("/not/used", "common", "20171101000002", fileMigrations)
+	if err == nil {
+		t.Errorf("Expected an error squashing a single migration")
+	}
+}
+
+func TestSquashMigrationsTemplate(t *testing.T) {
+	fileMigrations := []FileMigration{
+		{ID: "20171101000001", Application: "common"},
+		{ID: "20171101000002", Application: "common", Template: true},
+	}
+	_, err := SquashMigrations("/not/used", "common", "20171101000002", fileMigrations)
+	if err == nil {
+		t.Errorf("Expected an error squashing a template")
+	}
+}
+
+func TestConcatenateDownSQL(t *testing.T) {
+	migrations := []FileMigration{{DownSQL: "DROP a"}, {DownSQL: "DROP b"}}
+	if got, expected := ConcatenateDownSQL(migrations), "DROP b;\nDROP a;\n"; got != expected {
+		t.Errorf("Expected '%s', but got '%s'", expected, got)
+	}
+}
+
+func TestSquashChangelog(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+
+	fileMigrations := []FileMigration{
+		{ID: "2", Description: "squashed", Squashes: []string{"1", "2"}},
+		{ID: "4", Description: "squashed", Squashes: []string{"3", "4"}},
+		{ID: "6", Description: "squashed", Squashes: []string{"5", "6"}},
+	}
+	appliedMigrations := []AppliedMigration{
+		{ID: "1", Name: "foo"}, {ID: "2", Name: "bar"},
+		{ID: "3", Name: "foo"},
+		{ID: "6", Name: "squashed"},
+	}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM sth WHERE id = $1`).
+		WithArgs("1").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`DELETE FROM sth WHERE id = $1`).
+		WithArgs("2").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectExec(`INSERT INTO sth (id, name, applied_at) VALUES ($1, $2, now())`).
+		WithArgs("2", "squashed").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectCommit()
+
+	changed, err := SquashChangelog(db, fileMigrations, appliedMigrations, "sth")
+	if err != nil {
+		t.Errorf("Expected no error, but got: %v", err)
+	}
+	if !changed {
+		t.Errorf("Expected the changelog to be changed")
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
+
+func TestSquashChangelogError(t *testing.T) {
+	db, mock, _ := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+
+	fileMigrations := []FileMigration{
+		{ID: "2", Description: "squashed", Squashes: []string{"1", "2"}},
+	}
+	appliedMigrations := []AppliedMigration{{ID: "1"}, {ID: "2"}}
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM sth WHERE id = $1`).
+		WithArgs("1").
+		WillReturnError(fmt.Errorf("Some error"))
+	mock.ExpectRollback()
+
+	if _, err := SquashChangelog(db, fileMigrations, appliedMigrations, "sth"); err == nil {
+		t.Errorf("Expected an error, but got nothing")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("there were unfulfilled expectations: %s", err)
+	}
+}
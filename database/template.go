@@ -0,0 +1,47 @@
+package database
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+	"text/template"
+)
+
+var templateHeaderRegex = regexp.MustCompile(`(?m)^-- //@TEMPLATE[ \t]*$`)
+
+// IsTemplate returns whether the SQL is marked with a "-- //@TEMPLATE" line to be rendered
+func IsTemplate(content string) bool {
+	return templateHeaderRegex.MatchString(content)
+}
+
+// RenderTemplate renders SQL marked with a "-- //@TEMPLATE" line as Go text/template.
+// The vars of the environment configuration are available as {{ .Vars.<name> }} and the
+// environment variables as {{ .Env.<NAME> }}. Undefined variables are an error.
+// SQL without the marker is returned unchanged
+func RenderTemplate(name, content string, vars map[string]string) (string, error) {
+	if !IsTemplate(content) {
+		return content, nil
+	}
+
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(content)
+	if err != nil {
+		return "", fmt.Errorf("Could not parse template: %v", err)
+	}
+
+	env := map[string]string{}
+	for _, variable := range os.Environ() {
+		parts := strings.SplitN(variable, "=", 2)
+		env[parts[0]] = parts[1]
+	}
+	if vars == nil {
+		vars = map[string]string{}
+	}
+
+	var rendered strings.Builder
+	err = tmpl.Execute(&rendered, map[string]interface{}{"Vars": vars, "Env": env})
+	if err != nil {
+		return "", fmt.Errorf("Could not render template: %v", err)
+	}
+	return rendered.String(), nil
+}
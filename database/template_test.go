@@ -0,0 +1,101 @@
+package database
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	os.Setenv("GO_MIG_TEST_SCHEMA", "env_schema")
+	defer os.Unsetenv("GO_MIG_TEST_SCHEMA")
+	vars := map[string]string{"role": "db_viewer"}
+
+	testCases := []struct {
+		name, content, expected string
+	}{
+		{
+			name:     "no template",
+			content:  "GRANT {{ .Vars.role }} TO x;",
+			expected: "GRANT {{ .Vars.role }} TO x;",
+		},
+		{
+			name:     "vars",
+			content:  "-- //@TEMPLATE\nCREATE ROLE {{ .Vars.role }};",
+			expected: "-- //@TEMPLATE\nCREATE ROLE db_viewer;",
+		},
+		{
+			name:     "environment variables",
+			content:  "-- //@TEMPLATE\nCREATE SCHEMA {{ .Env.GO_MIG_TEST_SCHEMA }};",
+			expected: "-- //@TEMPLATE\nCREATE SCHEMA env_schema;",
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			rendered, err := RenderTemplate("test", testCase.content, vars)
+			if err != nil {
+				t.Fatalf("Expected no error, but got: %v", err)
+			}
+			if rendered != testCase.expected {
+				t.Errorf("Expected '%s', but got '%s'", testCase.expected, rendered)
+			}
+		})
+	}
+}
+
+func TestRenderTemplateUndefined(t *testing.T) {
+	for _, content := range []string{
+		"-- //@TEMPLATE\nCREATE ROLE {{ .Vars.nope }};",
+		"-- //@TEMPLATE\nCREATE ROLE {{ .Env.GO_MIG_TEST_NOT_SET }};",
+		"-- //@TEMPLATE\nCREATE ROLE {{ .Vars.role ;",
+	} {
+		if _, err := RenderTemplate("test", content, nil); err == nil {
+			t.Errorf("Expected an error for '%s', but got nothing", content)
+		}
+	}
+}
+
+func TestLoadTemplateMigration(t *testing.T) {
+	filename := "20171101000001_foo.sql"
+	appPath, cleanup := setupMigrationFor(t, filename)
+	defer cleanup()
+
+	ioutil.WriteFile(filepath.Join(appPath, filename), []byte(strings.Join([]string{
+		"-- //@TEMPLATE",
+		"CREATE SCHEMA {{ .Vars.schema }};",
+		"-- //@UNDO",
+		"DROP SCHEMA {{ .Vars.schema }};",
+	}, "\n")), 0777)
+	ioutil.WriteFile(
+		filepath.Join(appPath, "verify", filename),
+		[]byte("-- //@TEMPLATE\nSELECT '{{ .Vars.schema }}'::regnamespace"), 0777,
+	)
+
+	migration := FileMigration{}
+	err := migration.LoadFromFile(
+		filepath.Join(appPath, filename), map[string]string{"schema": "stage"},
+	)
+	if err != nil {
+		t.Fatalf("Returned error loading migration: %v", err)
+	}
+	if !migration.Template {
+		t.Errorf("Expected the migration to be marked as template")
+	}
+	if migration.UpSQL != "-- //@TEMPLATE\nCREATE SCHEMA stage;" {
+		t.Errorf("Did not render the up migration: %s", migration.UpSQL)
+	}
+	if migration.DownSQL != "DROP SCHEMA stage;" {
+		t.Errorf("Did not render the down migration: %s", migration.DownSQL)
+	}
+	if migration.VerifySQL != "-- //@TEMPLATE\nSELECT 'stage'::regnamespace" {
+		t.Errorf("Did not render the verify: %s", migration.VerifySQL)
+	}
+
+	err = migration.LoadFromFile(filepath.Join(appPath, filename), nil)
+	fileErrors, ok := err.(FileErrors)
+	if !ok || len(fileErrors) != 2 || fileErrors[0].Kind != KindTemplate {
+		t.Errorf("Expected template errors for missing vars, but got: %v", err)
+	}
+}
@@ -25,9 +25,9 @@ func WaitForStart(db *sql.DB, pollInterval time.Duration, retries int) error {
 	return fmt.Errorf("Timed out connecting to database: %v", err)
 }
 
-// GetBootstrapSQL returns the SQL string of the bootstrap file
-// or returns an empty string if the file does not exist
-func GetBootstrapSQL(migrationsPath string) (sql string, err error) {
+// GetBootstrapSQL returns the SQL string of the bootstrap file (rendered with the vars if it is
+// marked as template) or returns an empty string if the file does not exist
+func GetBootstrapSQL(migrationsPath string, vars map[string]string) (sql string, err error) {
 	bootstrapFile, err := os.Open(filepath.Join(migrationsPath, "bootstrap.sql"))
 	if err != nil {
 		if os.IsNotExist(err) {
@@ -42,13 +42,19 @@ func GetBootstrapSQL(migrationsPath string) (sql string, err error) {
 		return "", fmt.Errorf("Couldn't read config file: %v", err)
 	}
 
-	return string(fileContent), nil
+	rendered, err := RenderTemplate("bootstrap.sql", string(fileContent), vars)
+	if err != nil {
+		return "", fmt.Errorf("Invalid bootstrap.sql: %v", err)
+	}
+	return rendered, nil
 }
 
 // ApplyBootstrapMigration applies the bootstrap.sql, which it finds by itself based
 // on the migrations path
-func ApplyBootstrapMigration(db *sql.DB, migrationsPath string) (err error) {
-	fileContent, err := GetBootstrapSQL(migrationsPath)
+func ApplyBootstrapMigration(
+	db *sql.DB, migrationsPath string, vars map[string]string,
+) (err error) {
+	fileContent, err := GetBootstrapSQL(migrationsPath, vars)
 	if err != nil {
 		return err
 	}
@@ -64,21 +70,62 @@ func ApplyBootstrapMigration(db *sql.DB, migrationsPath string) (err error) {
 }
 
 // EnsureConsistentMigrations checks if all applied migrations (by ID) exist as local files
-// and if no local migration has been "skipped" (newer migrations applied)
-func EnsureConsistentMigrations(fileMigrations []FileMigration, appliedMigrations []AppliedMigration) error {
-	for idx := 0; idx < len(appliedMigrations); idx++ {
-		if len(fileMigrations) <= idx || fileMigrations[idx].ID != appliedMigrations[idx].ID {
-			moreInfo := "For more information execute the migrate status command"
-			if idx > 0 {
+// and if no local migration has been "skipped" (newer migrations applied).
+// The order is judged for each application on its own, so applications can be migrated
+// independently. If applications are given only these applications are checked.
+// With allowOutOfOrder skipped local migrations are accepted (they are applied out of order)
+func EnsureConsistentMigrations(
+	fileMigrations []FileMigration, appliedMigrations []AppliedMigration, applications []string,
+	allowOutOfOrder bool,
+) error {
+	moreInfo := "For more information execute the migrate status command"
+
+	fileLookup := map[string]FileMigration{}
+	for _, mig := range fileMigrations {
+		fileLookup[mig.ID] = mig
+	}
+
+	appliedByApp := map[string][]AppliedMigration{}
+	for _, mig := range appliedMigrations {
+		fileMigration, exists := fileLookup[mig.ID]
+		if !exists {
+			return fmt.Errorf(
+				"The applied migration %s_%s was not found locally\n%s", mig.ID, mig.Name, moreInfo,
+			)
+		}
+		appliedByApp[fileMigration.Application] = append(
+			appliedByApp[fileMigration.Application], mig,
+		)
+	}
+
+	if allowOutOfOrder {
+		return nil
+	}
+
+	filesByApp := map[string][]FileMigration{}
+	apps := []string{}
+	for _, mig := range FilterApplications(fileMigrations, applications) {
+		if _, exists := filesByApp[mig.Application]; !exists {
+			apps = append(apps, mig.Application)
+		}
+		filesByApp[mig.Application] = append(filesByApp[mig.Application], mig)
+	}
+
+	for _, app := range apps {
+		appFiles, appApplied := filesByApp[app], appliedByApp[app]
+		for idx := 0; idx < len(appApplied); idx++ {
+			if appFiles[idx].ID != appApplied[idx].ID {
+				if idx > 0 {
+					return fmt.Errorf(
+						"FileMigrations and AppliedMigrations of %s are out of sync after %s\n%s",
+						app, appFiles[idx-1].ID, moreInfo,
+					)
+				}
 				return fmt.Errorf(
-					"FileMigrations and AppliedMigrations are out of sync after %s\n%s",
-					fileMigrations[idx-1].ID, moreInfo,
+					"Local and applied migrations of %s are out of sync %s.\n%s",
+					app, "already at the first migration", moreInfo,
 				)
 			}
-			return fmt.Errorf(
-				"Local and applied migrations are out of sync already at the first migration.\n%s",
-				moreInfo,
-			)
 		}
 	}
 	return nil
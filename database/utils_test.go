@@ -73,7 +73,7 @@ func TestApplyBootstrap(t *testing.T) {
 
 	mock.ExpectExec("SELECT 1").WillReturnResult(sqlmock.NewResult(1, 1))
 
-	err := ApplyBootstrapMigration(db, dir)
+	err := ApplyBootstrapMigration(db, dir, nil)
 	if err != nil {
 		t.Fatalf("Received error during bootstrap: %v", err)
 	}
@@ -83,11 +83,32 @@ func TestApplyBootstrap(t *testing.T) {
 	}
 }
 
+func TestGetBootstrapSQLTemplate(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "")
+	defer os.RemoveAll(dir)
+	ioutil.WriteFile(
+		filepath.Join(dir, "bootstrap.sql"),
+		[]byte("-- //@TEMPLATE\nCREATE ROLE {{ .Vars.role }};"), 0777,
+	)
+
+	sql, err := GetBootstrapSQL(dir, map[string]string{"role": "db_viewer"})
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if expected := "-- //@TEMPLATE\nCREATE ROLE db_viewer;"; sql != expected {
+		t.Errorf("Expected '%s', but got '%s'", expected, sql)
+	}
+
+	if _, err := GetBootstrapSQL(dir, nil); err == nil {
+		t.Errorf("Expected an error for an undefined variable")
+	}
+}
+
 func TestApplyBootstrapNoFile(t *testing.T) {
 	db, mock, _ := sqlmock.New()
 	defer db.Close()
 
-	err := ApplyBootstrapMigration(db, ".")
+	err := ApplyBootstrapMigration(db, ".", nil)
 	if err != nil {
 		t.Fatalf("Received error during bootstrap: %v", err)
 	}
@@ -108,7 +129,7 @@ func TestApplyBootstrapFailure(t *testing.T) {
 	expectedSQLErr := errors.New("my-err")
 	mock.ExpectExec("SELECT 1").WillReturnError(expectedSQLErr)
 
-	err := ApplyBootstrapMigration(db, dir)
+	err := ApplyBootstrapMigration(db, dir, nil)
 	expectedError := fmt.Sprintf("Could not apply bootstrap.sql: %v", expectedSQLErr)
 	if err.Error() != expectedError {
 		t.Fatalf("Received different error during bootstrap: %v", err)
@@ -133,7 +154,7 @@ func TestEnsureConsistentMigrationsSuccess(t *testing.T) {
 	}
 	for idx, migration := range migrations {
 		t.Run(fmt.Sprintf("%d", idx), func(t *testing.T) {
-			err := EnsureConsistentMigrations(migration.file, migration.applied)
+			err := EnsureConsistentMigrations(migration.file, migration.applied, nil, false)
 			if err != nil {
 				t.Fatalf("Received error during EnsureConsistentMigrations: %v", err)
 			}
@@ -155,7 +176,7 @@ func TestEnsureConsistentMigrationsError(t *testing.T) {
 	}
 	for idx, migration := range migrations {
 		t.Run(fmt.Sprintf("%d", idx), func(t *testing.T) {
-			err := EnsureConsistentMigrations(migration.file, migration.applied)
+			err := EnsureConsistentMigrations(migration.file, migration.applied, nil, false)
 			if err == nil {
 				t.Fatalf(
 					dedent.Dedent(`
@@ -169,3 +190,51 @@ func TestEnsureConsistentMigrationsError(t *testing.T) {
 		})
 	}
 }
+
+func TestEnsureConsistentMigrationsPerApplication(t *testing.T) {
+	fileMigrations := []FileMigration{
+		{ID: "1", Application: "common"},
+		{ID: "2", Application: "sub_app"},
+		{ID: "3", Application: "common"},
+		{ID: "4", Application: "sub_app"},
+	}
+
+	err := EnsureConsistentMigrations(
+		fileMigrations, []AppliedMigration{{ID: "1"}, {ID: "3"}}, nil, false,
+	)
+	if err != nil {
+		t.Errorf("Expected no error for a gap between applications, but got: %v", err)
+	}
+
+	err = EnsureConsistentMigrations(
+		fileMigrations, []AppliedMigration{{ID: "1"}, {ID: "4"}}, nil, false,
+	)
+	if err == nil {
+		t.Errorf("Expected an error for a gap within an application")
+	}
+
+	err = EnsureConsistentMigrations(
+		fileMigrations, []AppliedMigration{{ID: "1"}, {ID: "4"}}, []string{"common"}, false,
+	)
+	if err != nil {
+		t.Errorf("Expected no error checking only the consistent application, but got: %v", err)
+	}
+}
+
+func TestEnsureConsistentMigrationsOutOfOrder(t *testing.T) {
+	fileMigrations := []FileMigration{{ID: "1"}, {ID: "2"}, {ID: "3"}}
+
+	err := EnsureConsistentMigrations(
+		fileMigrations, []AppliedMigration{{ID: "1"}, {ID: "3"}}, nil, true,
+	)
+	if err != nil {
+		t.Errorf("Expected no error for a gap with out of order migrations, but got: %v", err)
+	}
+
+	err = EnsureConsistentMigrations(
+		fileMigrations, []AppliedMigration{{ID: "1"}, {ID: "4"}}, nil, true,
+	)
+	if err == nil {
+		t.Errorf("Expected an error for an applied migration not found locally")
+	}
+}
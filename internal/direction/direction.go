@@ -18,3 +18,11 @@ var Directions = []struct {
 	{Up, "Up"},
 	{Down, "Down"},
 }
+
+// Name returns the lower case name of the direction (up or down), e.g. for logs and hooks
+func (dir MigrateDirection) Name() string {
+	if dir == Down {
+		return "down"
+	}
+	return "up"
+}
@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"go-migrations/database"
+	"go-migrations/database/config"
+)
+
+// FakeAdminWithSpy implements the database admin interface and saves method calls
+type FakeAdminWithSpy struct {
+	// Exists is returned by DatabaseExists
+	Exists bool
+	// Snapshots are returned by ListSnapshots and RestoreSnapshot
+	Snapshots []database.Snapshot
+
+	waitForStartCalls    []bool
+	createDatabaseCalls  []bool
+	dropDatabaseCalls    []bool
+	saveSnapshotCalls    [][]string
+	restoreSnapshotCalls []string
+	deleteSnapshotCalls  []string
+	closeCalls           []bool
+}
+
+// WaitForStart saves the call
+func (admin *FakeAdminWithSpy) WaitForStart(pollInterval time.Duration, retryCount int) error {
+	admin.waitForStartCalls = append(admin.waitForStartCalls, true)
+	return nil
+}
+
+// DatabaseExists returns Exists
+func (admin *FakeAdminWithSpy) DatabaseExists() (bool, error) {
+	return admin.Exists, nil
+}
+
+// CreateDatabase saves the call
+func (admin *FakeAdminWithSpy) CreateDatabase() error {
+	admin.createDatabaseCalls = append(admin.createDatabaseCalls, true)
+	admin.Exists = true
+	return nil
+}
+
+// AssertCreateDatabaseCalled checks for calls
+func (admin *FakeAdminWithSpy) AssertCreateDatabaseCalled(t *testing.T, expectCalled bool) {
+	wasCalled := len(admin.createDatabaseCalls) > 0
+
+	if wasCalled && !expectCalled {
+		t.Errorf("CreateDatabase was called but shouldn't have been")
+	} else if !wasCalled && expectCalled {
+		t.Errorf("CreateDatabase wasn't called but should have been")
+	}
+}
+
+// DropDatabase saves the call
+func (admin *FakeAdminWithSpy) DropDatabase() error {
+	admin.dropDatabaseCalls = append(admin.dropDatabaseCalls, true)
+	admin.Exists = false
+	return nil
+}
+
+// AssertDropDatabaseCalled checks for calls
+func (admin *FakeAdminWithSpy) AssertDropDatabaseCalled(t *testing.T, expectCalled bool) {
+	wasCalled := len(admin.dropDatabaseCalls) > 0
+
+	if wasCalled && !expectCalled {
+		t.Errorf("DropDatabase was called but shouldn't have been")
+	} else if !wasCalled && expectCalled {
+		t.Errorf("DropDatabase wasn't called but should have been")
+	}
+}
+
+// SaveSnapshot saves the call
+func (admin *FakeAdminWithSpy) SaveSnapshot(name, migrationID string) error {
+	admin.saveSnapshotCalls = append(admin.saveSnapshotCalls, []string{name, migrationID})
+	return nil
+}
+
+// AssertSaveSnapshotCalledWith checks for calls with name and migration ID
+func (admin *FakeAdminWithSpy) AssertSaveSnapshotCalledWith(t *testing.T, expected [][]string) {
+	if diff := pretty.Compare(admin.saveSnapshotCalls, expected); diff != "" {
+		t.Errorf("SaveSnapshot was called with unexpected args: (-got +want)\n%s", diff)
+	}
+}
+
+// RestoreSnapshot saves the call and returns the snapshot of the name
+func (admin *FakeAdminWithSpy) RestoreSnapshot(name string) (database.Snapshot, error) {
+	admin.restoreSnapshotCalls = append(admin.restoreSnapshotCalls, name)
+	for _, snapshot := range admin.Snapshots {
+		if snapshot.Name == name {
+			return snapshot, nil
+		}
+	}
+	return database.Snapshot{}, fmt.Errorf("Snapshot %s does not exist", name)
+}
+
+// AssertRestoreSnapshotCalledWith checks for calls with the names
+func (admin *FakeAdminWithSpy) AssertRestoreSnapshotCalledWith(t *testing.T, expected []string) {
+	if diff := pretty.Compare(admin.restoreSnapshotCalls, expected); diff != "" {
+		t.Errorf("RestoreSnapshot was called with unexpected args: (-got +want)\n%s", diff)
+	}
+}
+
+// ListSnapshots returns Snapshots
+func (admin *FakeAdminWithSpy) ListSnapshots() ([]database.Snapshot, error) {
+	return admin.Snapshots, nil
+}
+
+// DeleteSnapshot saves the call
+func (admin *FakeAdminWithSpy) DeleteSnapshot(name string) error {
+	admin.deleteSnapshotCalls = append(admin.deleteSnapshotCalls, name)
+	return nil
+}
+
+// AssertDeleteSnapshotCalledWith checks for calls with the names
+func (admin *FakeAdminWithSpy) AssertDeleteSnapshotCalledWith(t *testing.T, expected []string) {
+	if diff := pretty.Compare(admin.deleteSnapshotCalls, expected); diff != "" {
+		t.Errorf("DeleteSnapshot was called with unexpected args: (-got +want)\n%s", diff)
+	}
+}
+
+// Init does nothing
+func (admin *FakeAdminWithSpy) Init(_ config.Config) error {
+	return nil
+}
+
+// Close saves the call
+func (admin *FakeAdminWithSpy) Close() error {
+	admin.closeCalls = append(admin.closeCalls, true)
+	return nil
+}
+
+// AssertCloseCalled checks for calls
+func (admin *FakeAdminWithSpy) AssertCloseCalled(t *testing.T, expectCalled bool) {
+	wasCalled := len(admin.closeCalls) > 0
+
+	if wasCalled && !expectCalled {
+		t.Errorf("Close was called but shouldn't have been")
+	} else if !wasCalled && expectCalled {
+		t.Errorf("Close wasn't called but should have been")
+	}
+}
@@ -1,11 +1,11 @@
 package internal
 
 import (
-	"os"
+	"fmt"
+	"io"
 	"testing"
 	"time"
 
-	"github.com/jedib0t/go-pretty/v6/progress"
 	"github.com/kylelemons/godebug/pretty"
 
 	"go-migrations/database"
@@ -26,7 +26,26 @@ type applySpecificMigrationArgs struct {
 
 // FakeDbWithSpy implements the database interface and saves method calls
 type FakeDbWithSpy struct {
+	// FileMigrations are returned by GetFileMigrations
+	FileMigrations []database.FileMigration
+	// AppliedMigrations are returned by GetAppliedMigrations
+	AppliedMigrations []database.AppliedMigration
+	// Protected is returned by IsProtected
+	Protected bool
+	// ChangelogSquashes are returned by FindChangelogSquashes
+	ChangelogSquashes []database.FileMigration
+	// ChangelogPlan is returned by PlanMigrationsChangelog
+	ChangelogPlan []string
+	// Repeatables are returned by FindRepeatableMigrations
+	Repeatables []database.RepeatableMigration
+	// GenerateSeedErr is returned by GenerateSeedSQL after writing a part of the seed
+	GenerateSeedErr error
+
 	initCalls                       []bool
+	closeCalls                      []bool
+	setApplicationsCalls            [][]string
+	setAllowOutOfOrderCalls         []bool
+	baselineCalls                   []string
 	bootstrapCalls                  []bool
 	waitForStartCalls               []bool
 	ensureMigrationsChangelogCalls  []bool
@@ -34,9 +53,14 @@ type FakeDbWithSpy struct {
 	applyAllUpMigrationsCalls       []bool
 	getFileMigrationsCalls          []bool
 	getAppliedMigrationsCalls       []bool
-	generateSeedSQLCalls            []bool
+	generateSeedSQLCalls            []database.SeedOptions
 	applyMigrationsWithCountCalls   []applyMigrationsWithCountArgs
 	applySpecificMigrationCalls     []applySpecificMigrationArgs
+	markMigrationCalls              []applySpecificMigrationArgs
+	squashCalls                     [][]string
+	squashChangelogCalls            []bool
+	applyRepeatableMigrationsCalls  []bool
+	applyDataSeedsCalls             []bool
 }
 
 // WaitForStart saves the call
@@ -57,7 +81,7 @@ func (db *FakeDbWithSpy) AssertWaitForStartCalled(t *testing.T, expectCalled boo
 }
 
 // Bootstrap saves the call
-func (db *FakeDbWithSpy) Bootstrap() error {
+func (db *FakeDbWithSpy) Bootstrap(observer database.Observer) error {
 	db.bootstrapCalls = append(db.bootstrapCalls, true)
 	return nil
 }
@@ -73,10 +97,47 @@ func (db *FakeDbWithSpy) AssertBootstrapCalled(t *testing.T, expectCalled bool)
 	}
 }
 
-// GetFileMigrations saves the call
+// SetAllowOutOfOrder saves the call
+func (db *FakeDbWithSpy) SetAllowOutOfOrder(allow bool) {
+	db.setAllowOutOfOrderCalls = append(db.setAllowOutOfOrderCalls, allow)
+}
+
+// AllowsOutOfOrder returns the value of the last SetAllowOutOfOrder call
+func (db *FakeDbWithSpy) AllowsOutOfOrder() bool {
+	if len(db.setAllowOutOfOrderCalls) == 0 {
+		return false
+	}
+	return db.setAllowOutOfOrderCalls[len(db.setAllowOutOfOrderCalls)-1]
+}
+
+// IsProtected returns Protected
+func (db *FakeDbWithSpy) IsProtected() bool {
+	return db.Protected
+}
+
+// SetApplications saves the call
+func (db *FakeDbWithSpy) SetApplications(applications []string) {
+	db.setApplicationsCalls = append(db.setApplicationsCalls, applications)
+}
+
+// AssertSetApplicationsCalledWith checks the arguments of the last call
+func (db *FakeDbWithSpy) AssertSetApplicationsCalledWith(t *testing.T, applications []string) {
+	if len(db.setApplicationsCalls) == 0 {
+		t.Errorf("SetApplications wasn't called but should have been")
+		return
+	}
+	lastCall := db.setApplicationsCalls[len(db.setApplicationsCalls)-1]
+	if !StrSliceEqual(lastCall, applications) {
+		t.Errorf(
+			"SetApplications was called with '%v' instead of '%v'", lastCall, applications,
+		)
+	}
+}
+
+// GetFileMigrations saves the call and returns FileMigrations
 func (db *FakeDbWithSpy) GetFileMigrations() ([]database.FileMigration, error) {
 	db.getFileMigrationsCalls = append(db.getFileMigrationsCalls, true)
-	return nil, nil
+	return db.FileMigrations, nil
 }
 
 // AssertGetFileMigrationsCalled checks for calls
@@ -93,7 +154,7 @@ func (db *FakeDbWithSpy) AssertGetFileMigrationsCalled(t *testing.T, expectCalle
 // GetAppliedMigrations saves the call
 func (db *FakeDbWithSpy) GetAppliedMigrations() ([]database.AppliedMigration, error) {
 	db.getAppliedMigrationsCalls = append(db.getAppliedMigrationsCalls, true)
-	return nil, nil
+	return db.AppliedMigrations, nil
 }
 
 // AssertGetAppliedMigrationsCalled checks for calls
@@ -108,7 +169,7 @@ func (db *FakeDbWithSpy) AssertGetAppliedMigrationsCalled(t *testing.T, expectCa
 }
 
 // ApplyAllUpMigrations saves the call
-func (db *FakeDbWithSpy) ApplyAllUpMigrations(pw progress.Writer) error {
+func (db *FakeDbWithSpy) ApplyAllUpMigrations(observer database.Observer) error {
 	db.applyAllUpMigrationsCalls = append(db.applyAllUpMigrationsCalls, true)
 	return nil
 }
@@ -141,6 +202,11 @@ func (db *FakeDbWithSpy) AssertEnsureConsistentMigrationsCalled(t *testing.T, ex
 	}
 }
 
+// PlanMigrationsChangelog returns the ChangelogPlan
+func (db *FakeDbWithSpy) PlanMigrationsChangelog() ([]string, error) {
+	return db.ChangelogPlan, nil
+}
+
 // EnsureMigrationsChangelog saves the call
 func (db *FakeDbWithSpy) EnsureMigrationsChangelog() (bool, error) {
 	db.ensureMigrationsChangelogCalls = append(db.ensureMigrationsChangelogCalls, true)
@@ -158,10 +224,19 @@ func (db *FakeDbWithSpy) AssertEnsureMigrationsChangelogCalled(t *testing.T, exp
 	}
 }
 
-// GenerateSeedSQL saves the call
-func (db *FakeDbWithSpy) GenerateSeedSQL(f *os.File) error {
-	db.generateSeedSQLCalls = append(db.generateSeedSQLCalls, true)
-	return nil
+// FakeSeedSQL is written by GenerateSeedSQL
+const FakeSeedSQL = "SELECT 'seed';\n"
+
+// GenerateSeedSQL saves the call with the options and writes FakeSeedSQL (or a part of it
+// before returning GenerateSeedErr)
+func (db *FakeDbWithSpy) GenerateSeedSQL(w io.Writer, options database.SeedOptions) error {
+	db.generateSeedSQLCalls = append(db.generateSeedSQLCalls, options)
+	if db.GenerateSeedErr != nil {
+		io.WriteString(w, FakeSeedSQL[:len(FakeSeedSQL)/2])
+		return db.GenerateSeedErr
+	}
+	_, err := io.WriteString(w, FakeSeedSQL)
+	return err
 }
 
 // AssertGenerateSeedSQLCalled checks for calls
@@ -175,14 +250,104 @@ func (db *FakeDbWithSpy) AssertGenerateSeedSQLCalled(t *testing.T, expectCalled
 	}
 }
 
+// AssertGenerateSeedSQLCalledWith checks for a call with the options
+func (db *FakeDbWithSpy) AssertGenerateSeedSQLCalledWith(
+	t *testing.T, options database.SeedOptions,
+) {
+	for _, callOptions := range db.generateSeedSQLCalls {
+		if callOptions == options {
+			return
+		}
+	}
+	t.Errorf("GenerateSeedSQL wasn't called with %+v, but with %+v", options, db.generateSeedSQLCalls)
+}
+
+// Baseline saves the call
+func (db *FakeDbWithSpy) Baseline(toID string) error {
+	db.baselineCalls = append(db.baselineCalls, toID)
+	return nil
+}
+
+// AssertBaselineCalledWith checks the arguments of the last call
+func (db *FakeDbWithSpy) AssertBaselineCalledWith(t *testing.T, toID string) {
+	if len(db.baselineCalls) == 0 {
+		t.Errorf("Baseline wasn't called but should have been")
+		return
+	}
+	if lastCall := db.baselineCalls[len(db.baselineCalls)-1]; lastCall != toID {
+		t.Errorf("Baseline was called with '%s' instead of '%s'", lastCall, toID)
+	}
+}
+
+// Squash saves the call
+func (db *FakeDbWithSpy) Squash(beforeID, application string) (database.FileMigration, error) {
+	db.squashCalls = append(db.squashCalls, []string{beforeID, application})
+	return database.FileMigration{
+		ID: beforeID, Application: application, Filename: beforeID + "_squashed.sql",
+	}, nil
+}
+
+// AssertSquashCalledWith checks the arguments of the last call
+func (db *FakeDbWithSpy) AssertSquashCalledWith(t *testing.T, beforeID, application string) {
+	if len(db.squashCalls) == 0 {
+		t.Errorf("Squash wasn't called but should have been")
+		return
+	}
+	expected := []string{beforeID, application}
+	if diff := pretty.Compare(expected, db.squashCalls[len(db.squashCalls)-1]); diff != "" {
+		t.Errorf("Squash was called with other arguments:\n%s", diff)
+	}
+}
+
+// FindChangelogSquashes returns the configured squashes
+func (db *FakeDbWithSpy) FindChangelogSquashes() ([]database.FileMigration, error) {
+	return db.ChangelogSquashes, nil
+}
+
+// SquashChangelog saves the call
+func (db *FakeDbWithSpy) SquashChangelog() error {
+	db.squashChangelogCalls = append(db.squashChangelogCalls, true)
+	return nil
+}
+
+// AssertSquashChangelogCalled checks for calls
+func (db *FakeDbWithSpy) AssertSquashChangelogCalled(t *testing.T, expectCalled bool) {
+	wasCalled := len(db.squashChangelogCalls) > 0
+
+	if wasCalled && !expectCalled {
+		t.Errorf("SquashChangelog was called but shouldn't have been")
+	} else if !wasCalled && expectCalled {
+		t.Errorf("SquashChangelog wasn't called but should have been")
+	}
+}
+
 // Init saves the call
 func (db *FakeDbWithSpy) Init(_ config.Config) error {
 	db.initCalls = append(db.initCalls, true)
 	return nil
 }
 
+// Close saves the call
+func (db *FakeDbWithSpy) Close() error {
+	db.closeCalls = append(db.closeCalls, true)
+	return nil
+}
+
+// AssertCloseCalled checks for calls
+func (db *FakeDbWithSpy) AssertCloseCalled(t *testing.T, expectCalled bool) {
+	wasCalled := len(db.closeCalls) > 0
+
+	if wasCalled && !expectCalled {
+		t.Errorf("Close was called but shouldn't have been")
+	} else if !wasCalled && expectCalled {
+		t.Errorf("Close wasn't called but should have been")
+	}
+}
+
 // ApplySpecificMigration applies one up migration by a filter
-func (db *FakeDbWithSpy) ApplySpecificMigration(filter string, direction direction.MigrateDirection) error {
+func (db *FakeDbWithSpy) ApplySpecificMigration(
+	filter string, direction direction.MigrateDirection, observer database.Observer,
+) error {
 	db.applySpecificMigrationCalls = append(
 		db.applySpecificMigrationCalls,
 		applySpecificMigrationArgs{filter: filter, direction: direction},
@@ -221,9 +386,110 @@ func (db *FakeDbWithSpy) AssertApplySpecificMigrationCalledWith(
 	}
 }
 
+// ApplyRepeatableMigrations saves the call
+func (db *FakeDbWithSpy) ApplyRepeatableMigrations(observer database.Observer) (int, error) {
+	db.applyRepeatableMigrationsCalls = append(db.applyRepeatableMigrationsCalls, true)
+	return 0, nil
+}
+
+// AssertApplyRepeatableMigrationsCalled checks for calls
+func (db *FakeDbWithSpy) AssertApplyRepeatableMigrationsCalled(t *testing.T, expectCalled bool) {
+	wasCalled := len(db.applyRepeatableMigrationsCalls) > 0
+
+	if wasCalled && !expectCalled {
+		t.Errorf("ApplyRepeatableMigrations was called but shouldn't have been")
+	} else if !wasCalled && expectCalled {
+		t.Errorf("ApplyRepeatableMigrations wasn't called but should have been")
+	}
+}
+
+// FindRepeatableMigrations returns the Repeatables
+func (db *FakeDbWithSpy) FindRepeatableMigrations() ([]database.RepeatableMigration, error) {
+	return db.Repeatables, nil
+}
+
+// ApplyDataSeeds saves the call
+func (db *FakeDbWithSpy) ApplyDataSeeds(observer database.Observer) (int, error) {
+	db.applyDataSeedsCalls = append(db.applyDataSeedsCalls, true)
+	return 0, nil
+}
+
+// AssertApplyDataSeedsCalled checks for calls
+func (db *FakeDbWithSpy) AssertApplyDataSeedsCalled(t *testing.T, expectCalled bool) {
+	wasCalled := len(db.applyDataSeedsCalls) > 0
+
+	if wasCalled && !expectCalled {
+		t.Errorf("ApplyDataSeeds was called but shouldn't have been")
+	} else if !wasCalled && expectCalled {
+		t.Errorf("ApplyDataSeeds wasn't called but should have been")
+	}
+}
+
+// FindMigration returns a migration named by the filter
+func (db *FakeDbWithSpy) FindMigration(
+	filter string, direction direction.MigrateDirection,
+) (database.FileMigration, error) {
+	return database.FileMigration{ID: "1", Filename: filter}, nil
+}
+
+// FindMigrationsWithCount returns count migrations (or three for all)
+func (db *FakeDbWithSpy) FindMigrationsWithCount(
+	count uint, all bool, direction direction.MigrateDirection,
+) ([]database.FileMigration, error) {
+	if all {
+		count = 3
+	}
+	migrations := []database.FileMigration{}
+	for idx := uint(1); idx <= count; idx++ {
+		migrations = append(migrations, database.FileMigration{
+			ID: fmt.Sprint(idx), Filename: fmt.Sprintf("%d_migration.sql", idx),
+		})
+	}
+	return migrations, nil
+}
+
+// MarkMigration saves the call with the filename of the migration
+func (db *FakeDbWithSpy) MarkMigration(
+	migration database.FileMigration, direction direction.MigrateDirection,
+) error {
+	db.markMigrationCalls = append(
+		db.markMigrationCalls,
+		applySpecificMigrationArgs{filter: migration.Filename, direction: direction},
+	)
+	return nil
+}
+
+// AssertMarkMigrationCalled checks for at least one call
+func (db *FakeDbWithSpy) AssertMarkMigrationCalled(t *testing.T, expectCalled bool) (
+	wasCalled bool,
+) {
+	wasCalled = len(db.markMigrationCalls) > 0
+
+	if wasCalled && !expectCalled {
+		t.Errorf("MarkMigration was called but shouldn't have been")
+	} else if !wasCalled && expectCalled {
+		t.Errorf("MarkMigration wasn't called but should have been")
+	}
+	return wasCalled
+}
+
+// AssertMarkMigrationCalledWith checks the arguments of the last call
+func (db *FakeDbWithSpy) AssertMarkMigrationCalledWith(
+	t *testing.T, filename string, direction direction.MigrateDirection,
+) {
+	if !db.AssertMarkMigrationCalled(t, true) {
+		return
+	}
+	lastCall := db.markMigrationCalls[len(db.markMigrationCalls)-1]
+	expectedCall := applySpecificMigrationArgs{filter: filename, direction: direction}
+	if lastCall != expectedCall {
+		t.Errorf("MarkMigration was called with '%v' instead of '%v'", lastCall, expectedCall)
+	}
+}
+
 // ApplyMigrationsWithCount applies Up migration by a count
 func (db *FakeDbWithSpy) ApplyMigrationsWithCount(
-	count uint, all bool, dir direction.MigrateDirection,
+	count uint, all bool, dir direction.MigrateDirection, observer database.Observer,
 ) error {
 	db.applyMigrationsWithCountCalls = append(
 		db.applyMigrationsWithCountCalls,
@@ -0,0 +1,119 @@
+package logging
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"runtime"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Formats are the supported log formats
+var Formats = []string{"text", "json"}
+
+// Configure sets the format (text or json), the level (DEBUG, INFO, WARN or ERROR) and the output
+// of the logger. Without a file the logs are written to stderr. The returned closer closes the
+// log file (after the last entry, e.g. the summary) and writes further logs to stderr again
+func Configure(format, level, file string) (io.Closer, error) {
+	debug := level == "DEBUG"
+
+	switch format {
+	case "text":
+		formatter := &log.TextFormatter{DisableTimestamp: true, PadLevelText: true}
+		if debug {
+			formatter = &log.TextFormatter{
+				FullTimestamp: true, PadLevelText: true, CallerPrettyfier: callerPrettyfier,
+			}
+		}
+		log.SetFormatter(formatter)
+	case "json":
+		log.SetFormatter(&log.JSONFormatter{CallerPrettyfier: callerPrettyfier})
+	default:
+		return noFile{}, fmt.Errorf("Unknown log format %s, use one of %v", format, Formats)
+	}
+
+	switch level {
+	case "DEBUG":
+		log.SetLevel(log.DebugLevel)
+	case "ERROR":
+		log.SetLevel(log.ErrorLevel)
+	case "WARN":
+		log.SetLevel(log.WarnLevel)
+	default:
+		log.SetLevel(log.InfoLevel)
+	}
+	log.SetReportCaller(debug)
+
+	addHookOnce.Do(func() { log.AddHook(&defaultFields) })
+
+	if file == "" {
+		return noFile{}, nil
+	}
+	f, err := os.OpenFile(file, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		return noFile{}, fmt.Errorf("Couldn't open log file: %v", err)
+	}
+	log.SetOutput(f)
+	return logFile{f}, nil
+}
+
+// logFile is the output of the logger until it is closed
+type logFile struct {
+	file *os.File
+}
+
+func (f logFile) Close() error {
+	log.SetOutput(os.Stderr)
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("Couldn't close log file: %v", err)
+	}
+	return nil
+}
+
+// noFile is returned without a log file
+type noFile struct{}
+
+func (noFile) Close() error {
+	return nil
+}
+
+func callerPrettyfier(f *runtime.Frame) (string, string) {
+	filename := path.Base(f.File)
+	return "", fmt.Sprintf(" %s:%d", filename, f.Line)
+}
+
+// fieldsHook adds fields to all log entries, which do not set them explicitly
+type fieldsHook struct {
+	mutex  sync.Mutex
+	fields log.Fields
+}
+
+var defaultFields = fieldsHook{fields: log.Fields{}}
+var addHookOnce sync.Once
+
+func (hook *fieldsHook) Levels() []log.Level {
+	return log.AllLevels
+}
+
+func (hook *fieldsHook) Fire(entry *log.Entry) error {
+	hook.mutex.Lock()
+	defer hook.mutex.Unlock()
+
+	for key, value := range hook.fields {
+		if _, ok := entry.Data[key]; !ok {
+			entry.Data[key] = value
+		}
+	}
+	return nil
+}
+
+// SetField adds the field (e.g. the environment) to all following log entries
+func SetField(key string, value interface{}) {
+	defaultFields.mutex.Lock()
+	defer defaultFields.mutex.Unlock()
+
+	defaultFields.fields[key] = value
+}
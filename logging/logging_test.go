@@ -0,0 +1,166 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kylelemons/godebug/pretty"
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+// configureJSONFile configures the logger to write JSON into a temporary file and returns
+// a function to read the written entries
+func configureJSONFile(t *testing.T) func() []map[string]interface{} {
+	dir, _ := ioutil.TempDir("", "go_mig")
+	logFile := filepath.Join(dir, "log.json")
+	closer, err := Configure("json", "INFO", logFile)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+
+	return func() []map[string]interface{} {
+		defer os.RemoveAll(dir)
+		if err := closer.Close(); err != nil {
+			t.Errorf("Expected no error closing the log file, but got: %v", err)
+		}
+		log.SetOutput(ioutil.Discard)
+
+		f, _ := os.Open(logFile)
+		defer f.Close()
+		entries := []map[string]interface{}{}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			entry := map[string]interface{}{}
+			if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+				t.Errorf("Expected a JSON log line, but got %s", scanner.Text())
+			}
+			delete(entry, "time")
+			entries = append(entries, entry)
+		}
+		return entries
+	}
+}
+
+func resetSummary() {
+	summary.started = false
+	summary.applied = nil
+	summary.rolledBack = nil
+	summary.repeatables = nil
+	summary.seeds = nil
+}
+
+func TestConfigureUnknownFormat(t *testing.T) {
+	if _, err := Configure("xml", "INFO", ""); err == nil {
+		t.Errorf("Expected an error for an unknown format")
+	}
+}
+
+func TestConfigureCloseLogFile(t *testing.T) {
+	dir, _ := ioutil.TempDir("", "go_mig")
+	defer os.RemoveAll(dir)
+	defer log.SetOutput(ioutil.Discard)
+
+	logFile := filepath.Join(dir, "log.txt")
+	closer, err := Configure("text", "INFO", logFile)
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if err := closer.Close(); err != nil {
+		t.Errorf("Expected no error closing the log file, but got: %v", err)
+	}
+	if log.StandardLogger().Out != os.Stderr {
+		t.Errorf("Expected the logs to be written to stderr after closing the log file")
+	}
+	if err := closer.Close(); err == nil {
+		t.Errorf("Expected an error closing the log file twice")
+	}
+
+	closer, err = Configure("text", "INFO", "")
+	if err != nil {
+		t.Fatalf("Expected no error, but got: %v", err)
+	}
+	if err := closer.Close(); err != nil {
+		t.Errorf("Expected no error without a log file, but got: %v", err)
+	}
+}
+
+func TestSummaryObserver(t *testing.T) {
+	resetSummary()
+	StartSummary()
+	readEntries := configureJSONFile(t)
+	SetField("environment", "ci")
+	defer delete(defaultFields.fields, "environment")
+
+	events := []database.Event{
+		{Type: database.RunStarted, Direction: direction.Up, Total: 2},
+		{
+			Type: database.MigrationFinished, Direction: direction.Up,
+			Migration: database.FileMigration{ID: "20200101000001"},
+		},
+		{
+			Type: database.MigrationFailed, Direction: direction.Up,
+			Migration: database.FileMigration{ID: "20200101000003"}, Err: errors.New("some error"),
+		},
+		{
+			Type: database.MigrationFinished, Direction: direction.Down,
+			Migration: database.FileMigration{ID: "20200101000002"},
+		},
+		{Type: database.RepeatableApplied, Direction: direction.Up, File: "common/R_views.sql"},
+		{Type: database.SeedApplied, Direction: direction.Up, File: "01_users.sql"},
+	}
+	for _, event := range events {
+		SummaryObserver.Notify(event)
+	}
+	LogSummary(nil)
+
+	entries := readEntries()
+	if len(entries) != 1 {
+		t.Fatalf("Expected 1 log entry, but got %v", entries)
+	}
+	delete(entries[0], "duration_ms")
+	expected := map[string]interface{}{
+		"level": "info", "msg": "Run completed with 1 applied and 1 rolled back migrations",
+		"environment": "ci", "applied": []interface{}{"20200101000001"},
+		"rolled_back": []interface{}{"20200101000002"},
+		"repeatables": []interface{}{"common/R_views.sql"}, "seeds": []interface{}{"01_users.sql"},
+	}
+	if diff := pretty.Compare(entries[0], expected); diff != "" {
+		t.Errorf("Got unexpected summary: (-got +want)\n%s", diff)
+	}
+}
+
+func TestLogSummaryFailedRun(t *testing.T) {
+	resetSummary()
+	StartSummary()
+	readEntries := configureJSONFile(t)
+
+	LogSummary(errors.New("some error"))
+
+	entries := readEntries()
+	if len(entries) != 1 || entries[0]["level"] != "error" || entries[0]["error"] != "some error" {
+		t.Fatalf("Expected a failed summary, but got %v", entries)
+	}
+	if applied, ok := entries[0]["applied"].([]interface{}); !ok || len(applied) != 0 {
+		t.Errorf("Expected an empty list of applied migrations, but got %v", entries[0]["applied"])
+	}
+}
+
+func TestLogSummaryNotStarted(t *testing.T) {
+	resetSummary()
+	readEntries := configureJSONFile(t)
+
+	LogSummary(nil)
+	LogSummary(errors.New("some error"))
+
+	if entries := readEntries(); len(entries) != 0 {
+		t.Errorf("Expected no summary for commands without migrations, but got %v", entries)
+	}
+}
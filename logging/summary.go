@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+// summary collects the migrations of the run for the summary at its end
+var summary struct {
+	mutex      sync.Mutex
+	started    bool
+	start      time.Time
+	applied    []string
+	rolledBack []string
+	// repeatables and seeds are the files of the applied repeatable migrations and data seeds
+	repeatables []string
+	seeds       []string
+}
+
+// StartSummary starts the summary of a command applying or rolling back migrations. Other
+// commands end without a summary
+func StartSummary() {
+	summary.mutex.Lock()
+	defer summary.mutex.Unlock()
+
+	summary.started = true
+	summary.start = time.Now()
+}
+
+// SummaryObserver records the applied and rolled back migrations as well as the applied
+// repeatable migrations and data seeds for the summary of the run
+var SummaryObserver = database.ObserverFunc(func(event database.Event) {
+	summary.mutex.Lock()
+	defer summary.mutex.Unlock()
+
+	switch event.Type {
+	case database.MigrationFinished:
+		if event.Direction == direction.Down {
+			summary.rolledBack = append(summary.rolledBack, event.Migration.ID)
+		} else {
+			summary.applied = append(summary.applied, event.Migration.ID)
+		}
+	case database.RepeatableApplied:
+		summary.repeatables = append(summary.repeatables, event.File)
+	case database.SeedApplied:
+		summary.seeds = append(summary.seeds, event.File)
+	}
+})
+
+// LogSummary logs the migrations applied and rolled back within the run and its total duration,
+// if the summary was started
+func LogSummary(err error) {
+	summary.mutex.Lock()
+	defer summary.mutex.Unlock()
+	if !summary.started {
+		return
+	}
+
+	applied, rolledBack := summary.applied, summary.rolledBack
+	if applied == nil {
+		applied = []string{}
+	}
+	if rolledBack == nil {
+		rolledBack = []string{}
+	}
+	fields := log.Fields{
+		"applied":     applied,
+		"rolled_back": rolledBack,
+		"duration_ms": time.Since(summary.start).Milliseconds(),
+	}
+	// repeatable migrations and data seeds are only listed if any were applied
+	if len(summary.repeatables) > 0 {
+		fields["repeatables"] = summary.repeatables
+	}
+	if len(summary.seeds) > 0 {
+		fields["seeds"] = summary.seeds
+	}
+	entry := log.WithFields(fields)
+	if err != nil {
+		entry.WithError(err).Errorf(
+			"Run failed after %d applied and %d rolled back migrations",
+			len(applied), len(rolledBack),
+		)
+		return
+	}
+	entry.Infof(
+		"Run completed with %d applied and %d rolled back migrations",
+		len(applied), len(rolledBack),
+	)
+}
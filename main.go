@@ -1,40 +1,87 @@
 package main
 
 import (
+	"errors"
 	"fmt"
+	"io"
 	"math/rand"
 	"os"
-	"path"
-	"runtime"
-
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
 	"github.com/urfave/cli/v2"
 
+	"go-migrations/commands"
 	"go-migrations/commands/bootstrap"
 	"go-migrations/commands/createseed"
+	"go-migrations/commands/dbadmin"
 	"go-migrations/commands/migrate"
+	"go-migrations/commands/seed"
+	"go-migrations/commands/snapshot"
 	"go-migrations/commands/start"
+	"go-migrations/database"
+	"go-migrations/logging"
 	"go-migrations/utils"
 )
 
 func errExitHandler(c *cli.Context, err error) {
+	// the handler is also called after showing the help of the app
+	if err == nil {
+		return
+	}
+	logging.LogSummary(err)
+
+	var fileErrors database.FileErrors
+	if errors.As(err, &fileErrors) {
+		for _, fileError := range fileErrors {
+			log.Error(fileError)
+		}
+		log.Fatalf("Found %d problems in the migration files", len(fileErrors))
+	}
 	log.Fatal(err)
 }
 
 func main() {
 	rand.Seed(time.Now().UnixNano())
-	initLogger()
+	logLevel := utils.GetEnvDefault("LOG_LEVEL", "INFO")
+	// the log file is opened by app.Before, which doesn't run for every invocation
+	var logFile io.Closer
 
 	app := cli.NewApp()
 	app.EnableBashCompletion = true
 	app.ExitErrHandler = errExitHandler
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name: "log-format", Value: "text",
+			Usage: fmt.Sprintf("format of the logs (%s)", strings.Join(logging.Formats, ", ")),
+		},
+		&cli.StringFlag{
+			Name:  "log-file",
+			Usage: "append the logs to this file instead of writing them to stderr",
+		},
+		&cli.StringFlag{
+			Name: "progress", Value: "auto",
+			Usage: fmt.Sprintf(
+				"how to show the progress of migrations (%s). auto shows a progress bar "+
+					"in terminals and logs the migrations otherwise",
+				strings.Join(commands.ProgressModes, ", "),
+			),
+		},
+	}
+	app.Before = func(c *cli.Context) (err error) {
+		logFile, err = logging.Configure(c.String("log-format"), logLevel, c.String("log-file"))
+		return err
+	}
 	app.Commands = []*cli.Command{
 		start.StartCommand,
+		start.StopCommand,
 		bootstrap.BootstrapCommand,
 		migrate.MigrateCommands,
 		createseed.CreateSeedCommand,
+		seed.SeedCommand,
+		dbadmin.DbCommands,
+		snapshot.SnapshotCommands,
 	}
 
 	err := app.Run(os.Args)
@@ -42,32 +89,11 @@ func main() {
 		// this should not be called, as we have an exiting error handler
 		errExitHandler(nil, err)
 	}
-}
-
-func initLogger() {
-	log.SetFormatter(&log.TextFormatter{
-		DisableTimestamp: true,
-		PadLevelText:     true,
-	})
-
-	switch logLevel := utils.GetEnvDefault("LOG_LEVEL", "INFO"); logLevel {
-	case "DEBUG":
-		log.SetLevel(log.DebugLevel)
-		log.SetReportCaller(true)
-		formatter := &log.TextFormatter{
-			FullTimestamp: true,
-			PadLevelText:  true,
-			CallerPrettyfier: func(f *runtime.Frame) (string, string) {
-				filename := path.Base(f.File)
-				return "", fmt.Sprintf(" %s:%d", filename, f.Line)
-			},
+	// only commands applying or rolling back migrations started a summary
+	logging.LogSummary(nil)
+	if logFile != nil {
+		if err := logFile.Close(); err != nil {
+			log.Fatal(err)
 		}
-		log.SetFormatter(formatter)
-	case "ERROR":
-		log.SetLevel(log.ErrorLevel)
-	case "WARN":
-		log.SetLevel(log.WarnLevel)
-	default:
-		log.SetLevel(log.InfoLevel)
 	}
 }
@@ -0,0 +1,55 @@
+package observer
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+
+	"go-migrations/database"
+)
+
+// jsonEvent is the JSON representation of an event
+type jsonEvent struct {
+	Event       database.EventType `json:"event"`
+	Direction   string             `json:"direction"`
+	MigrationID string             `json:"migration_id,omitempty"`
+	App         string             `json:"app,omitempty"`
+	Hook        string             `json:"hook,omitempty"`
+	File        string             `json:"file,omitempty"`
+	Total       int                `json:"total,omitempty"`
+	DurationMs  int64              `json:"duration_ms,omitempty"`
+	Error       string             `json:"error,omitempty"`
+}
+
+// JSON writes every event as JSON object on a line of its own
+type JSON struct {
+	mutex   sync.Mutex
+	encoder *json.Encoder
+}
+
+// NewJSON returns an observer writing the events to the writer
+func NewJSON(w io.Writer) *JSON {
+	return &JSON{encoder: json.NewEncoder(w)}
+}
+
+// Notify writes the event
+func (j *JSON) Notify(event database.Event) {
+	encoded := jsonEvent{
+		Event:       event.Type,
+		Direction:   event.Direction.Name(),
+		MigrationID: event.Migration.ID,
+		App:         event.Migration.Application,
+		Hook:        event.Hook,
+		File:        event.File,
+		Total:       event.Total,
+		DurationMs:  event.Duration.Milliseconds(),
+	}
+	if event.Err != nil {
+		encoded.Error = event.Err.Error()
+	}
+
+	j.mutex.Lock()
+	defer j.mutex.Unlock()
+	// an event, which cannot be written, must not abort the migrations
+	_ = j.encoder.Encode(encoded)
+}
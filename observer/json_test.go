@@ -0,0 +1,44 @@
+package observer
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+func TestJSON(t *testing.T) {
+	var buffer bytes.Buffer
+	observer := NewJSON(&buffer)
+
+	migration := database.FileMigration{ID: "20200101000001", Application: "common"}
+	observer.Notify(database.Event{Type: database.RunStarted, Direction: direction.Down, Total: 1})
+	observer.Notify(database.Event{
+		Type: database.MigrationFailed, Direction: direction.Down, Migration: migration,
+		Duration: 20 * time.Millisecond, Err: errors.New("some error"),
+	})
+	observer.Notify(database.Event{
+		Type: database.SeedApplied, Direction: direction.Up, File: "01_users.sql",
+		Duration: 5 * time.Millisecond,
+	})
+
+	expected := []map[string]interface{}{
+		{"event": "run_started", "direction": "down", "total": float64(1)},
+		{
+			"event": "migration_failed", "direction": "down", "migration_id": "20200101000001",
+			"app": "common", "duration_ms": float64(20), "error": "some error",
+		},
+		{
+			"event": "seed_applied", "direction": "up", "file": "01_users.sql",
+			"duration_ms": float64(5),
+		},
+	}
+	if diff := pretty.Compare(readJSONLines(t, &buffer), expected); diff != "" {
+		t.Errorf("Got unexpected events: (-got +want)\n%s", diff)
+	}
+}
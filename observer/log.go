@@ -0,0 +1,62 @@
+package observer
+
+import (
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database"
+)
+
+// Log logs every event with structured fields (for CI and log aggregation)
+var Log = database.ObserverFunc(func(event database.Event) {
+	entry := log.WithField("direction", event.Direction.Name())
+	if event.Migration.ID != "" {
+		entry = entry.WithFields(log.Fields{
+			"migration_id": event.Migration.ID,
+			"app":          event.Migration.Application,
+		})
+	}
+
+	switch event.Type {
+	case database.RunStarted:
+		entry.WithField("total", event.Total).Debugf("Applying %d migrations", event.Total)
+	case database.RunFinished:
+		entry.WithField("duration_ms", event.Duration.Milliseconds()).Debug("Finished migrations")
+	case database.MigrationStarted:
+		entry.Debug("Applying migration")
+	case database.MigrationFinished:
+		entry.WithField("duration_ms", event.Duration.Milliseconds()).Info("Applied migration")
+	case database.MigrationFailed:
+		entry.WithField("duration_ms", event.Duration.Milliseconds()).
+			WithError(event.Err).Error("Migration failed")
+	case database.VerifyPassed:
+		entry.Debug("Verify passed")
+	case database.VerifyFailed:
+		entry.WithError(event.Err).Error("Verify failed")
+	case database.BootstrapApplied:
+		log.Info("Applied bootstrap migration")
+	case database.HookFinished:
+		entry.WithFields(log.Fields{
+			"hook": event.Hook, "duration_ms": event.Duration.Milliseconds(),
+		}).Info("Ran hook")
+	case database.HookFailed:
+		entry.WithFields(log.Fields{
+			"hook": event.Hook, "duration_ms": event.Duration.Milliseconds(),
+		}).WithError(event.Err).Error("Hook failed")
+	case database.RepeatableApplied:
+		entry.WithFields(log.Fields{
+			"file": event.File, "duration_ms": event.Duration.Milliseconds(),
+		}).Info("Applied repeatable migration")
+	case database.RepeatableFailed:
+		entry.WithFields(log.Fields{
+			"file": event.File, "duration_ms": event.Duration.Milliseconds(),
+		}).WithError(event.Err).Error("Repeatable migration failed")
+	case database.SeedApplied:
+		entry.WithFields(log.Fields{
+			"file": event.File, "duration_ms": event.Duration.Milliseconds(),
+		}).Info("Applied data seed")
+	case database.SeedFailed:
+		entry.WithFields(log.Fields{
+			"file": event.File, "duration_ms": event.Duration.Milliseconds(),
+		}).WithError(event.Err).Error("Data seed failed")
+	}
+})
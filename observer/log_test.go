@@ -0,0 +1,82 @@
+package observer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/kylelemons/godebug/pretty"
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+// readJSONLines parses every line of the buffer as JSON object (without its time)
+func readJSONLines(t *testing.T, buffer *bytes.Buffer) []map[string]interface{} {
+	lines := []map[string]interface{}{}
+	scanner := bufio.NewScanner(buffer)
+	for scanner.Scan() {
+		line := map[string]interface{}{}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Errorf("Expected a JSON line, but got %s", scanner.Text())
+		}
+		delete(line, "time")
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func TestLog(t *testing.T) {
+	var buffer bytes.Buffer
+	log.SetOutput(&buffer)
+	log.SetFormatter(&log.JSONFormatter{})
+	defer log.SetFormatter(&log.TextFormatter{})
+	defer log.SetOutput(os.Stderr)
+
+	migration := database.FileMigration{ID: "20200101000001", Application: "common"}
+	Log.Notify(database.Event{Type: database.RunStarted, Direction: direction.Up, Total: 2})
+	Log.Notify(database.Event{
+		Type: database.MigrationFinished, Direction: direction.Up, Migration: migration,
+		Duration: 1500 * time.Millisecond,
+	})
+	Log.Notify(database.Event{
+		Type: database.MigrationFailed, Direction: direction.Down, Migration: migration,
+		Err: errors.New("some error"),
+	})
+	Log.Notify(database.Event{
+		Type: database.HookFailed, Direction: direction.Up, Hook: "_hooks/after_up.sql",
+		Duration: 20 * time.Millisecond, Err: errors.New("timed out"),
+	})
+	Log.Notify(database.Event{
+		Type: database.RepeatableApplied, Direction: direction.Up, File: "common/R_views.sql",
+		Duration: 30 * time.Millisecond,
+	})
+
+	expected := []map[string]interface{}{
+		{
+			"level": "info", "msg": "Applied migration", "migration_id": "20200101000001",
+			"app": "common", "direction": "up", "duration_ms": float64(1500),
+		},
+		{
+			"level": "error", "msg": "Migration failed", "migration_id": "20200101000001",
+			"app": "common", "direction": "down", "duration_ms": float64(0),
+			"error": "some error",
+		},
+		{
+			"level": "error", "msg": "Hook failed", "hook": "_hooks/after_up.sql",
+			"direction": "up", "duration_ms": float64(20), "error": "timed out",
+		},
+		{
+			"level": "info", "msg": "Applied repeatable migration", "file": "common/R_views.sql",
+			"direction": "up", "duration_ms": float64(30),
+		},
+	}
+	if diff := pretty.Compare(readJSONLines(t, &buffer), expected); diff != "" {
+		t.Errorf("Got unexpected log entries: (-got +want)\n%s", diff)
+	}
+}
@@ -0,0 +1,69 @@
+package observer
+
+import (
+	"io"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/progress"
+	log "github.com/sirupsen/logrus"
+
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+const updateFrequency = time.Millisecond * 250
+
+// Progress shows every run as progress bar (for terminals)
+type Progress struct {
+	pw      progress.Writer
+	tracker *progress.Tracker
+	// rendered is closed after rendering the run stopped
+	rendered chan struct{}
+}
+
+// NewProgress returns an observer rendering the progress bars to the writer
+func NewProgress(w io.Writer) *Progress {
+	pw := progress.NewWriter()
+	pw.SetAutoStop(true)
+	pw.SetTrackerPosition(progress.PositionRight)
+	pw.SetUpdateFrequency(updateFrequency)
+	pw.SetOutputWriter(w)
+	return &Progress{pw: pw}
+}
+
+// Notify updates the progress bar of the run. Migration events outside of a run (without
+// RunStarted) are not shown
+func (p *Progress) Notify(event database.Event) {
+	switch event.Type {
+	case database.RunStarted:
+		message := "Applying migrations"
+		if event.Direction == direction.Down {
+			message = "Rolling back migrations"
+		}
+		p.tracker = &progress.Tracker{Message: message, Total: int64(event.Total)}
+		p.pw.AppendTracker(p.tracker)
+		p.rendered = make(chan struct{})
+		go func(rendered chan struct{}) {
+			p.pw.Render()
+			close(rendered)
+		}(p.rendered)
+	case database.MigrationFinished:
+		if p.tracker != nil {
+			p.tracker.Increment(1)
+		}
+	case database.RunFinished:
+		if p.tracker == nil {
+			return
+		}
+		p.tracker.MarkAsDone()
+		// the rendering stops with the next update after all trackers are done
+		<-p.rendered
+		p.tracker, p.rendered = nil, nil
+	case database.BootstrapApplied:
+		log.Info("Applied bootstrap migration")
+	case database.RepeatableApplied:
+		log.Infof("Applied repeatable migration %s", event.File)
+	case database.SeedApplied:
+		log.Infof("Applied data seed %s", event.File)
+	}
+}
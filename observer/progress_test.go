@@ -0,0 +1,52 @@
+package observer
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"go-migrations/database"
+	"go-migrations/internal/direction"
+)
+
+func TestProgress(t *testing.T) {
+	var buffer bytes.Buffer
+	observer := NewProgress(&buffer)
+
+	observer.Notify(database.Event{Type: database.RunStarted, Direction: direction.Down, Total: 2})
+	observer.Notify(database.Event{Type: database.MigrationFinished, Direction: direction.Down})
+	observer.Notify(database.Event{Type: database.MigrationFinished, Direction: direction.Down})
+	tracker := observer.tracker
+	observer.Notify(database.Event{Type: database.RunFinished, Direction: direction.Down})
+
+	if tracker.Total != 2 || tracker.PercentDone() != 100 {
+		t.Errorf("Expected the tracker to count 2 of 2, but got %v", tracker)
+	}
+	if !tracker.IsDone() {
+		t.Errorf("Expected the tracker to be done")
+	}
+	if observer.pw.IsRenderInProgress() {
+		t.Errorf("Expected the rendering to stop")
+	}
+	if !strings.Contains(buffer.String(), "Rolling back migrations") {
+		t.Errorf("Expected the progress bar to be rendered, but got %q", buffer.String())
+	}
+}
+
+func TestProgressWithoutRun(t *testing.T) {
+	var buffer bytes.Buffer
+	observer := NewProgress(&buffer)
+
+	// e.g. a migration applied outside of a run or a run finished twice
+	observer.Notify(database.Event{Type: database.MigrationFinished, Direction: direction.Up})
+	observer.Notify(database.Event{Type: database.RunFinished, Direction: direction.Up})
+
+	observer.Notify(database.Event{Type: database.RunStarted, Direction: direction.Up, Total: 1})
+	observer.Notify(database.Event{Type: database.RunFinished, Direction: direction.Up})
+	observer.Notify(database.Event{Type: database.MigrationFinished, Direction: direction.Up})
+	observer.Notify(database.Event{Type: database.RunFinished, Direction: direction.Up})
+
+	if observer.tracker != nil || observer.pw.IsRenderInProgress() {
+		t.Errorf("Expected no run in progress")
+	}
+}